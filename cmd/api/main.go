@@ -10,12 +10,28 @@ import (
 	"time"
 
 	"ynov-social-api/internal/api/handler"
+	"ynov-social-api/internal/api/middleware"
 	"ynov-social-api/internal/api/router"
 	"ynov-social-api/internal/config"
+	"ynov-social-api/internal/domain/loginattempt"
+	"ynov-social-api/internal/pkg/events"
 	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/pkg/mailer"
+	"ynov-social-api/internal/repository/memory"
 	"ynov-social-api/internal/repository/sqlite"
+	"ynov-social-api/internal/service/accesstoken"
+	"ynov-social-api/internal/service/account"
+	"ynov-social-api/internal/service/admin"
 	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/follow"
+	"ynov-social-api/internal/service/gateway"
+	"ynov-social-api/internal/service/invite"
+	"ynov-social-api/internal/service/lockout"
+	"ynov-social-api/internal/service/message"
+	"ynov-social-api/internal/service/notification"
+	"ynov-social-api/internal/service/oauth"
 	"ynov-social-api/internal/service/post"
+	"ynov-social-api/internal/service/session"
 	"ynov-social-api/internal/service/user"
 )
 
@@ -41,29 +57,133 @@ func main() {
 	// Initialize repositories
 	userRepo := sqlite.NewUserRepository(db.GetConn())
 	postRepo := sqlite.NewPostRepository(db.GetConn())
+	followRepo := sqlite.NewFollowRepository(db.GetConn())
+	sessionRepo := sqlite.NewSessionRepository(db.GetConn())
+	verificationRepo := sqlite.NewVerificationRepository(db.GetConn())
+	accessTokenRepo := sqlite.NewAccessTokenRepository(db.GetConn())
+	oauthRepo := sqlite.NewOAuthRepository(db.GetConn())
+	auditRepo := sqlite.NewAuditRepository(db.GetConn())
+	inviteRepo := sqlite.NewInviteRepository(db.GetConn())
+	messageRepo := sqlite.NewMessageRepository(db.GetConn())
+	notificationRepo := sqlite.NewNotificationRepository(db.GetConn())
+
+	// Failed login attempts are kept in memory unless replicas have to share them
+	var loginAttemptRepo loginattempt.Repository
+	if cfg.Lockout.Store == "database" {
+		loginAttemptRepo = sqlite.NewLoginAttemptRepository(db.GetConn())
+	} else {
+		loginAttemptRepo = memory.NewLoginAttemptRepository()
+	}
+
+	// Initialize mailer
+	var mailTransport mailer.Mailer
+	if cfg.Mail.Driver == "smtp" {
+		mailTransport = mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	} else {
+		mailTransport = mailer.NewLogMailer(log, cfg.Mail.Dir, cfg.Mail.From)
+	}
+	mail := mailer.NewAsyncMailer(mailTransport, log)
+
+	// Events pushed to the real-time streams
+	broker := events.NewBroker(cfg.Stream.ReplayBuffer)
+	// Live connections of the WebSocket gateway
+	hub := events.NewHub()
+
+	// Load token signing keys
+	keyringOptions := auth.KeyringOptions{
+		Algorithm: cfg.JWT.Algorithm,
+		Secret:    cfg.JWT.Secret,
+		KeysDir:   cfg.JWT.KeysDir,
+		ActiveID:  cfg.JWT.ActiveKeyID,
+	}
+	keyring, err := auth.LoadKeyring(keyringOptions)
+	if err != nil {
+		log.Fatal("Failed to load signing keys: %v", err)
+	}
+
+	// Open the breached password dataset
+	passwordPolicyOptions := auth.PasswordPolicyOptions{
+		MinLength:   cfg.Password.MinLength,
+		MaxLength:   cfg.Password.MaxLength,
+		MinEntropy:  cfg.Password.MinEntropy,
+		BannedWords: cfg.Password.BannedWords,
+	}
+	if cfg.Password.BreachedPath != "" {
+		passwordPolicyOptions.Breached, err = auth.NewBreachedPasswords(cfg.Password.BreachedPath)
+		if err != nil {
+			log.Fatal("Failed to load breached passwords: %v", err)
+		}
+	}
 
 	// Initialize services
-	passwordService := auth.NewPasswordService()
-	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.TTL)
-	userService := user.NewService(userRepo, passwordService)
-	postService := post.NewService(postRepo)
+	passwordService := auth.NewPasswordService(auth.PasswordOptions{
+		Algorithm:         cfg.Password.Algorithm,
+		Argon2Memory:      uint32(cfg.Password.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Password.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Password.Argon2Parallelism),
+		BcryptCost:        cfg.Password.BcryptCost,
+	})
+	passwordPolicy := auth.NewPasswordPolicy(passwordPolicyOptions)
+	jwtService := auth.NewJWTService(keyring, cfg.JWT.TTL)
+	sessionService := session.NewService(sessionRepo, userRepo, jwtService, cfg.JWT.RefreshTTL)
+	totpService := auth.NewTOTPService(cfg.TwoFactor.Issuer)
+	lockoutService := lockout.NewService(loginAttemptRepo, auditRepo, cfg.Lockout.MaxAttempts, cfg.Lockout.IPMaxAttempts, cfg.Lockout.Duration)
+	inviteService := invite.NewService(inviteRepo, userRepo, cfg.Signup.InviteQuota)
+	userService := user.NewService(userRepo, passwordService, passwordPolicy, totpService, jwtService, lockoutService, inviteService, cfg.TwoFactor.ChallengeTTL, cfg.Signup.Mode)
+	notificationService := notification.NewService(notificationRepo, userRepo, broker)
+	postService := post.NewService(postRepo, userRepo, notificationService, broker, cfg.Account.RequireVerifiedEmail)
+	accountService := account.NewService(userRepo, verificationRepo, accessTokenRepo, postRepo, followRepo, passwordService, passwordPolicy, sessionService, lockoutService, mail, cfg.Account.BaseURL, cfg.Account.DeletionGracePeriod)
+	followService := follow.NewService(followRepo, userRepo, notificationService)
+	messageService := message.NewService(messageRepo, userRepo, hub)
+	gatewayService := gateway.NewService(hub, messageService, userRepo)
+	adminService := admin.NewService(userRepo, userService, postService, sessionService)
+	accessTokenService := accesstoken.NewService(accessTokenRepo, userRepo)
+	oauthService := oauth.NewService(oauthRepo, sessionService, jwtService, cfg.OAuth.CodeTTL)
+
+	// Create or promote the first admin account
+	if cfg.Admin.Email != "" {
+		bootstrapped, err := adminService.BootstrapAdmin(context.Background(), cfg.Admin.Email, cfg.Admin.Password)
+		if err != nil {
+			log.Fatal("Failed to bootstrap admin account: %v", err)
+		}
+		if bootstrapped {
+			log.Info("Admin account bootstrapped: %s", cfg.Admin.Email)
+		}
+	}
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(userService, jwtService, log)
+	authHandler := handler.NewAuthHandler(userService, sessionService, accountService, log)
 	postHandler := handler.NewPostHandler(postService, log)
+	userHandler := handler.NewUserHandler(userService, followService, accountService, log)
+	sessionHandler := handler.NewSessionHandler(sessionService, log)
+	accountHandler := handler.NewAccountHandler(accountService, log)
+	adminHandler := handler.NewAdminHandler(adminService, log)
+	accessTokenHandler := handler.NewAccessTokenHandler(accessTokenService, log)
+	inviteHandler := handler.NewInviteHandler(inviteService, log)
+	messageHandler := handler.NewMessageHandler(messageService, log)
+	notificationHandler := handler.NewNotificationHandler(notificationService, log)
+	streamHandler := handler.NewStreamHandler(broker, sessionService, cfg.Stream.HeartbeatInterval, log)
+	gatewayHandler := handler.NewGatewayHandler(gatewayService, sessionService, log)
+	oauthHandler := handler.NewOAuthHandler(oauthService, log)
+	jwksHandler := handler.NewJWKSHandler(jwtService)
 
 	// Initialize router
-	r := router.New(authHandler, postHandler, jwtService)
+	r := router.New(authHandler, postHandler, userHandler, sessionHandler, accountHandler, adminHandler, accessTokenHandler, inviteHandler, messageHandler, notificationHandler, streamHandler, gatewayHandler, oauthHandler, jwksHandler, jwtService, sessionService, accessTokenService)
 
 	// Configure HTTP server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
-		Handler:      r,
+		Handler:      middleware.RealIP(cfg.Server.TrustedProxies)(r),
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Shutdown waits for requests to complete: end the event streams, and close the
+	// WebSocket connections, which it does not track
+	srv.RegisterOnShutdown(broker.Close)
+	srv.RegisterOnShutdown(hub.Close)
+
 	// Start server in a goroutine
 	go func() {
 		log.Info("Starting server on port %s", cfg.Server.Port)
@@ -72,6 +192,28 @@ func main() {
 		}
 	}()
 
+	// Purge the accounts whose deletion grace period is over
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		runAccountPurge(purgeCtx, accountService, cfg.Account.PurgeInterval, log)
+	}()
+
+	// Reload signing keys on SIGHUP (key rotation without restart)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := keyring.Reload(keyringOptions); err != nil {
+				log.Error("Failed to reload signing keys: %v", err)
+				continue
+			}
+			active, _ := keyring.Active()
+			log.Info("Signing keys reloaded, active key: %s", active.ID)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -87,6 +229,41 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Error("Server forced to shutdown: %v", err)
 	}
+	if err := hub.Wait(ctx); err != nil {
+		log.Error("WebSocket connections forced to close: %v", err)
+	}
+
+	// Stop the purge job: an interrupted purge is rolled back and resumed at the next start
+	stopPurge()
+	<-purgeDone
+
+	// Let emails queued by the last requests go out
+	if err := mail.Wait(ctx); err != nil {
+		log.Error("Pending emails not sent: %v", err)
+	}
 
 	log.Info("Server stopped")
 }
+
+// runAccountPurge purges the accounts past their deletion grace period at startup and then
+// at every interval, until the context is cancelled
+func runAccountPurge(ctx context.Context, accountService *account.Service, interval time.Duration, log *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := accountService.PurgeDeletedAccounts(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to purge deleted accounts: %v", err)
+		}
+		if purged > 0 {
+			log.Info("Purged %d deleted account(s)", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
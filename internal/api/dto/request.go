@@ -2,8 +2,10 @@ package dto
 
 // SignupRequest represents the signup request payload
 type SignupRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	Handle     string `json:"handle,omitempty"`
+	InviteCode string `json:"inviteCode,omitempty"` // required when registration is invite-only
 }
 
 // LoginRequest represents the login request payload
@@ -12,7 +14,153 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// TwoFactorLoginRequest represents the second step of a login with two-factor authentication
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"` // TOTP code or recovery code
+}
+
+// TwoFactorCodeRequest represents a request confirmed by a TOTP (or recovery) code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// ForgotPasswordRequest represents the password reset request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest represents the new password payload, authorized by a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ChangePasswordRequest represents the change password payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// ChangeEmailRequest represents the change email payload
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewEmail        string `json:"newEmail"`
+}
+
+// DeleteAccountRequest represents the account deletion payload
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+}
+
+// VerifyEmailRequest represents the email verification payload
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// RefreshTokenRequest represents the refresh token request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
 // CreatePostRequest represents the create post request payload
 type CreatePostRequest struct {
 	Content string `json:"content"`
 }
+
+// UpdatePostRequest represents the update post request payload
+type UpdatePostRequest struct {
+	Content string `json:"content"`
+}
+
+// UpdateProfileRequest represents the update profile request payload (omitted fields are left untouched)
+type UpdateProfileRequest struct {
+	Handle      *string `json:"handle"`
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatarUrl"`
+}
+
+// AssignRoleRequest represents the role assignment payload
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// CreateAccessTokenRequest represents the personal access token creation payload
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"` // 0 for a token that never expires
+}
+
+// SendMessageRequest represents the direct message payload
+type SendMessageRequest struct {
+	Recipient string `json:"recipient"` // handle of the recipient
+	Subject   string `json:"subject"`
+	Content   string `json:"content"`
+}
+
+// UpdateMessageRequest represents the message update payload
+type UpdateMessageRequest struct {
+	Status string `json:"status"` // "lu" (read) or "non-lu" (unread)
+}
+
+// CreateConversationRequest represents the group conversation creation payload
+type CreateConversationRequest struct {
+	Name         string   `json:"name"`
+	Participants []string `json:"participants"` // handles of the other members
+}
+
+// UpdateConversationRequest represents the group conversation update payload
+type UpdateConversationRequest struct {
+	Name string `json:"name"`
+}
+
+// AddMembersRequest represents the payload adding members to a group conversation
+type AddMembersRequest struct {
+	Participants []string `json:"participants"` // handles of the new members
+}
+
+// ConversationMessageRequest represents the payload of a message sent in a conversation
+type ConversationMessageRequest struct {
+	Subject string `json:"subject"`
+	Content string `json:"content"`
+}
+
+// MarkConversationReadRequest represents the payload marking a conversation as read
+type MarkConversationReadRequest struct {
+	MessageID string `json:"messageId"` // last message read, defaults to the latest one
+}
+
+// MarkNotificationsReadRequest represents the payload marking notifications as read
+type MarkNotificationsReadRequest struct {
+	Cursor int64 `json:"cursor"` // cursor of the last notification read, defaults to all of them
+}
+
+// GatewayCommand represents a command sent by a client over the WebSocket gateway
+type GatewayCommand struct {
+	Type           string `json:"type"`                     // "presence", "typing" or "ack"
+	Status         string `json:"status,omitempty"`         // presence: "online" or "away"
+	ConversationID string `json:"conversationId,omitempty"` // typing
+	Typing         *bool  `json:"typing,omitempty"`         // typing: false when the user stopped, defaults to true
+	MessageID      string `json:"messageId,omitempty"`      // ack: message delivered to the client
+}
+
+// CreateInviteRequest represents the invite creation payload
+type CreateInviteRequest struct {
+	MaxUses       int `json:"maxUses"`       // defaults to 1
+	ExpiresInDays int `json:"expiresInDays"` // defaults to 7
+}
+
+// CreateOAuthClientRequest represents the OAuth client registration payload
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"` // false for public clients (mobile or single-page apps), which get no secret
+}
+
+// AuthorizeDecisionRequest represents the user's answer to an OAuth consent prompt
+type AuthorizeDecisionRequest struct {
+	Approve bool `json:"approve"`
+}
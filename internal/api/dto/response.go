@@ -2,16 +2,125 @@ package dto
 
 // TokenResponse represents the authentication token response
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"` // access token lifetime in seconds
+}
+
+// TwoFactorChallengeResponse represents the login response when a second factor is required
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+	ExpiresIn         int64  `json:"expiresIn"` // challenge lifetime in seconds
+}
+
+// TwoFactorStatusResponse represents the authenticated user's two-factor settings
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// TwoFactorEnrollmentResponse represents a pending TOTP enrollment
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// RecoveryCodesResponse represents newly issued two-factor recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// JWKSResponse represents a JSON Web Key Set (RFC 7517)
+type JWKSResponse struct {
+	Keys []JWKResponse `json:"keys"`
+}
+
+// JWKResponse represents a public JSON Web Key
+type JWKResponse struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// SessionResponse represents an active login session in API responses
+type SessionResponse struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	IPAddress  string `json:"ipAddress"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastSeenAt int64  `json:"lastSeenAt"`
+	Current    bool   `json:"current"` // whether this is the session making the request
+	// ClientID and Scopes are set on sessions granted to an OAuth client
+	ClientID string   `json:"clientId,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
 // PostResponse represents a post in API responses
 type PostResponse struct {
-	ID         string `json:"id"`
-	Author     string `json:"author"`
+	ID            string                `json:"id"`
+	Author        string                `json:"author"`
+	AuthorProfile AuthorProfileResponse `json:"authorProfile"`
+	Content       string                `json:"content"`
+	ParentID      string                `json:"parentId,omitempty"`
+	RootID        string                `json:"rootId,omitempty"`
+	CreatedAt     int64                 `json:"createdAt"`
+	UpdatedAt     int64                 `json:"updatedAt"`
+	LikesCount    int                   `json:"likesCount"`
+	RepliesCount  int                   `json:"repliesCount"`
+	LikedByMe     bool                  `json:"likedByMe"`
+	Deleted       bool                  `json:"deleted,omitempty"`
+}
+
+// ThreadNodeResponse represents a post and its loaded replies in a conversation tree
+type ThreadNodeResponse struct {
+	PostResponse
+	Replies        []ThreadNodeResponse `json:"replies"`
+	HasMoreReplies bool                 `json:"hasMoreReplies"`
+}
+
+// ThreadResponse represents a conversation tree in API responses
+type ThreadResponse struct {
+	Ancestors []PostResponse     `json:"ancestors"`
+	Post      ThreadNodeResponse `json:"post"`
+}
+
+// AuthorProfileResponse represents the public profile of a post's author
+type AuthorProfileResponse struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	AvatarURL   string `json:"avatarUrl"`
+	PostsCount  int    `json:"postsCount"`
+}
+
+// PublicProfileResponse represents a user's public profile
+type PublicProfileResponse struct {
+	ID          string `json:"id"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatarUrl"`
+}
+
+// ProfileResponse represents the authenticated user's own profile
+type ProfileResponse struct {
+	Email            string `json:"email"`
+	EmailVerified    bool   `json:"emailVerified"`
+	TwoFactorEnabled bool   `json:"twoFactorEnabled"`
+	Role             string `json:"role"`
+	PublicProfileResponse
+}
+
+// PostRevisionResponse represents a previous version of a post in API responses
+type PostRevisionResponse struct {
 	Content    string `json:"content"`
 	CreatedAt  int64  `json:"createdAt"`
-	LikesCount int    `json:"likesCount"`
+	ReplacedAt int64  `json:"replacedAt"`
 }
 
 // LikesCountResponse represents the likes count response
@@ -19,6 +128,254 @@ type LikesCountResponse struct {
 	LikesCount int `json:"likesCount"`
 }
 
+// PostLikesResponse represents the likes count of a post in stream events
+type PostLikesResponse struct {
+	PostID     string `json:"postId"`
+	LikesCount int    `json:"likesCount"`
+}
+
+// FollowResponse represents a user in follower/following lists
+type FollowResponse struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	AvatarURL   string `json:"avatarUrl"`
+	FollowedAt  int64  `json:"followedAt"`
+}
+
+// MessageResponse represents a direct message in API responses
+type MessageResponse struct {
+	ID             string              `json:"id"`
+	ConversationID string              `json:"conversationId"`
+	Sender         string              `json:"sender"`              // handle of the sender
+	Recipient      string              `json:"recipient,omitempty"` // handle of the recipient, omitted in group conversations
+	SenderProfile  ParticipantResponse `json:"senderProfile"`
+	Subject        string              `json:"subject"`
+	Content        string              `json:"content"`
+	// Status is "lu" once the recipient read the message, "non-lu" before. In group
+	// conversations, it tells whether the viewer read the message.
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"createdAt"`
+	ReadAt    int64  `json:"readAt,omitempty"` // read receipt, omitted while unread and in group conversations
+}
+
+// ParticipantResponse represents the public profile of a conversation participant or of a
+// notification actor
+type ParticipantResponse struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	AvatarURL   string `json:"avatarUrl"`
+}
+
+// ConversationMemberResponse represents a member of a conversation
+type ConversationMemberResponse struct {
+	ParticipantResponse
+	Role     string `json:"role,omitempty"` // "admin" or "member", group conversations only
+	JoinedAt int64  `json:"joinedAt"`
+}
+
+// ConversationResponse represents a conversation in API responses
+type ConversationResponse struct {
+	ID            string                       `json:"id"`
+	IsGroup       bool                         `json:"isGroup"`
+	Name          string                       `json:"name,omitempty"`
+	Participants  []ConversationMemberResponse `json:"participants"`
+	LastMessage   *MessageResponse             `json:"lastMessage,omitempty"`
+	UnreadCount   int                          `json:"unreadCount"`
+	CreatedAt     int64                        `json:"createdAt"`
+	LastMessageAt int64                        `json:"lastMessageAt"`
+}
+
+// UnreadCountResponse represents the number of unread messages or notifications
+type UnreadCountResponse struct {
+	Count int `json:"count"`
+}
+
+// NotificationResponse represents a notification in API responses
+type NotificationResponse struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`             // "like", "reply", "mention" or "follow"
+	PostID string `json:"postId,omitempty"` // omitted for follows
+	// Message summarizes the notification, e.g. "@alice and 4 others liked your post"
+	Message string `json:"message"`
+	// Actors are the latest users behind the notification, ActorsCount counts all of them
+	Actors      []ParticipantResponse `json:"actors"`
+	ActorsCount int                   `json:"actorsCount"`
+	Cursor      int64                 `json:"cursor"` // to mark notifications as read up to this one
+	Read        bool                  `json:"read"`
+	CreatedAt   int64                 `json:"createdAt"`
+	UpdatedAt   int64                 `json:"updatedAt"`
+	ReadAt      int64                 `json:"readAt,omitempty"`
+}
+
+// GatewayEvent represents an event pushed over the WebSocket gateway
+type GatewayEvent struct {
+	// Type is "ready", "message", "typing", "presence", "delivered" or "error"
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// GatewayReadyResponse represents the state sent when a client connects to the gateway
+type GatewayReadyResponse struct {
+	Presence []PresenceResponse `json:"presence"` // connected contacts
+}
+
+// PresenceResponse represents the presence of a contact
+type PresenceResponse struct {
+	Handle string `json:"handle"`
+	Status string `json:"status"` // "online", "away" or "offline"
+}
+
+// TypingResponse represents a member typing in a conversation
+type TypingResponse struct {
+	ConversationID string `json:"conversationId"`
+	Handle         string `json:"handle"`
+	Typing         bool   `json:"typing"`
+}
+
+// DeliveryResponse represents the delivery of a message to one of its recipients
+type DeliveryResponse struct {
+	MessageID      string `json:"messageId"`
+	ConversationID string `json:"conversationId"`
+	Handle         string `json:"handle"` // recipient the message was delivered to
+	DeliveredAt    int64  `json:"deliveredAt"`
+}
+
+// AccountDeletionResponse represents a scheduled account deletion
+type AccountDeletionResponse struct {
+	DeletionScheduledAt int64 `json:"deletionScheduledAt"`
+}
+
+// ExportResponse represents the personal data of a user in a data export
+type ExportResponse struct {
+	ExportedAt int64                `json:"exportedAt"`
+	Profile    ProfileResponse      `json:"profile"`
+	Posts      []ExportPostResponse `json:"posts"`
+	Likes      []ExportLikeResponse `json:"likes"`
+	Following  []FollowResponse     `json:"following"`
+	Followers  []FollowResponse     `json:"followers"`
+}
+
+// ExportPostResponse represents a post written by the user in a data export
+type ExportPostResponse struct {
+	ID         string                 `json:"id"`
+	ParentID   string                 `json:"parentId,omitempty"`
+	Content    string                 `json:"content"`
+	CreatedAt  int64                  `json:"createdAt"`
+	UpdatedAt  int64                  `json:"updatedAt"`
+	LikesCount int                    `json:"likesCount"`
+	History    []PostRevisionResponse `json:"history,omitempty"`
+}
+
+// ExportLikeResponse represents a post liked by the user in a data export
+type ExportLikeResponse struct {
+	PostID  string `json:"postId"`
+	Author  string `json:"author"`
+	Content string `json:"content"`
+}
+
+// AdminUserResponse represents a user in admin endpoint responses
+type AdminUserResponse struct {
+	ID          string `json:"id"`
+	Handle      string `json:"handle"`
+	Role        string `json:"role"`
+	SuspendedAt int64  `json:"suspendedAt,omitempty"` // 0 (omitted) unless the account is suspended
+	InvitedBy   string `json:"invitedBy,omitempty"`   // ID of the user whose invite was used to register
+}
+
+// AccessTokenResponse represents a personal access token in API responses (without its value)
+type AccessTokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"createdAt"`
+	LastUsedAt int64    `json:"lastUsedAt,omitempty"` // omitted until the token is used
+	ExpiresAt  int64    `json:"expiresAt,omitempty"`  // omitted if the token never expires
+}
+
+// CreatedAccessTokenResponse represents a newly created personal access token, the only
+// response carrying its value
+type CreatedAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// InviteResponse represents an invite in API responses (without its code)
+type InviteResponse struct {
+	ID        string   `json:"id"`
+	CreatedBy string   `json:"createdBy"`
+	MaxUses   int      `json:"maxUses"`
+	Uses      int      `json:"uses"`
+	Active    bool     `json:"active"`   // whether the invite can still be used
+	Invitees  []string `json:"invitees"` // handles of the users who registered with the invite
+	CreatedAt int64    `json:"createdAt"`
+	ExpiresAt int64    `json:"expiresAt"`
+	RevokedAt int64    `json:"revokedAt,omitempty"`
+}
+
+// CreatedInviteResponse represents a newly created invite, the only response carrying its code
+type CreatedInviteResponse struct {
+	InviteResponse
+	Code string `json:"code"`
+}
+
+// OAuthClientResponse represents an OAuth client in API responses (without its secret)
+type OAuthClientResponse struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"`
+	CreatedAt    int64    `json:"createdAt"`
+}
+
+// CreatedOAuthClientResponse represents a newly registered OAuth client, the only response
+// carrying its secret
+type CreatedOAuthClientResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"clientSecret,omitempty"` // omitted for public clients
+}
+
+// AuthorizationPromptResponse describes what the user is asked to consent to
+type AuthorizationPromptResponse struct {
+	ClientID   string   `json:"clientId"`
+	ClientName string   `json:"clientName"`
+	Scopes     []string `json:"scopes"`
+	Consented  bool     `json:"consented"` // the user already granted these scopes to the client
+}
+
+// AuthorizeResponse tells the client application where to send the user after consent
+type AuthorizeResponse struct {
+	RedirectURI string `json:"redirectUri"`
+}
+
+// OAuthTokenResponse represents the token endpoint response (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthIntrospectionResponse represents the token introspection response (RFC 7662 section 2.2)
+type OAuthIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+}
+
+// OAuthErrorResponse represents an OAuth error (RFC 6749 section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+	// RedirectURI carries the error to the client on authorization requests, once the redirect URI is trusted
+	RedirectURI string `json:"redirect_uri,omitempty"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Status           int               `json:"status"`
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	accessTokenService "ynov-social-api/internal/service/accesstoken"
+)
+
+// AccessTokenHandler handles personal access token management endpoints
+type AccessTokenHandler struct {
+	tokenService *accessTokenService.Service
+	logger       *logger.Logger
+}
+
+// NewAccessTokenHandler creates a new access token handler
+func NewAccessTokenHandler(tokenService *accessTokenService.Service, logger *logger.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+// HandleTokens handles the token collection: GET /tokens lists the current user's tokens,
+// POST /tokens creates one
+func (h *AccessTokenHandler) HandleTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTokens(w, r)
+	case http.MethodPost:
+		h.createToken(w, r)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// HandleTokenAction handles single token routes (DELETE /tokens/{id})
+func (h *AccessTokenHandler) HandleTokenAction(w http.ResponseWriter, r *http.Request) {
+	tokenID, ok := pathParam(r, "/tokens/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.tokenService.Revoke(r.Context(), userID, tokenID); err != nil {
+		h.logger.Error("Failed to revoke access token: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// listTokens handles listing of the current user's tokens
+func (h *AccessTokenHandler) listTokens(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	tokens, err := h.tokenService.List(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list access tokens: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	resp := make([]dto.AccessTokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		resp = append(resp, mapAccessTokenToDTO(t))
+	}
+
+	response.OK(w, resp)
+}
+
+// createToken handles token creation. The token value is only returned in this response.
+func (h *AccessTokenHandler) createToken(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var req dto.CreateAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	rawToken, t, err := h.tokenService.Create(r.Context(), userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		h.logger.Error("Failed to create access token: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, dto.CreatedAccessTokenResponse{
+		AccessTokenResponse: mapAccessTokenToDTO(t),
+		Token:               rawToken,
+	})
+}
+
+// mapAccessTokenToDTO maps an access token domain model to a DTO
+func mapAccessTokenToDTO(t *accesstoken.Token) dto.AccessTokenResponse {
+	resp := dto.AccessTokenResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+	if !t.LastUsedAt.IsZero() {
+		resp.LastUsedAt = t.LastUsedAt.Unix()
+	}
+	if !t.ExpiresAt.IsZero() {
+		resp.ExpiresAt = t.ExpiresAt.Unix()
+	}
+	return resp
+}
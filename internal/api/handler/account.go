@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/service/account"
+)
+
+// AccountHandler handles password reset and email verification endpoints
+type AccountHandler struct {
+	accountService *account.Service
+	logger         *logger.Logger
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(accountService *account.Service, logger *logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// ForgotPassword handles password reset requests
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	var req dto.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(r.Context(), req.Email, middleware.ClientIP(r)); err != nil {
+		h.logger.Error("Failed to request password reset: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	// Same response whether or not the account exists
+	response.NoContent(w)
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	var req dto.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		h.logger.Error("Failed to reset password: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// VerifyEmail handles email address verification with a verification token
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	var req dto.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), req.Token); err != nil {
+		h.logger.Error("Failed to verify email: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// ResendVerification handles sending a new verification email to the authenticated user
+func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.accountService.SendEmailVerification(r.Context(), userID); err != nil {
+		h.logger.Error("Failed to send verification email: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
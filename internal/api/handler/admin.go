@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/service/admin"
+)
+
+// AdminHandler handles moderation and role management endpoints.
+// Permissions are checked by the router with middleware.RequirePermission.
+type AdminHandler struct {
+	adminService *admin.Service
+	logger       *logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService *admin.Service, logger *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		logger:       logger,
+	}
+}
+
+// HandleSuspension handles user suspension: POST /admin/suspensions/{handle} suspends the
+// account, DELETE lifts the suspension
+func (h *AdminHandler) HandleSuspension(w http.ResponseWriter, r *http.Request) {
+	handle, ok := pathParam(r, "/admin/suspensions/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	actorID := middleware.CurrentUser(r).ID
+
+	var u *user.User
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		u, err = h.adminService.SuspendUser(r.Context(), actorID, handle)
+	case http.MethodDelete:
+		u, err = h.adminService.UnsuspendUser(r.Context(), actorID, handle)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to update suspension: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapAdminUserToDTO(u))
+}
+
+// HandleRole handles role assignment: PUT /admin/roles/{handle}
+func (h *AdminHandler) HandleRole(w http.ResponseWriter, r *http.Request) {
+	handle, ok := pathParam(r, "/admin/roles/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	var req dto.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	u, err := h.adminService.AssignRole(r.Context(), middleware.CurrentUser(r).ID, handle, req.Role)
+	if err != nil {
+		h.logger.Error("Failed to assign role: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapAdminUserToDTO(u))
+}
+
+// HandlePostTakedown handles the removal of any post: DELETE /admin/posts/{id}
+func (h *AdminHandler) HandlePostTakedown(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathParam(r, "/admin/posts/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err := h.adminService.TakedownPost(r.Context(), postID); err != nil {
+		h.logger.Error("Failed to take down post: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// pathParam extracts the single path segment following the given prefix
+func pathParam(r *http.Request, prefix string) (string, bool) {
+	param := strings.TrimPrefix(r.URL.Path, prefix)
+	if param == "" || strings.Contains(param, "/") {
+		return "", false
+	}
+	return param, true
+}
+
+// mapAdminUserToDTO maps a user domain model to the DTO returned by admin endpoints
+func mapAdminUserToDTO(u *user.User) dto.AdminUserResponse {
+	resp := dto.AdminUserResponse{
+		ID:     u.ID,
+		Handle: u.Handle,
+		Role:   string(u.Role),
+	}
+	if u.IsSuspended() {
+		resp.SuspendedAt = u.SuspendedAt.Unix()
+	}
+	resp.InvitedBy = u.InvitedBy
+	return resp
+}
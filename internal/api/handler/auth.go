@@ -5,26 +5,31 @@ import (
 	"net/http"
 
 	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
 	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/user"
 	"ynov-social-api/internal/pkg/apperrors"
 	"ynov-social-api/internal/pkg/logger"
-	"ynov-social-api/internal/service/auth"
-	"ynov-social-api/internal/service/user"
+	"ynov-social-api/internal/service/account"
+	"ynov-social-api/internal/service/session"
+	userService "ynov-social-api/internal/service/user"
 )
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	userService *user.Service
-	jwtService  *auth.JWTService
-	logger      *logger.Logger
+	userService    *userService.Service
+	sessionService *session.Service
+	accountService *account.Service
+	logger         *logger.Logger
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(userService *user.Service, jwtService *auth.JWTService, logger *logger.Logger) *AuthHandler {
+func NewAuthHandler(userService *userService.Service, sessionService *session.Service, accountService *account.Service, logger *logger.Logger) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		jwtService:  jwtService,
-		logger:      logger,
+		userService:    userService,
+		sessionService: sessionService,
+		accountService: accountService,
+		logger:         logger,
 	}
 }
 
@@ -41,12 +46,18 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.Register(r.Context(), req.Email, req.Password); err != nil {
+	u, err := h.userService.Register(r.Context(), req.Email, req.Password, req.Handle, req.InviteCode)
+	if err != nil {
 		h.logger.Error("Failed to register user: %v", err)
 		response.Error(w, err)
 		return
 	}
 
+	// The account is usable right away; the user can ask for a new link if this one is lost
+	if err := h.accountService.SendEmailVerification(r.Context(), u.ID); err != nil {
+		h.logger.Error("Failed to send verification email: %v", err)
+	}
+
 	response.NoContent(w)
 }
 
@@ -63,19 +74,145 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	email, err := h.userService.Authenticate(r.Context(), req.Email, req.Password)
+	u, err := h.userService.Authenticate(r.Context(), req.Email, req.Password, middleware.ClientIP(r))
 	if err != nil {
 		h.logger.Error("Failed to authenticate user: %v", err)
 		response.Error(w, err)
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(email)
+	// The session only starts once the second factor is checked by LoginTwoFactor
+	if u.TOTPEnabled {
+		challenge, err := h.userService.IssueTwoFactorChallenge(u)
+		if err != nil {
+			h.logger.Error("Failed to issue two-factor challenge: %v", err)
+			response.Error(w, err)
+			return
+		}
+
+		response.OK(w, dto.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge.Token,
+			ExpiresIn:         int64(challenge.ExpiresIn.Seconds()),
+		})
+		return
+	}
+
+	h.startSession(w, r, u)
+}
+
+// LoginTwoFactor handles the second step of a login with two-factor authentication
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	var req dto.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	u, err := h.userService.CompleteTwoFactorLogin(r.Context(), req.ChallengeToken, req.Code, middleware.ClientIP(r))
+	if err != nil {
+		h.logger.Error("Failed to complete two-factor login: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	h.startSession(w, r, u)
+}
+
+// startSession starts a session for a user who completed the login and writes its tokens.
+// Logging in during the grace period of an account deletion cancels it.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, u *user.User) {
+	tokens, err := h.sessionService.Start(r.Context(), u.ID, r.UserAgent(), middleware.ClientIP(r))
 	if err != nil {
-		h.logger.Error("Failed to generate token: %v", err)
-		response.Error(w, apperrors.ErrInternalServer)
+		h.logger.Error("Failed to start session: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	if err := h.accountService.CancelDeletion(r.Context(), u); err != nil {
+		h.logger.Error("Failed to cancel account deletion: %v", err)
+	}
+
+	response.OK(w, mapTokenPairToDTO(tokens))
+}
+
+// Refresh handles refresh token rotation
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
 		return
 	}
 
-	response.OK(w, dto.TokenResponse{Token: token})
+	var req dto.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(r.Context(), req.RefreshToken, r.UserAgent(), middleware.ClientIP(r))
+	if err != nil {
+		h.logger.Error("Failed to refresh token: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapTokenPairToDTO(tokens))
+}
+
+// Logout handles revocation of the current session
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	sessionID := middleware.GetSessionID(r)
+	if sessionID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.sessionService.Logout(r.Context(), sessionID); err != nil {
+		h.logger.Error("Failed to logout: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// LogoutAll handles revocation of every session of the current user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.sessionService.LogoutAll(r.Context(), userID); err != nil {
+		h.logger.Error("Failed to logout from all sessions: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// mapTokenPairToDTO maps a session token pair to DTO
+func mapTokenPairToDTO(tokens *session.TokenPair) dto.TokenResponse {
+	return dto.TokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+	}
 }
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/message"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/pkg/websocket"
+	gatewayService "ynov-social-api/internal/service/gateway"
+	"ynov-social-api/internal/service/session"
+)
+
+// Gateway connection settings
+const (
+	// gatewayPingInterval is how often clients are pinged, to keep the connection open through
+	// proxies and detect departed clients
+	gatewayPingInterval = 30 * time.Second
+	// gatewayReadTimeout is how long a client may stay silent, pongs included
+	gatewayReadTimeout = 2 * gatewayPingInterval
+	// gatewayMaxCommandSize is the maximum size of a command sent by a client
+	gatewayMaxCommandSize = 4096
+)
+
+// Gateway commands sent by clients
+const (
+	gatewayCommandPresence = "presence"
+	gatewayCommandTyping   = "typing"
+	gatewayCommandAck      = "ack"
+)
+
+// Gateway events that are not pushed through the hub
+const (
+	gatewayReadyEvent = "ready" // sent once connected, with the presence of the contacts
+	gatewayErrorEvent = "error" // a command failed
+)
+
+// GatewayHandler handles the WebSocket gateway
+type GatewayHandler struct {
+	gatewayService *gatewayService.Service
+	sessionService *session.Service
+	logger         *logger.Logger
+}
+
+// NewGatewayHandler creates a new gateway handler
+func NewGatewayHandler(gatewayService *gatewayService.Service, sessionService *session.Service, logger *logger.Logger) *GatewayHandler {
+	return &GatewayHandler{
+		gatewayService: gatewayService,
+		sessionService: sessionService,
+		logger:         logger,
+	}
+}
+
+// Connect handles GET /ws, upgrading the connection to a WebSocket. The server pushes new
+// messages, typing indicators, the presence of contacts and delivery acknowledgements as
+// JSON events; clients send presence, typing and ack commands. The connection is closed when
+// the access token expires, or at the next ping once the session is revoked.
+func (h *GatewayHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r, gatewayMaxCommandSize)
+	if err != nil {
+		var handshakeErr *websocket.HandshakeError
+		if errors.As(err, &handshakeErr) {
+			response.Error(w, apperrors.New(handshakeErr.Status, handshakeErr.Message))
+			return
+		}
+		h.logger.Error("Failed to upgrade to WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The connection is hijacked: the request context is no longer canceled on shutdown, the
+	// hub closes the connection instead
+	ctx := r.Context()
+	client, presences, err := h.gatewayService.Connect(ctx, userID)
+	if err != nil {
+		if errors.Is(err, events.ErrHubClosed) {
+			_ = conn.WriteClose(websocket.CloseGoingAway, "server shutting down")
+			return
+		}
+		h.logger.Error("Failed to connect to the gateway: %v", err)
+		_ = conn.WriteClose(websocket.CloseInternalError, "")
+		return
+	}
+	defer h.gatewayService.Disconnect(ctx, client)
+
+	conn.SetReadTimeout(gatewayReadTimeout)
+
+	ready := dto.GatewayReadyResponse{Presence: make([]dto.PresenceResponse, 0, len(presences))}
+	for _, presence := range presences {
+		ready.Presence = append(ready.Presence, dto.PresenceResponse{Handle: presence.Handle, Status: presence.Status})
+	}
+	if err := h.write(conn, dto.GatewayEvent{Type: gatewayReadyEvent, Data: ready}); err != nil {
+		return
+	}
+
+	// Events are written by their own goroutine while this one reads commands, until the
+	// connection closes
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.writeEvents(ctx, conn, client, middleware.GetSessionID(r), middleware.GetTokenExpiry(r), stop)
+	}()
+
+	h.readCommands(ctx, conn, client)
+	close(stop)
+	<-done
+}
+
+// readCommands runs the commands sent by the client until the connection closes
+func (h *GatewayHandler) readCommands(ctx context.Context, conn *websocket.Conn, client *gatewayService.Connection) {
+	for {
+		messageType, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.TextMessage {
+			_ = conn.WriteClose(websocket.CloseUnsupportedData, "commands must be JSON text messages")
+			continue
+		}
+
+		var cmd dto.GatewayCommand
+		if json.Unmarshal(payload, &cmd) != nil {
+			err = apperrors.New(http.StatusBadRequest, "invalid JSON")
+		} else {
+			err = h.run(ctx, client, cmd)
+		}
+
+		if err != nil {
+			if _, ok := apperrors.AsAppError(err); !ok {
+				h.logger.Error("Failed to run gateway command: %v", err)
+			}
+			if err := h.write(conn, mapGatewayErrorToDTO(err)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// run runs a command sent by the client
+func (h *GatewayHandler) run(ctx context.Context, client *gatewayService.Connection, cmd dto.GatewayCommand) error {
+	switch cmd.Type {
+	case gatewayCommandPresence:
+		return h.gatewayService.SetPresence(ctx, client, cmd.Status)
+	case gatewayCommandTyping:
+		typing := cmd.Typing == nil || *cmd.Typing
+		return h.gatewayService.Typing(ctx, client, cmd.ConversationID, typing)
+	case gatewayCommandAck:
+		return h.gatewayService.Ack(ctx, client, cmd.MessageID)
+	default:
+		return apperrors.NewValidationError(map[string]string{"type": "must be presence, typing or ack"})
+	}
+}
+
+// writeEvents pushes the events of the hub to the client and pings it, until stop is closed.
+// It closes the connection once the access token expires (at expiresAt, unless zero) or the
+// session is revoked.
+func (h *GatewayHandler) writeEvents(ctx context.Context, conn *websocket.Conn, client *gatewayService.Connection, sessionID string, expiresAt time.Time, stop <-chan struct{}) {
+	ping := time.NewTicker(gatewayPingInterval)
+	defer ping.Stop()
+
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	pending := client.Events()
+	// closeConn sends a close frame, then waits for the client to answer it
+	closeConn := func(code int, reason string) error {
+		pending, expired = nil, nil
+		ping.Stop()
+		return conn.WriteClose(code, reason)
+	}
+
+	for {
+		var err error
+		select {
+		case <-stop:
+			return
+		case event, ok := <-pending:
+			// The events are closed on shutdown, or when the client was too slow to keep up:
+			// it reconnects and reloads its conversations
+			if !ok {
+				if h.gatewayService.ShuttingDown() {
+					err = closeConn(websocket.CloseGoingAway, "server shutting down")
+				} else {
+					err = closeConn(websocket.CloseTryAgainLater, "too many pending events")
+				}
+				break
+			}
+			err = h.write(conn, mapGatewayEventToDTO(event))
+		case <-expired:
+			err = closeConn(websocket.ClosePolicyViolation, "token expired")
+		case <-ping.C:
+			// The session may have been revoked since the connection was opened (logout,
+			// password change, suspension)
+			if sessionErr := h.sessionService.ValidateSession(ctx, sessionID); sessionErr != nil {
+				if _, ok := apperrors.AsAppError(sessionErr); !ok {
+					h.logger.Error("Failed to validate gateway session: %v", sessionErr)
+				}
+				err = closeConn(websocket.ClosePolicyViolation, "session revoked")
+				break
+			}
+			err = conn.WritePing()
+		}
+
+		// The client is gone: stop reading too
+		if err != nil {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// write sends an event to the client
+func (h *GatewayHandler) write(conn *websocket.Conn, event dto.GatewayEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Failed to encode gateway event: %v", err)
+		return err
+	}
+
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// mapGatewayEventToDTO converts an event of the hub to its API representation
+func mapGatewayEventToDTO(event events.Event) dto.GatewayEvent {
+	resp := dto.GatewayEvent{Type: event.Type, Data: struct{}{}}
+	switch data := event.Data.(type) {
+	case *message.Message:
+		resp.Data = mapMessageToDTO(data)
+	case gatewayService.Typing:
+		resp.Data = dto.TypingResponse{ConversationID: data.ConversationID, Handle: data.Handle, Typing: data.Typing}
+	case gatewayService.Presence:
+		resp.Data = dto.PresenceResponse{Handle: data.Handle, Status: data.Status}
+	case gatewayService.Delivery:
+		resp.Data = dto.DeliveryResponse{
+			MessageID:      data.MessageID,
+			ConversationID: data.ConversationID,
+			Handle:         data.Handle,
+			DeliveredAt:    data.DeliveredAt.Unix(),
+		}
+	}
+	return resp
+}
+
+// mapGatewayErrorToDTO converts the error of a command to an error event, in the format of
+// the error responses of the API
+func mapGatewayErrorToDTO(err error) dto.GatewayEvent {
+	appErr, ok := apperrors.AsAppError(err)
+	if !ok {
+		appErr = apperrors.ErrInternalServer
+	}
+
+	return dto.GatewayEvent{
+		Type: gatewayErrorEvent,
+		Data: dto.ErrorResponse{
+			Status:           appErr.Code,
+			Message:          appErr.Message,
+			ValidationErrors: appErr.ValidationErrors,
+		},
+	}
+}
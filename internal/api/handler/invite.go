@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/invite"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	inviteService "ynov-social-api/internal/service/invite"
+)
+
+// InviteHandler handles invite code endpoints
+type InviteHandler struct {
+	inviteService *inviteService.Service
+	logger        *logger.Logger
+}
+
+// NewInviteHandler creates a new invite handler
+func NewInviteHandler(inviteService *inviteService.Service, logger *logger.Logger) *InviteHandler {
+	return &InviteHandler{
+		inviteService: inviteService,
+		logger:        logger,
+	}
+}
+
+// HandleInvites handles the invite collection: GET /invites lists the current user's invites,
+// POST /invites creates one
+func (h *InviteHandler) HandleInvites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listInvites(w, r)
+	case http.MethodPost:
+		h.createInvite(w, r)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// HandleInviteAction handles single invite routes (DELETE /invites/{id})
+func (h *InviteHandler) HandleInviteAction(w http.ResponseWriter, r *http.Request) {
+	inviteID, ok := pathParam(r, "/invites/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.inviteService.Revoke(r.Context(), userID, inviteID); err != nil {
+		h.logger.Error("Failed to revoke invite: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// HandleUserInvites lists the invites created by a user and who registered with them:
+// GET /admin/invites/{handle}
+func (h *InviteHandler) HandleUserInvites(w http.ResponseWriter, r *http.Request) {
+	handle, ok := pathParam(r, "/admin/invites/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	invites, err := h.inviteService.ListForUser(r.Context(), handle)
+	if err != nil {
+		h.logger.Error("Failed to list user invites: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapInvitesToDTO(invites))
+}
+
+// listInvites handles listing of the current user's invites
+func (h *InviteHandler) listInvites(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	invites, err := h.inviteService.List(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list invites: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapInvitesToDTO(invites))
+}
+
+// createInvite handles invite creation. The code is only returned in this response.
+func (h *InviteHandler) createInvite(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var req dto.CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	code, inv, err := h.inviteService.Create(r.Context(), userID, req.MaxUses, req.ExpiresInDays)
+	if err != nil {
+		h.logger.Error("Failed to create invite: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, dto.CreatedInviteResponse{
+		InviteResponse: mapInviteToDTO(inv),
+		Code:           code,
+	})
+}
+
+// mapInvitesToDTO maps invite domain models to DTOs
+func mapInvitesToDTO(invites []*invite.Invite) []dto.InviteResponse {
+	resp := make([]dto.InviteResponse, 0, len(invites))
+	for _, inv := range invites {
+		resp = append(resp, mapInviteToDTO(inv))
+	}
+	return resp
+}
+
+// mapInviteToDTO maps an invite domain model to a DTO
+func mapInviteToDTO(inv *invite.Invite) dto.InviteResponse {
+	resp := dto.InviteResponse{
+		ID:        inv.ID,
+		CreatedBy: inv.CreatedBy,
+		MaxUses:   inv.MaxUses,
+		Uses:      inv.Uses,
+		Active:    inv.IsActive(),
+		Invitees:  inv.Invitees,
+		CreatedAt: inv.CreatedAt.Unix(),
+		ExpiresAt: inv.ExpiresAt.Unix(),
+	}
+	if resp.Invitees == nil {
+		resp.Invitees = []string{}
+	}
+	if inv.IsRevoked() {
+		resp.RevokedAt = inv.RevokedAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/service/auth"
+)
+
+// JWKSHandler publishes the public token verification keys
+type JWKSHandler struct {
+	jwtService *auth.JWTService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtService *auth.JWTService) *JWKSHandler {
+	return &JWKSHandler{
+		jwtService: jwtService,
+	}
+}
+
+// JWKS handles the /.well-known/jwks.json endpoint
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	keys := h.jwtService.Keyring().PublicKeys()
+	resp := dto.JWKSResponse{Keys: make([]dto.JWKResponse, 0, len(keys))}
+	for _, key := range keys {
+		jwk := dto.JWKResponse{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+		}
+
+		switch public := key.PublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		resp.Keys = append(resp.Keys, jwk)
+	}
+
+	// Gateways may cache the key set, but not for longer than a rotation takes to propagate
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.OK(w, resp)
+}
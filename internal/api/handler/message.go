@@ -0,0 +1,559 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/message"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	messageService "ynov-social-api/internal/service/message"
+)
+
+// Message read statuses, as named by the messaging API
+const (
+	messageStatusRead   = "lu"
+	messageStatusUnread = "non-lu"
+)
+
+// MessageHandler handles messaging endpoints
+type MessageHandler struct {
+	messageService *messageService.Service
+	logger         *logger.Logger
+}
+
+// NewMessageHandler creates a new message handler
+func NewMessageHandler(messageService *messageService.Service, logger *logger.Logger) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+		logger:         logger,
+	}
+}
+
+// HandleMessages handles the message collection: GET /messages lists received messages
+// (optionally filtered with ?status=lu|non-lu), POST /messages sends one
+func (h *MessageHandler) HandleMessages(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listMessages(w, r, userID)
+	case http.MethodPost:
+		h.sendMessage(w, r, userID)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// HandleMessageAction handles single message routes (/messages/{id}), read status changes
+// (/messages/{id}/mark-as-read, /messages/{id}/mark-as-unread) and the unread count
+// (/messages/status/unread)
+func (h *MessageHandler) HandleMessageAction(w http.ResponseWriter, r *http.Request) {
+	// Parse URL: /messages/{id} or /messages/{id}/{action}
+	path := strings.TrimPrefix(r.URL.Path, "/messages/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) > 2 || parts[0] == "" {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if path == "status/unread" {
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.countUnread(w, r, userID)
+		return
+	}
+
+	messageID := parts[0]
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.getMessage(w, r, userID, messageID)
+		case http.MethodPut:
+			h.updateMessage(w, r, userID, messageID)
+		case http.MethodDelete:
+			h.deleteMessage(w, r, userID, messageID)
+		default:
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+		return
+	}
+
+	var read bool
+	switch parts[1] {
+	case "mark-as-read":
+		read = true
+	case "mark-as-unread":
+		read = false
+	default:
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodPatch {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	h.setRead(w, r, userID, messageID, read)
+}
+
+// HandleConversations handles the conversation collection: GET /conversations lists the
+// conversations of the current user, POST /conversations creates a group conversation
+func (h *MessageHandler) HandleConversations(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listConversations(w, r, userID)
+	case http.MethodPost:
+		h.createConversation(w, r, userID)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// HandleConversationAction handles single conversation routes (/conversations/{id}), their
+// messages (/conversations/{id}/messages), members (/conversations/{id}/members[/{handle}])
+// and the leave and read actions (/conversations/{id}/leave, /conversations/{id}/read)
+func (h *MessageHandler) HandleConversationAction(w http.ResponseWriter, r *http.Request) {
+	// Parse URL: /conversations/{id}, /conversations/{id}/{action} or /conversations/{id}/members/{handle}
+	path := strings.TrimPrefix(r.URL.Path, "/conversations/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) > 3 || parts[0] == "" || (len(parts) == 3 && (parts[1] != "members" || parts[2] == "")) {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := parts[0]
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.getConversation(w, r, userID, conversationID)
+		case http.MethodPatch:
+			h.renameConversation(w, r, userID, conversationID)
+		default:
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+		return
+	}
+
+	if len(parts) == 3 {
+		if r.Method != http.MethodDelete {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.removeMember(w, r, userID, conversationID, parts[2])
+		return
+	}
+
+	switch parts[1] {
+	case "messages":
+		switch r.Method {
+		case http.MethodGet:
+			h.listConversationMessages(w, r, userID, conversationID)
+		case http.MethodPost:
+			h.sendConversationMessage(w, r, userID, conversationID)
+		default:
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+	case "members":
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.addMembers(w, r, userID, conversationID)
+	case "leave":
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.leaveConversation(w, r, userID, conversationID)
+	case "read":
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.markConversationRead(w, r, userID, conversationID)
+	default:
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+	}
+}
+
+// listConversations handles listing of the current user's conversations
+func (h *MessageHandler) listConversations(w http.ResponseWriter, r *http.Request, userID string) {
+	page, limit := parsePagination(r)
+
+	conversations, err := h.messageService.ListConversations(r.Context(), userID, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list conversations: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	resp := make([]dto.ConversationResponse, 0, len(conversations))
+	for _, c := range conversations {
+		resp = append(resp, mapConversationToDTO(c))
+	}
+
+	response.OK(w, resp)
+}
+
+// createConversation handles group conversation creation
+func (h *MessageHandler) createConversation(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.CreateConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	c, err := h.messageService.CreateGroup(r.Context(), userID, req.Name, req.Participants)
+	if err != nil {
+		h.logger.Error("Failed to create conversation: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, mapConversationToDTO(c))
+}
+
+// getConversation handles retrieval of a single conversation
+func (h *MessageHandler) getConversation(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	c, err := h.messageService.GetConversation(r.Context(), userID, conversationID)
+	if err != nil {
+		h.logger.Error("Failed to get conversation: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapConversationToDTO(c))
+}
+
+// renameConversation handles group conversation renaming
+func (h *MessageHandler) renameConversation(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	var req dto.UpdateConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	c, err := h.messageService.Rename(r.Context(), userID, conversationID, req.Name)
+	if err != nil {
+		h.logger.Error("Failed to rename conversation: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapConversationToDTO(c))
+}
+
+// listConversationMessages handles the paginated history of a conversation
+// (?before=<message ID>&page=1&limit=10)
+func (h *MessageHandler) listConversationMessages(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	page, limit := parsePagination(r)
+
+	messages, err := h.messageService.ListConversationMessages(r.Context(), userID, conversationID, r.URL.Query().Get("before"), page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list conversation messages: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapMessagesToDTO(messages))
+}
+
+// sendConversationMessage handles sending a message in a conversation
+func (h *MessageHandler) sendConversationMessage(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	var req dto.ConversationMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	m, err := h.messageService.SendToConversation(r.Context(), userID, conversationID, req.Subject, req.Content)
+	if err != nil {
+		h.logger.Error("Failed to send message: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, mapMessageToDTO(m))
+}
+
+// addMembers handles adding members to a group conversation
+func (h *MessageHandler) addMembers(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	var req dto.AddMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	c, err := h.messageService.AddMembers(r.Context(), userID, conversationID, req.Participants)
+	if err != nil {
+		h.logger.Error("Failed to add conversation members: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapConversationToDTO(c))
+}
+
+// removeMember handles removing a member from a group conversation
+func (h *MessageHandler) removeMember(w http.ResponseWriter, r *http.Request, userID, conversationID, handle string) {
+	if err := h.messageService.RemoveMember(r.Context(), userID, conversationID, handle); err != nil {
+		h.logger.Error("Failed to remove conversation member: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// leaveConversation handles leaving a group conversation
+func (h *MessageHandler) leaveConversation(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	if err := h.messageService.Leave(r.Context(), userID, conversationID); err != nil {
+		h.logger.Error("Failed to leave conversation: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// markConversationRead handles marking a conversation as read, up to a message or entirely
+// (the body is optional)
+func (h *MessageHandler) markConversationRead(w http.ResponseWriter, r *http.Request, userID, conversationID string) {
+	var req dto.MarkConversationReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	c, err := h.messageService.MarkConversationRead(r.Context(), userID, conversationID, req.MessageID)
+	if err != nil {
+		h.logger.Error("Failed to mark conversation as read: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapConversationToDTO(c))
+}
+
+// listMessages handles listing of the messages received by the current user
+func (h *MessageHandler) listMessages(w http.ResponseWriter, r *http.Request, userID string) {
+	var status message.ReadStatus
+	switch r.URL.Query().Get("status") {
+	case "":
+		status = message.AnyStatus
+	case messageStatusRead:
+		status = message.OnlyRead
+	case messageStatusUnread:
+		status = message.OnlyUnread
+	default:
+		response.Error(w, apperrors.NewValidationError(map[string]string{"status": "must be lu or non-lu"}))
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	messages, err := h.messageService.ListReceived(r.Context(), userID, status, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list messages: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapMessagesToDTO(messages))
+}
+
+// sendMessage handles sending a message
+func (h *MessageHandler) sendMessage(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	m, err := h.messageService.Send(r.Context(), userID, req.Recipient, req.Subject, req.Content)
+	if err != nil {
+		h.logger.Error("Failed to send message: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, mapMessageToDTO(m))
+}
+
+// getMessage handles retrieval of a single message
+func (h *MessageHandler) getMessage(w http.ResponseWriter, r *http.Request, userID, messageID string) {
+	m, err := h.messageService.Get(r.Context(), userID, messageID)
+	if err != nil {
+		h.logger.Error("Failed to get message: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapMessageToDTO(m))
+}
+
+// updateMessage handles read status changes through PUT /messages/{id}
+func (h *MessageHandler) updateMessage(w http.ResponseWriter, r *http.Request, userID, messageID string) {
+	var req dto.UpdateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	switch req.Status {
+	case messageStatusRead:
+		h.setRead(w, r, userID, messageID, true)
+	case messageStatusUnread:
+		h.setRead(w, r, userID, messageID, false)
+	default:
+		response.Error(w, apperrors.NewValidationError(map[string]string{"status": "must be lu or non-lu"}))
+	}
+}
+
+// setRead handles marking a message as read or unread
+func (h *MessageHandler) setRead(w http.ResponseWriter, r *http.Request, userID, messageID string, read bool) {
+	var m *message.Message
+	var err error
+	if read {
+		m, err = h.messageService.MarkRead(r.Context(), userID, messageID)
+	} else {
+		m, err = h.messageService.MarkUnread(r.Context(), userID, messageID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to update message: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapMessageToDTO(m))
+}
+
+// deleteMessage handles message deletion
+func (h *MessageHandler) deleteMessage(w http.ResponseWriter, r *http.Request, userID, messageID string) {
+	if err := h.messageService.Delete(r.Context(), userID, messageID); err != nil {
+		h.logger.Error("Failed to delete message: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// countUnread handles the unread message count
+func (h *MessageHandler) countUnread(w http.ResponseWriter, r *http.Request, userID string) {
+	count, err := h.messageService.CountUnread(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to count unread messages: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, dto.UnreadCountResponse{Count: count})
+}
+
+// mapMessagesToDTO maps message domain models to DTOs
+func mapMessagesToDTO(messages []*message.Message) []dto.MessageResponse {
+	resp := make([]dto.MessageResponse, 0, len(messages))
+	for _, m := range messages {
+		resp = append(resp, mapMessageToDTO(m))
+	}
+	return resp
+}
+
+// mapMessageToDTO maps a message domain model to a DTO
+func mapMessageToDTO(m *message.Message) dto.MessageResponse {
+	resp := dto.MessageResponse{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		Sender:         m.SenderProfile.Handle,
+		Recipient:      m.RecipientProfile.Handle,
+		SenderProfile:  mapParticipantToDTO(m.SenderProfile),
+		Subject:        m.Subject,
+		Content:        m.Content,
+		Status:         messageStatusUnread,
+		CreatedAt:      m.CreatedAt.Unix(),
+	}
+	switch {
+	case !m.IsDirect():
+		if !m.UnreadByViewer {
+			resp.Status = messageStatusRead
+		}
+	case m.IsRead():
+		resp.Status = messageStatusRead
+		resp.ReadAt = m.ReadAt.Unix()
+	}
+	return resp
+}
+
+// mapConversationToDTO maps a conversation domain model to a DTO
+func mapConversationToDTO(c *message.Conversation) dto.ConversationResponse {
+	resp := dto.ConversationResponse{
+		ID:            c.ID,
+		IsGroup:       c.IsGroup,
+		Name:          c.Name,
+		Participants:  make([]dto.ConversationMemberResponse, 0, len(c.Members)),
+		UnreadCount:   c.UnreadCount,
+		CreatedAt:     c.CreatedAt.Unix(),
+		LastMessageAt: c.LastMessageAt.Unix(),
+	}
+	for _, member := range c.Members {
+		memberResp := dto.ConversationMemberResponse{
+			ParticipantResponse: mapParticipantToDTO(member.Profile),
+			JoinedAt:            member.JoinedAt.Unix(),
+		}
+		if c.IsGroup {
+			memberResp.Role = member.Role
+		}
+		resp.Participants = append(resp.Participants, memberResp)
+	}
+	if c.LastMessage != nil {
+		lastMessage := mapMessageToDTO(c.LastMessage)
+		resp.LastMessage = &lastMessage
+	}
+	return resp
+}
+
+// mapParticipantToDTO maps a participant profile to a DTO
+func mapParticipantToDTO(p message.Profile) dto.ParticipantResponse {
+	return dto.ParticipantResponse{
+		Handle:      p.Handle,
+		DisplayName: p.DisplayName,
+		AvatarURL:   p.AvatarURL,
+	}
+}
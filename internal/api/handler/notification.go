@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/notification"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	notificationService "ynov-social-api/internal/service/notification"
+)
+
+// notificationActions describes what the actors of each type of notification did
+var notificationActions = map[string]string{
+	notification.TypeLike:    "liked your post",
+	notification.TypeReply:   "replied to your post",
+	notification.TypeMention: "mentioned you in a post",
+	notification.TypeFollow:  "followed you",
+}
+
+// NotificationHandler handles notification endpoints
+type NotificationHandler struct {
+	notificationService *notificationService.Service
+	logger              *logger.Logger
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *notificationService.Service, logger *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// HandleNotifications handles GET /notifications, listing the notifications of the current
+// user (most recent first, optionally before a cursor with ?before=)
+func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	before, _ := strconv.ParseInt(r.URL.Query().Get("before"), 10, 64)
+	page, limit := parsePagination(r)
+
+	notifications, err := h.notificationService.List(r.Context(), userID, before, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list notifications: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	result := make([]dto.NotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		result = append(result, mapNotificationToDTO(n))
+	}
+
+	response.OK(w, result)
+}
+
+// HandleNotificationAction handles the unread count (GET /notifications/unread-count) and
+// marking notifications as read up to a cursor (POST /notifications/read)
+func (h *NotificationHandler) HandleNotificationAction(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/notifications/") {
+	case "unread-count":
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.countUnread(w, r, userID)
+	case "read":
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.markRead(w, r, userID)
+	default:
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+	}
+}
+
+// countUnread handles counting the notifications the current user has not read
+func (h *NotificationHandler) countUnread(w http.ResponseWriter, r *http.Request, userID string) {
+	count, err := h.notificationService.CountUnread(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to count unread notifications: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, dto.UnreadCountResponse{Count: count})
+}
+
+// markRead handles marking the notifications of the current user as read, up to a cursor or
+// entirely (the body is optional)
+func (h *NotificationHandler) markRead(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.MarkNotificationsReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	count, err := h.notificationService.MarkRead(r.Context(), userID, req.Cursor)
+	if err != nil {
+		h.logger.Error("Failed to mark notifications as read: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, dto.UnreadCountResponse{Count: count})
+}
+
+// mapNotificationToDTO converts a notification to its API representation
+func mapNotificationToDTO(n *notification.Notification) dto.NotificationResponse {
+	actors := make([]dto.ParticipantResponse, 0, len(n.Actors))
+	for _, actor := range n.Actors {
+		actors = append(actors, dto.ParticipantResponse{
+			Handle:      actor.Handle,
+			DisplayName: actor.DisplayName,
+			AvatarURL:   actor.AvatarURL,
+		})
+	}
+
+	resp := dto.NotificationResponse{
+		ID:          n.ID,
+		Type:        n.Type,
+		PostID:      n.PostID,
+		Message:     notificationMessage(n),
+		Actors:      actors,
+		ActorsCount: n.ActorsCount,
+		Cursor:      n.Seq,
+		Read:        n.IsRead(),
+		CreatedAt:   n.CreatedAt.Unix(),
+		UpdatedAt:   n.UpdatedAt.Unix(),
+	}
+	if n.IsRead() {
+		resp.ReadAt = n.ReadAt.Unix()
+	}
+
+	return resp
+}
+
+// notificationMessage summarizes a notification: "@alice and 4 others liked your post"
+func notificationMessage(n *notification.Notification) string {
+	who := "Someone"
+	if len(n.Actors) > 0 {
+		who = "@" + n.Actors[0].Handle
+	}
+
+	switch others := n.ActorsCount - 1; {
+	case others == 1:
+		who += " and 1 other"
+	case others > 1:
+		who += fmt.Sprintf(" and %d others", others)
+	}
+
+	return who + " " + notificationActions[n.Type]
+}
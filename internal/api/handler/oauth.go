@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/oauth"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	oauthService "ynov-social-api/internal/service/oauth"
+	"ynov-social-api/internal/service/session"
+)
+
+// OAuthHandler handles the OAuth 2.0 authorization server endpoints: client registration,
+// consent, and the token, introspection and revocation endpoints used by clients
+type OAuthHandler struct {
+	oauthService *oauthService.Service
+	logger       *logger.Logger
+}
+
+// NewOAuthHandler creates a new OAuth handler
+func NewOAuthHandler(oauthService *oauthService.Service, logger *logger.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		logger:       logger,
+	}
+}
+
+// HandleClients handles the client collection: GET /oauth/clients lists the clients registered
+// by the current user, POST /oauth/clients registers one
+func (h *OAuthHandler) HandleClients(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		clients, err := h.oauthService.ListClients(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to list OAuth clients: %v", err)
+			response.Error(w, err)
+			return
+		}
+
+		resp := make([]dto.OAuthClientResponse, 0, len(clients))
+		for _, c := range clients {
+			resp = append(resp, mapOAuthClientToDTO(c))
+		}
+		response.OK(w, resp)
+
+	case http.MethodPost:
+		var req dto.CreateOAuthClientRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+			return
+		}
+
+		secret, c, err := h.oauthService.RegisterClient(r.Context(), userID, req.Name, req.RedirectURIs, req.Scopes, req.Confidential)
+		if err != nil {
+			h.logger.Error("Failed to register OAuth client: %v", err)
+			response.Error(w, err)
+			return
+		}
+
+		response.Created(w, dto.CreatedOAuthClientResponse{
+			OAuthClientResponse: mapOAuthClientToDTO(c),
+			ClientSecret:        secret,
+		})
+
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// HandleClientAction handles single client routes (DELETE /oauth/clients/{id})
+func (h *OAuthHandler) HandleClientAction(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := pathParam(r, "/oauth/clients/")
+	if !ok {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(r.Context(), userID, clientID); err != nil {
+		h.logger.Error("Failed to delete OAuth client: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// Authorize handles authorization requests, whose parameters are passed in the query string.
+// The consent screen of the client application calls GET /oauth/authorize to learn what to
+// display, then POST /oauth/authorize with the user's decision, and redirects the user to the
+// returned URI.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	req := oauthService.AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		prompt, err := h.oauthService.PrepareAuthorization(r.Context(), userID, req)
+		if err != nil {
+			h.oauthError(w, err)
+			return
+		}
+
+		response.OK(w, dto.AuthorizationPromptResponse{
+			ClientID:   prompt.Client.ID,
+			ClientName: prompt.Client.Name,
+			Scopes:     prompt.Scopes,
+			Consented:  prompt.Consented,
+		})
+
+	case http.MethodPost:
+		var decision dto.AuthorizeDecisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+			return
+		}
+
+		redirectURI, err := h.oauthService.Authorize(r.Context(), userID, req, decision.Approve)
+		if err != nil {
+			h.oauthError(w, err)
+			return
+		}
+
+		response.OK(w, dto.AuthorizeResponse{RedirectURI: redirectURI})
+
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// Token handles the token endpoint (POST /oauth/token, form-encoded as required by RFC 6749)
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.oauthError(w, apperrors.New(http.StatusBadRequest, "invalid form body"))
+		return
+	}
+
+	tokens, err := h.oauthService.Token(r.Context(), clientCredentials(r), oauthService.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		UserAgent:    r.UserAgent(),
+		IPAddress:    middleware.ClientIP(r),
+	})
+	if err != nil {
+		h.oauthError(w, err)
+		return
+	}
+
+	noStore(w)
+	response.OK(w, mapOAuthTokensToDTO(tokens))
+}
+
+// Introspect handles the token introspection endpoint (POST /oauth/introspect, RFC 7662),
+// reserved to confidential clients
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.oauthError(w, apperrors.New(http.StatusBadRequest, "invalid form body"))
+		return
+	}
+
+	info, err := h.oauthService.Introspect(r.Context(), clientCredentials(r), r.PostForm.Get("token"))
+	if err != nil {
+		h.oauthError(w, err)
+		return
+	}
+
+	resp := dto.OAuthIntrospectionResponse{Active: info.Active}
+	if info.Active {
+		resp.TokenType = info.TokenType
+		resp.Scope = strings.Join(info.Scopes, " ")
+		resp.ClientID = info.ClientID
+		resp.Sub = info.UserID
+		resp.Iat = info.IssuedAt.Unix()
+		resp.Exp = info.ExpiresAt.Unix()
+	}
+
+	noStore(w)
+	response.OK(w, resp)
+}
+
+// Revoke handles the token revocation endpoint (POST /oauth/revoke, RFC 7009)
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.oauthError(w, apperrors.New(http.StatusBadRequest, "invalid form body"))
+		return
+	}
+
+	if err := h.oauthService.Revoke(r.Context(), clientCredentials(r), r.PostForm.Get("token")); err != nil {
+		h.oauthError(w, err)
+		return
+	}
+
+	// The response is the same whether or not the token was valid
+	w.WriteHeader(http.StatusOK)
+}
+
+// oauthError writes an error in the format of RFC 6749, internal errors included
+func (h *OAuthHandler) oauthError(w http.ResponseWriter, err error) {
+	var oauthErr *oauthService.Error
+	if !errors.As(err, &oauthErr) {
+		appErr, ok := apperrors.AsAppError(err)
+		if !ok || appErr.Code >= http.StatusInternalServerError {
+			h.logger.Error("OAuth request failed: %v", err)
+			oauthErr = &oauthService.Error{Status: http.StatusInternalServerError, Code: "server_error"}
+		} else {
+			oauthErr = &oauthService.Error{Status: appErr.Code, Code: oauthService.ErrorInvalidRequest, Description: appErr.Message}
+		}
+	}
+
+	if oauthErr.Code == oauthService.ErrorInvalidClient {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	noStore(w)
+	response.JSON(w, oauthErr.Status, dto.OAuthErrorResponse{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+		RedirectURI:      oauthErr.RedirectURI,
+	})
+}
+
+// clientCredentials reads the client credentials from the Authorization header (HTTP Basic,
+// form-encoded as required by RFC 6749 section 2.3.1) or, failing that, from the form body
+func clientCredentials(r *http.Request) oauthService.ClientCredentials {
+	if clientID, secret, ok := r.BasicAuth(); ok {
+		if decoded, err := url.QueryUnescape(clientID); err == nil {
+			clientID = decoded
+		}
+		if decoded, err := url.QueryUnescape(secret); err == nil {
+			secret = decoded
+		}
+		return oauthService.ClientCredentials{ClientID: clientID, ClientSecret: secret}
+	}
+	return oauthService.ClientCredentials{
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+}
+
+// noStore forbids caching of responses carrying credentials (RFC 6749 section 5.1)
+func noStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+}
+
+// mapOAuthClientToDTO maps an OAuth client domain model to a DTO
+func mapOAuthClientToDTO(c *oauth.Client) dto.OAuthClientResponse {
+	return dto.OAuthClientResponse{
+		ID:           c.ID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectURIs,
+		Scopes:       c.Scopes,
+		Confidential: c.IsConfidential(),
+		CreatedAt:    c.CreatedAt.Unix(),
+	}
+}
+
+// mapOAuthTokensToDTO maps the tokens of a session granted to an OAuth client to the token endpoint response
+func mapOAuthTokensToDTO(tokens *session.TokenPair) dto.OAuthTokenResponse {
+	return dto.OAuthTokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        strings.Join(tokens.Scopes, " "),
+	}
+}
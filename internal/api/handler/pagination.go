@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// parsePagination extracts the page/limit query parameters, applying defaults and bounds
+func parsePagination(r *http.Request) (page, limit int) {
+	query := r.URL.Query()
+
+	page, _ = strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ = strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	return page, limit
+}
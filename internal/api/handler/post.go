@@ -42,20 +42,20 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	author := middleware.GetUserEmail(r)
-	if author == "" {
+	authorID := middleware.CurrentUser(r).ID
+	if authorID == "" {
 		response.Error(w, apperrors.ErrUnauthorized)
 		return
 	}
 
-	post, err := h.postService.CreatePost(r.Context(), author, req.Content)
+	post, err := h.postService.CreatePost(r.Context(), authorID, req.Content)
 	if err != nil {
 		h.logger.Error("Failed to create post: %v", err)
 		response.Error(w, err)
 		return
 	}
 
-	resp := h.mapPostToDTO(post)
+	resp := mapPostToDTO(post)
 	response.Created(w, resp)
 }
 
@@ -66,60 +66,117 @@ func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := r.URL.Query()
+	beforeTimestamp, page, limit := parseFeedParams(r)
+	viewerID := middleware.CurrentUser(r).ID
 
-	var beforeTimestamp int64
-	if beforeTsStr := query.Get("beforeTs"); beforeTsStr != "" {
-		beforeTimestamp, _ = strconv.ParseInt(beforeTsStr, 10, 64)
+	posts, err := h.postService.ListPosts(r.Context(), viewerID, beforeTimestamp, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list posts: %v", err)
+		response.Error(w, err)
+		return
 	}
 
-	page, _ := strconv.Atoi(query.Get("page"))
-	if page < 1 {
-		page = 1
+	response.OK(w, h.mapPostsToDTO(posts))
+}
+
+// Timeline handles the personalised home timeline (own posts and posts from followed users)
+func (h *PostHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
 	}
 
-	limit, _ := strconv.Atoi(query.Get("limit"))
-	if limit <= 0 || limit > 50 {
-		limit = 10
+	viewerID := middleware.CurrentUser(r).ID
+	if viewerID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
 	}
 
-	posts, err := h.postService.ListPosts(r.Context(), beforeTimestamp, page, limit)
+	beforeTimestamp, page, limit := parseFeedParams(r)
+
+	posts, err := h.postService.GetTimeline(r.Context(), viewerID, beforeTimestamp, page, limit)
 	if err != nil {
-		h.logger.Error("Failed to list posts: %v", err)
+		h.logger.Error("Failed to get timeline: %v", err)
 		response.Error(w, err)
 		return
 	}
 
-	resp := make([]dto.PostResponse, 0, len(posts))
-	for _, p := range posts {
-		resp = append(resp, h.mapPostToDTO(p))
+	response.OK(w, h.mapPostsToDTO(posts))
+}
+
+// parseFeedParams extracts the beforeTs/page/limit feed query parameters
+func parseFeedParams(r *http.Request) (beforeTimestamp int64, page, limit int) {
+	query := r.URL.Query()
+
+	if beforeTsStr := query.Get("beforeTs"); beforeTsStr != "" {
+		beforeTimestamp, _ = strconv.ParseInt(beforeTsStr, 10, 64)
 	}
 
-	response.OK(w, resp)
+	page, limit = parsePagination(r)
+	return beforeTimestamp, page, limit
 }
 
-// HandlePostAction handles post actions (like/unlike)
+// HandlePostAction handles single post routes (/posts/{id}) and post actions (like/unlike/history/replies/thread)
 func (h *PostHandler) HandlePostAction(w http.ResponseWriter, r *http.Request) {
-	// Parse URL: /posts/{id}/like or /posts/{id}/unlike
+	// Parse URL: /posts/{id} or /posts/{id}/{action}
 	path := strings.TrimPrefix(r.URL.Path, "/posts/")
 	parts := strings.Split(path, "/")
 
-	if len(parts) != 2 || parts[0] == "" {
+	if len(parts) > 2 || parts[0] == "" {
 		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
 		return
 	}
 
 	postID := parts[0]
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.getPost(w, r, userID, postID)
+		case http.MethodPatch:
+			h.updatePost(w, r, userID, postID)
+		case http.MethodDelete:
+			h.deletePost(w, r, userID, postID)
+		default:
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+		return
+	}
+
 	action := parts[1]
 
-	if action != "like" && action != "unlike" {
-		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+	switch action {
+	case "history":
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.getPostHistory(w, r, postID)
+		return
+	case "replies":
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.createReply(w, r, userID, postID)
+		return
+	case "thread":
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.getThread(w, r, userID, postID)
 		return
 	}
 
-	userEmail := middleware.GetUserEmail(r)
-	if userEmail == "" {
-		response.Error(w, apperrors.ErrUnauthorized)
+	if action != "like" && action != "unlike" {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
 		return
 	}
 
@@ -131,13 +188,13 @@ func (h *PostHandler) HandlePostAction(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
 			return
 		}
-		likesCount, err = h.postService.LikePost(r.Context(), userEmail, postID)
+		likesCount, err = h.postService.LikePost(r.Context(), userID, postID)
 	case "unlike":
 		if r.Method != http.MethodDelete {
 			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
 			return
 		}
-		likesCount, err = h.postService.UnlikePost(r.Context(), userEmail, postID)
+		likesCount, err = h.postService.UnlikePost(r.Context(), userID, postID)
 	default:
 		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
 		return
@@ -156,13 +213,152 @@ func (h *PostHandler) HandlePostAction(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, resp)
 }
 
+// getPost handles single post retrieval
+func (h *PostHandler) getPost(w http.ResponseWriter, r *http.Request, userID, postID string) {
+	post, err := h.postService.GetPost(r.Context(), userID, postID)
+	if err != nil {
+		h.logger.Error("Failed to get post: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapPostToDTO(post))
+}
+
+// updatePost handles post edition
+func (h *PostHandler) updatePost(w http.ResponseWriter, r *http.Request, userID, postID string) {
+	var req dto.UpdatePostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	post, err := h.postService.UpdatePost(r.Context(), userID, postID, req.Content)
+	if err != nil {
+		h.logger.Error("Failed to update post: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapPostToDTO(post))
+}
+
+// deletePost handles post deletion
+func (h *PostHandler) deletePost(w http.ResponseWriter, r *http.Request, userID, postID string) {
+	if err := h.postService.DeletePost(r.Context(), userID, postID); err != nil {
+		h.logger.Error("Failed to delete post: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// getPostHistory handles post edit history retrieval
+func (h *PostHandler) getPostHistory(w http.ResponseWriter, r *http.Request, postID string) {
+	revisions, err := h.postService.GetPostHistory(r.Context(), postID)
+	if err != nil {
+		h.logger.Error("Failed to get post history: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	resp := make([]dto.PostRevisionResponse, 0, len(revisions))
+	for _, rev := range revisions {
+		resp = append(resp, dto.PostRevisionResponse{
+			Content:    rev.Content,
+			CreatedAt:  rev.CreatedAt.Unix(),
+			ReplacedAt: rev.ReplacedAt.Unix(),
+		})
+	}
+
+	response.OK(w, resp)
+}
+
+// createReply handles reply creation
+func (h *PostHandler) createReply(w http.ResponseWriter, r *http.Request, userID, parentID string) {
+	var req dto.CreatePostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	reply, err := h.postService.CreateReply(r.Context(), userID, parentID, req.Content)
+	if err != nil {
+		h.logger.Error("Failed to create reply: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, mapPostToDTO(reply))
+}
+
+// getThread handles conversation tree retrieval
+func (h *PostHandler) getThread(w http.ResponseWriter, r *http.Request, userID, postID string) {
+	query := r.URL.Query()
+	depth, _ := strconv.Atoi(query.Get("depth"))
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	thread, err := h.postService.GetThread(r.Context(), userID, postID, depth, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to get thread: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	resp := dto.ThreadResponse{
+		Ancestors: make([]dto.PostResponse, 0, len(thread.Ancestors)),
+		Post:      h.mapThreadNodeToDTO(thread.Root),
+	}
+	for _, p := range thread.Ancestors {
+		resp.Ancestors = append(resp.Ancestors, mapPostToDTO(p))
+	}
+
+	response.OK(w, resp)
+}
+
+// mapThreadNodeToDTO maps a thread node and its replies to DTO
+func (h *PostHandler) mapThreadNodeToDTO(node *post.ThreadNode) dto.ThreadNodeResponse {
+	resp := dto.ThreadNodeResponse{
+		PostResponse:   mapPostToDTO(node.Post),
+		Replies:        make([]dto.ThreadNodeResponse, 0, len(node.Replies)),
+		HasMoreReplies: node.HasMoreReplies,
+	}
+	for _, reply := range node.Replies {
+		resp.Replies = append(resp.Replies, h.mapThreadNodeToDTO(reply))
+	}
+	return resp
+}
+
+// mapPostsToDTO maps a list of post domain models to DTOs
+func (h *PostHandler) mapPostsToDTO(posts []*post.Post) []dto.PostResponse {
+	resp := make([]dto.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		resp = append(resp, mapPostToDTO(p))
+	}
+	return resp
+}
+
 // mapPostToDTO maps a post domain model to DTO
-func (h *PostHandler) mapPostToDTO(p *post.Post) dto.PostResponse {
+func mapPostToDTO(p *post.Post) dto.PostResponse {
 	return dto.PostResponse{
-		ID:         p.ID,
-		Author:     p.Author,
-		Content:    p.Content,
-		CreatedAt:  p.CreatedAt.Unix(),
-		LikesCount: p.LikesCount,
+		ID:     p.ID,
+		Author: p.AuthorProfile.Handle, // expose the handle, never the author email
+		AuthorProfile: dto.AuthorProfileResponse{
+			Handle:      p.AuthorProfile.Handle,
+			DisplayName: p.AuthorProfile.DisplayName,
+			AvatarURL:   p.AuthorProfile.AvatarURL,
+			PostsCount:  p.AuthorProfile.PostsCount,
+		},
+		Content:      p.Content,
+		ParentID:     p.ParentID,
+		RootID:       p.RootID,
+		CreatedAt:    p.CreatedAt.Unix(),
+		UpdatedAt:    p.UpdatedAt.Unix(),
+		LikesCount:   p.LikesCount,
+		RepliesCount: p.RepliesCount,
+		LikedByMe:    p.LikedByMe,
+		Deleted:      p.Deleted,
 	}
 }
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/service/session"
+)
+
+// SessionHandler handles session management endpoints
+type SessionHandler struct {
+	sessionService *session.Service
+	logger         *logger.Logger
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService *session.Service, logger *logger.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		logger:         logger,
+	}
+}
+
+// ListSessions handles listing of the current user's active sessions
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	currentID := middleware.GetSessionID(r)
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, dto.SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IPAddress,
+			CreatedAt:  s.CreatedAt.Unix(),
+			LastSeenAt: s.LastSeenAt.Unix(),
+			Current:    s.ID == currentID,
+			ClientID:   s.ClientID,
+			Scopes:     s.Scopes,
+		})
+	}
+
+	response.OK(w, resp)
+}
+
+// HandleSessionAction handles single session routes (DELETE /sessions/{id})
+func (h *SessionHandler) HandleSessionAction(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimPrefix(r.URL.Path, "/sessions/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		h.logger.Error("Failed to revoke session: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
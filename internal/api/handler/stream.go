@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/notification"
+	"ynov-social-api/internal/domain/post"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/service/session"
+)
+
+// streamWriteTimeout bounds each write to a stream, replacing the server's WriteTimeout
+// which would otherwise cut every stream after a few seconds
+const streamWriteTimeout = 10 * time.Second
+
+// streamResyncEvent tells a client resuming a stream that some events were lost, and that
+// it should reload what it displays
+const streamResyncEvent = "resync"
+
+// StreamHandler handles the real-time event stream
+type StreamHandler struct {
+	broker            *events.Broker
+	sessionService    *session.Service
+	heartbeatInterval time.Duration
+	logger            *logger.Logger
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(broker *events.Broker, sessionService *session.Service, heartbeatInterval time.Duration, logger *logger.Logger) *StreamHandler {
+	return &StreamHandler{
+		broker:            broker,
+		sessionService:    sessionService,
+		heartbeatInterval: heartbeatInterval,
+		logger:            logger,
+	}
+}
+
+// Stream handles GET /stream, pushing new posts, likes count changes and the notifications
+// of the current user as Server-Sent Events. Clients reconnecting with a Last-Event-ID
+// header receive the events they missed, or a resync event if they are no longer available.
+// The stream ends when the access token expires, or at the next heartbeat once the session is
+// revoked: the client reconnects with a new token.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	lastID, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	subscription, replay, resumed := h.broker.Subscribe(userID, lastID)
+	defer subscription.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable buffering by reverse proxies
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	if !resumed {
+		replay = append([]events.Event{{Type: streamResyncEvent}}, replay...)
+	}
+	for _, event := range replay {
+		if err := h.write(controller, w, event); err != nil {
+			return
+		}
+	}
+	if err := h.flush(controller); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+	var expired <-chan time.Time
+	if expiresAt := middleware.GetTokenExpiry(r); !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired:
+			return
+		case event, ok := <-subscription.Events():
+			// The subscription is closed on shutdown, or when the client was too slow to keep
+			// up: it reconnects and resumes from the last event it received
+			if !ok {
+				return
+			}
+			if err := h.write(controller, w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			// The session may have been revoked since the stream was opened (logout, password
+			// change, suspension)
+			if err := h.sessionService.ValidateSession(r.Context(), middleware.GetSessionID(r)); err != nil {
+				if _, ok := apperrors.AsAppError(err); !ok {
+					h.logger.Error("Failed to validate stream session: %v", err)
+				}
+				return
+			}
+
+			// Comments keep the connection open through proxies and detect departed clients
+			if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := h.flush(controller); err != nil {
+			return
+		}
+	}
+}
+
+// write sends an event to the client, in the SSE format
+func (h *StreamHandler) write(controller *http.ResponseController, w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(mapStreamEventToDTO(event))
+	if err != nil {
+		h.logger.Error("Failed to encode stream event: %v", err)
+		return err
+	}
+
+	if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// flush sends the buffered events to the client
+func (h *StreamHandler) flush(controller *http.ResponseController) error {
+	if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return controller.Flush()
+}
+
+// mapStreamEventToDTO converts the payload of a stream event to its API representation
+func mapStreamEventToDTO(event events.Event) interface{} {
+	switch data := event.Data.(type) {
+	case *post.Post:
+		return mapPostToDTO(data)
+	case post.LikesChange:
+		return dto.PostLikesResponse{PostID: data.PostID, LikesCount: data.LikesCount}
+	case *notification.Notification:
+		return mapNotificationToDTO(data)
+	default:
+		return struct{}{}
+	}
+}
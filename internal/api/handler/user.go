@@ -0,0 +1,482 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ynov-social-api/internal/api/dto"
+	"ynov-social-api/internal/api/middleware"
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/follow"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/logger"
+	"ynov-social-api/internal/service/account"
+	followService "ynov-social-api/internal/service/follow"
+	"ynov-social-api/internal/service/session"
+	userService "ynov-social-api/internal/service/user"
+)
+
+// UserHandler handles user endpoints
+type UserHandler struct {
+	userService    *userService.Service
+	followService  *followService.Service
+	accountService *account.Service
+	logger         *logger.Logger
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *userService.Service, followService *followService.Service, accountService *account.Service, logger *logger.Logger) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		followService:  followService,
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// HandleUserAction handles profile routes (/users/me, /users/{handle}), account deletion and data
+// export (/users/me/export), credentials changes (/users/me/password, /users/me/email),
+// two-factor settings (/users/me/2fa) and user actions (follow/unfollow/followers/following)
+func (h *UserHandler) HandleUserAction(w http.ResponseWriter, r *http.Request) {
+	// Parse URL: /users/{handle}, /users/{handle}/{action} or /users/me/2fa/{action}
+	path := strings.TrimPrefix(r.URL.Path, "/users/")
+	parts := strings.Split(path, "/")
+
+	userID := middleware.CurrentUser(r).ID
+	if userID == "" {
+		response.Error(w, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if len(parts) >= 2 && parts[0] == "me" && parts[1] == "2fa" {
+		h.handleTwoFactor(w, r, userID, parts[2:])
+		return
+	}
+
+	if len(parts) > 2 || parts[0] == "" {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	target := parts[0]
+
+	if len(parts) == 1 {
+		switch {
+		case target == "me" && r.Method == http.MethodGet:
+			h.getMe(w, r, userID)
+		case target == "me" && r.Method == http.MethodPatch:
+			h.updateMe(w, r, userID)
+		case target == "me" && r.Method == http.MethodDelete:
+			h.deleteMe(w, r, userID)
+		case target != "me" && r.Method == http.MethodGet:
+			h.getProfile(w, r, target)
+		default:
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		}
+		return
+	}
+
+	action := parts[1]
+
+	if target == "me" && action == "export" {
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.exportMe(w, r, userID)
+		return
+	}
+
+	if target == "me" && (action == "password" || action == "email") {
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.changeCredentials(w, r, userID, action)
+		return
+	}
+
+	switch action {
+	case "follow":
+		h.handleFollow(w, r, userID, target)
+	case "followers", "following":
+		if r.Method != http.MethodGet {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.listFollows(w, r, target, action)
+	default:
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+	}
+}
+
+// getMe handles retrieval of the authenticated user's profile
+func (h *UserHandler) getMe(w http.ResponseWriter, r *http.Request, userID string) {
+	u, err := h.userService.GetProfile(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get profile: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, h.mapProfileToDTO(u))
+}
+
+// updateMe handles partial update of the authenticated user's profile
+func (h *UserHandler) updateMe(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	u, err := h.userService.UpdateProfile(r.Context(), userID, user.ProfileUpdate{
+		Handle:      req.Handle,
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		AvatarURL:   req.AvatarURL,
+	})
+	if err != nil {
+		h.logger.Error("Failed to update profile: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, h.mapProfileToDTO(u))
+}
+
+// deleteMe handles the deletion of the authenticated user's account, which requires the current
+// password. The account is purged after a grace period during which logging in cancels the deletion.
+func (h *UserHandler) deleteMe(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	deleteAt, err := h.accountService.RequestDeletion(r.Context(), userID, req.CurrentPassword, middleware.ClientIP(r))
+	if err != nil {
+		h.logger.Error("Failed to request account deletion: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, dto.AccountDeletionResponse{DeletionScheduledAt: deleteAt.Unix()})
+}
+
+// exportMe handles the export of the authenticated user's data, as a ZIP archive of JSON files
+// (default) or as a single JSON document with ?format=json
+func (h *UserHandler) exportMe(w http.ResponseWriter, r *http.Request, userID string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "zip"
+	}
+	if format != "zip" && format != "json" {
+		response.Error(w, apperrors.NewValidationError(map[string]string{"format": "must be zip or json"}))
+		return
+	}
+
+	export, err := h.accountService.Export(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to export user data: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	resp := h.mapExportToDTO(export)
+	filename := fmt.Sprintf("export-%s-%s.%s", export.User.Handle, export.CreatedAt.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		response.OK(w, resp)
+		return
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", resp.Profile},
+		{"posts.json", resp.Posts},
+		{"likes.json", resp.Likes},
+		{"following.json", resp.Following},
+		{"followers.json", resp.Followers},
+	}
+
+	// The archive is streamed: once the headers are sent, errors can only be logged
+	w.Header().Set("Content-Type", "application/zip")
+	w.WriteHeader(http.StatusOK)
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.CreatedAt,
+		})
+		if err != nil {
+			h.logger.Error("Failed to write data export: %v", err)
+			return
+		}
+
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			h.logger.Error("Failed to write data export: %v", err)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		h.logger.Error("Failed to write data export: %v", err)
+	}
+}
+
+// changeCredentials handles password and email changes, which require the current password.
+// Other sessions are revoked; the response carries the tokens of a new session for this device.
+func (h *UserHandler) changeCredentials(w http.ResponseWriter, r *http.Request, userID, action string) {
+	var tokens *session.TokenPair
+	var err error
+
+	if action == "password" {
+		var req dto.ChangePasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+			return
+		}
+		tokens, err = h.accountService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword, r.UserAgent(), middleware.ClientIP(r))
+	} else {
+		var req dto.ChangeEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+			return
+		}
+		tokens, err = h.accountService.ChangeEmail(r.Context(), userID, req.CurrentPassword, req.NewEmail, r.UserAgent(), middleware.ClientIP(r))
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to change %s: %v", action, err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, mapTokenPairToDTO(tokens))
+}
+
+// handleTwoFactor handles the two-factor settings of the authenticated user:
+// GET/POST/DELETE /users/me/2fa (status, enrollment, disabling) and POST /users/me/2fa/confirm
+func (h *UserHandler) handleTwoFactor(w http.ResponseWriter, r *http.Request, userID string, parts []string) {
+	if len(parts) == 1 && parts[0] == "confirm" {
+		if r.Method != http.MethodPost {
+			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		h.confirmTwoFactor(w, r, userID)
+		return
+	}
+
+	if len(parts) != 0 {
+		response.Error(w, apperrors.New(http.StatusNotFound, "not found"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status, err := h.userService.GetTwoFactorStatus(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get two-factor status: %v", err)
+			response.Error(w, err)
+			return
+		}
+		response.OK(w, dto.TwoFactorStatusResponse{
+			Enabled:                status.Enabled,
+			RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+		})
+	case http.MethodPost:
+		enrollment, err := h.userService.EnrollTwoFactor(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to enroll two-factor authentication: %v", err)
+			response.Error(w, err)
+			return
+		}
+		response.Created(w, dto.TwoFactorEnrollmentResponse{
+			Secret:     enrollment.Secret,
+			OTPAuthURI: enrollment.URI,
+		})
+	case http.MethodDelete:
+		h.disableTwoFactor(w, r, userID)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+	}
+}
+
+// confirmTwoFactor handles the confirmation of a pending TOTP enrollment
+func (h *UserHandler) confirmTwoFactor(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	codes, err := h.userService.ConfirmTwoFactor(r.Context(), userID, req.Code)
+	if err != nil {
+		h.logger.Error("Failed to confirm two-factor authentication: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disableTwoFactor handles turning two-factor authentication off
+func (h *UserHandler) disableTwoFactor(w http.ResponseWriter, r *http.Request, userID string) {
+	var req dto.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, apperrors.New(http.StatusBadRequest, "invalid JSON"))
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(r.Context(), userID, req.Code, middleware.ClientIP(r)); err != nil {
+		h.logger.Error("Failed to disable two-factor authentication: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// getProfile handles retrieval of a user's public profile by handle
+func (h *UserHandler) getProfile(w http.ResponseWriter, r *http.Request, handle string) {
+	u, err := h.userService.GetPublicProfile(r.Context(), handle)
+	if err != nil {
+		h.logger.Error("Failed to get public profile: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, h.mapPublicProfileToDTO(u))
+}
+
+// handleFollow handles following (POST) and unfollowing (DELETE) a user
+func (h *UserHandler) handleFollow(w http.ResponseWriter, r *http.Request, userID, target string) {
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = h.followService.Follow(r.Context(), userID, target)
+	case http.MethodDelete:
+		err = h.followService.Unfollow(r.Context(), userID, target)
+	default:
+		response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to update follow: %v", err)
+		response.Error(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// listFollows handles follower and following lists
+func (h *UserHandler) listFollows(w http.ResponseWriter, r *http.Request, target, action string) {
+	page, limit := parsePagination(r)
+
+	var follows []*follow.Follow
+	var err error
+	if action == "followers" {
+		follows, err = h.followService.ListFollowers(r.Context(), target, page, limit)
+	} else {
+		follows, err = h.followService.ListFollowing(r.Context(), target, page, limit)
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to list %s: %v", action, err)
+		response.Error(w, err)
+		return
+	}
+
+	response.OK(w, h.mapFollowsToDTO(follows))
+}
+
+// mapPublicProfileToDTO maps a user domain model to its public profile DTO
+func (h *UserHandler) mapPublicProfileToDTO(u *user.User) dto.PublicProfileResponse {
+	return dto.PublicProfileResponse{
+		ID:          u.ID,
+		Handle:      u.Handle,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		AvatarURL:   u.AvatarURL,
+	}
+}
+
+// mapFollowsToDTO maps follow domain models to DTOs
+func (h *UserHandler) mapFollowsToDTO(follows []*follow.Follow) []dto.FollowResponse {
+	resp := make([]dto.FollowResponse, 0, len(follows))
+	for _, f := range follows {
+		resp = append(resp, dto.FollowResponse{
+			Handle:      f.Profile.Handle,
+			DisplayName: f.Profile.DisplayName,
+			AvatarURL:   f.Profile.AvatarURL,
+			FollowedAt:  f.CreatedAt.Unix(),
+		})
+	}
+	return resp
+}
+
+// mapExportToDTO maps a user data export to its DTO
+func (h *UserHandler) mapExportToDTO(export *account.Export) dto.ExportResponse {
+	resp := dto.ExportResponse{
+		ExportedAt: export.CreatedAt.Unix(),
+		Profile:    h.mapProfileToDTO(export.User),
+		Posts:      make([]dto.ExportPostResponse, 0, len(export.Posts)),
+		Likes:      make([]dto.ExportLikeResponse, 0, len(export.Likes)),
+		Following:  h.mapFollowsToDTO(export.Following),
+		Followers:  h.mapFollowsToDTO(export.Followers),
+	}
+
+	for _, p := range export.Posts {
+		exported := dto.ExportPostResponse{
+			ID:         p.ID,
+			ParentID:   p.ParentID,
+			Content:    p.Content,
+			CreatedAt:  p.CreatedAt.Unix(),
+			UpdatedAt:  p.UpdatedAt.Unix(),
+			LikesCount: p.LikesCount,
+		}
+		for _, rev := range export.Revisions[p.ID] {
+			exported.History = append(exported.History, dto.PostRevisionResponse{
+				Content:    rev.Content,
+				CreatedAt:  rev.CreatedAt.Unix(),
+				ReplacedAt: rev.ReplacedAt.Unix(),
+			})
+		}
+		resp.Posts = append(resp.Posts, exported)
+	}
+
+	for _, p := range export.Likes {
+		resp.Likes = append(resp.Likes, dto.ExportLikeResponse{
+			PostID:  p.ID,
+			Author:  p.AuthorProfile.Handle,
+			Content: p.Content,
+		})
+	}
+
+	return resp
+}
+
+// mapProfileToDTO maps a user domain model to the private profile DTO
+func (h *UserHandler) mapProfileToDTO(u *user.User) dto.ProfileResponse {
+	return dto.ProfileResponse{
+		Email:                 u.Email,
+		EmailVerified:         u.EmailVerified,
+		TwoFactorEnabled:      u.TOTPEnabled,
+		Role:                  string(u.Role),
+		PublicProfileResponse: h.mapPublicProfileToDTO(u),
+	}
+}
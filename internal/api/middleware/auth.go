@@ -3,47 +3,164 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/accesstoken"
 	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/websocket"
+	accessTokenService "ynov-social-api/internal/service/accesstoken"
 	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/session"
 )
 
 type contextKey string
 
-const userEmailKey contextKey = "userEmail"
+const (
+	userKey      contextKey = "user"
+	sessionIDKey contextKey = "sessionID"
+	expiresAtKey contextKey = "expiresAt"
+	scopeKey     contextKey = "scope"
+)
+
+// User identifies the authenticated user of a request
+type User struct {
+	ID          string
+	Email       string
+	Role        string
+	Permissions []string // permissions granted by the role, as carried by the token
+	Scopes      []string // scopes of the personal access token or OAuth client, nil for first-party logins
+}
 
-// Auth middleware verifies JWT token and adds user email to context
-func Auth(jwtService *auth.JWTService) func(http.Handler) http.Handler {
+// Auth middleware verifies JWT token, rejects tokens of revoked sessions
+// and adds the current user, session ID and token expiry to context.
+// Personal access tokens and tokens issued to OAuth clients are only accepted on routes
+// mounted with AllowAccessTokens, and must grant the scope the route requires.
+func Auth(jwtService *auth.JWTService, sessionService *session.Service, tokenService *accessTokenService.Service) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
+			token, ok := bearerToken(r)
+			if !ok {
 				response.Error(w, apperrors.ErrMissingAuth)
 				return
 			}
 
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-				response.Error(w, apperrors.ErrMissingAuth)
+			if strings.HasPrefix(token, accesstoken.Prefix) {
+				authenticateAccessToken(w, r, next, tokenService, token)
 				return
 			}
 
-			email, err := jwtService.ValidateToken(parts[1])
+			claims, err := jwtService.ValidateToken(token)
 			if err != nil {
 				response.Error(w, apperrors.ErrInvalidToken)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userEmailKey, email)
+			if err := sessionService.ValidateSession(r.Context(), claims.SessionID); err != nil {
+				response.Error(w, err)
+				return
+			}
+
+			current := User{
+				ID:          claims.Subject,
+				Email:       claims.Email,
+				Role:        claims.Role,
+				Permissions: claims.Permissions,
+			}
+			if claims.ClientID != "" {
+				current.Scopes = strings.Fields(claims.Scope)
+				if !grantsRouteScope(r, current.Scopes) {
+					response.Error(w, apperrors.ErrInsufficientScope)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), userKey, current)
+			ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+			if claims.ExpiresAt != nil {
+				ctx = context.WithValue(ctx, expiresAtKey, claims.ExpiresAt.Time)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken extracts the token of the Authorization header. Browsers cannot set headers
+// on WebSocket connections, which may pass the token in the access_token query parameter instead.
+func bearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		token := r.URL.Query().Get("access_token")
+		return token, token != "" && websocket.IsUpgrade(r)
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+// authenticateAccessToken authenticates a request made with a personal access token, which
+// must grant the scope the route requires
+func authenticateAccessToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokenService *accessTokenService.Service, rawToken string) {
+	t, u, err := tokenService.Authenticate(r.Context(), rawToken)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if !grantsRouteScope(r, t.Scopes) {
+		response.Error(w, apperrors.ErrInsufficientScope)
+		return
+	}
+
+	// Role permissions are not delegated to tokens
+	ctx := context.WithValue(r.Context(), userKey, User{
+		ID:     u.ID,
+		Email:  u.Email,
+		Role:   string(u.Role),
+		Scopes: t.Scopes,
+	})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// grantsRouteScope reports whether scopes include the scope the route requires for the request.
+// Routes not mounted with AllowAccessTokens require a first-party login.
+func grantsRouteScope(r *http.Request, scopes []string) bool {
+	scope, _ := r.Context().Value(scopeKey).(func(*http.Request) string)
+	return scope != nil && slices.Contains(scopes, scope(r))
+}
+
+// AllowAccessTokens middleware lets personal access tokens and OAuth clients use the route,
+// provided they grant the scope returned by scope for the request. It must be mounted in front of Auth.
+func AllowAccessTokens(scope func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), scopeKey, scope)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// GetUserEmail extracts the user email from the request context
-func GetUserEmail(r *http.Request) string {
-	email, _ := r.Context().Value(userEmailKey).(string)
-	return email
+// CurrentUser extracts the authenticated user from the request context
+func CurrentUser(r *http.Request) User {
+	u, _ := r.Context().Value(userKey).(User)
+	return u
+}
+
+// GetSessionID extracts the session ID from the request context
+func GetSessionID(r *http.Request) string {
+	sessionID, _ := r.Context().Value(sessionIDKey).(string)
+	return sessionID
+}
+
+// GetTokenExpiry extracts the expiry of the JWT authenticating the request from the request
+// context. It is zero for personal access tokens.
+func GetTokenExpiry(r *http.Request) time.Time {
+	expiresAt, _ := r.Context().Value(expiresAtKey).(time.Time)
+	return expiresAt
 }
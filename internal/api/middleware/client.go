@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIPKey is the context key of the client IP address resolved by RealIP
+const clientIPKey contextKey = "clientIP"
+
+// RealIP resolves the IP address of the client once per request. The X-Forwarded-For header
+// is only honored when the request comes from a trusted proxy (e.g. the API gateway), and the
+// right-most address not belonging to a trusted proxy is used: the entries on its left are
+// set by the client and can be forged.
+func RealIP(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIP(r)
+			if isTrusted(ip, trustedProxies) {
+				hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+				for i := len(hops) - 1; i >= 0; i-- {
+					hop := net.ParseIP(strings.TrimSpace(hops[i]))
+					if hop == nil {
+						break
+					}
+					ip = hop.String()
+					if !isTrusted(ip, trustedProxies) {
+						break
+					}
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), clientIPKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the IP address of the client, as resolved by RealIP
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the IP address of the peer of the connection
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isTrusted reports whether the IP address belongs to a trusted proxy
+func isTrusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/pkg/apperrors"
+)
+
+// RequirePermission middleware rejects requests from users whose token does not grant the
+// given permission. It must be mounted behind Auth.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !CurrentUser(r).HasPermission(permission) {
+				response.Error(w, apperrors.ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasPermission reports whether the user's token grants the given permission
+func (u User) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
+}
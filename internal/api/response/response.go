@@ -2,7 +2,9 @@ package response
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
 
 	"ynov-social-api/internal/api/dto"
 	"ynov-social-api/internal/pkg/apperrors"
@@ -25,6 +27,10 @@ func Error(w http.ResponseWriter, err error) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if appErr.RetryAfter > 0 {
+		// Round up so that clients never retry too early
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
 	w.WriteHeader(appErr.Code)
 
 	response := dto.ErrorResponse{
@@ -2,27 +2,64 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
 	"ynov-social-api/internal/api/handler"
 	"ynov-social-api/internal/api/middleware"
 	"ynov-social-api/internal/api/response"
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/domain/user"
 	"ynov-social-api/internal/pkg/apperrors"
+	accessTokenService "ynov-social-api/internal/service/accesstoken"
 	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/session"
 )
 
 // New creates and configures the application router
-func New(authHandler *handler.AuthHandler, postHandler *handler.PostHandler, jwtService *auth.JWTService) http.Handler {
+func New(authHandler *handler.AuthHandler, postHandler *handler.PostHandler, userHandler *handler.UserHandler, sessionHandler *handler.SessionHandler, accountHandler *handler.AccountHandler, adminHandler *handler.AdminHandler, accessTokenHandler *handler.AccessTokenHandler, inviteHandler *handler.InviteHandler, messageHandler *handler.MessageHandler, notificationHandler *handler.NotificationHandler, streamHandler *handler.StreamHandler, gatewayHandler *handler.GatewayHandler, oauthHandler *handler.OAuthHandler, jwksHandler *handler.JWKSHandler, jwtService *auth.JWTService, sessionService *session.Service, tokenService *accessTokenService.Service) http.Handler {
 	mux := http.NewServeMux()
 
 	// Public routes
 	mux.HandleFunc("/signup", authHandler.Signup)
 	mux.HandleFunc("/login", authHandler.Login)
+	mux.HandleFunc("/login/2fa", authHandler.LoginTwoFactor)
+	mux.HandleFunc("/token/refresh", authHandler.Refresh)
+	mux.HandleFunc("/password/forgot", accountHandler.ForgotPassword)
+	mux.HandleFunc("/password/reset", accountHandler.ResetPassword)
+	mux.HandleFunc("/email/verify", accountHandler.VerifyEmail)
+	mux.HandleFunc("/.well-known/jwks.json", jwksHandler.JWKS)
 
-	// Protected routes
-	authMiddleware := middleware.Auth(jwtService)
+	// OAuth endpoints called by clients, authenticated with their client credentials
+	mux.HandleFunc("/oauth/token", oauthHandler.Token)
+	mux.HandleFunc("/oauth/introspect", oauthHandler.Introspect)
+	mux.HandleFunc("/oauth/revoke", oauthHandler.Revoke)
+
+	// Protected routes. Personal access tokens and OAuth client tokens are only accepted on routes
+	// mounted with middleware.AllowAccessTokens, and must grant the scope the request requires.
+	authMiddleware := middleware.Auth(jwtService, sessionService, tokenService)
+
+	// Session routes
+	mux.Handle("/logout", authMiddleware(http.HandlerFunc(authHandler.Logout)))
+	mux.Handle("/logout-all", authMiddleware(http.HandlerFunc(authHandler.LogoutAll)))
+	mux.Handle("/sessions", authMiddleware(http.HandlerFunc(sessionHandler.ListSessions)))
+	mux.Handle("/sessions/", authMiddleware(http.HandlerFunc(sessionHandler.HandleSessionAction)))
+	mux.Handle("/email/verify/resend", authMiddleware(http.HandlerFunc(accountHandler.ResendVerification)))
+
+	// Personal access token management (tokens cannot manage themselves)
+	mux.Handle("/tokens", authMiddleware(http.HandlerFunc(accessTokenHandler.HandleTokens)))
+	mux.Handle("/tokens/", authMiddleware(http.HandlerFunc(accessTokenHandler.HandleTokenAction)))
+
+	// Invite codes
+	mux.Handle("/invites", authMiddleware(http.HandlerFunc(inviteHandler.HandleInvites)))
+	mux.Handle("/invites/", authMiddleware(http.HandlerFunc(inviteHandler.HandleInviteAction)))
+
+	// OAuth client registration and consent, by the logged-in user
+	mux.Handle("/oauth/clients", authMiddleware(http.HandlerFunc(oauthHandler.HandleClients)))
+	mux.Handle("/oauth/clients/", authMiddleware(http.HandlerFunc(oauthHandler.HandleClientAction)))
+	mux.Handle("/oauth/authorize", authMiddleware(http.HandlerFunc(oauthHandler.Authorize)))
 
 	// Posts routes
-	mux.Handle("/posts", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/posts", middleware.AllowAccessTokens(postScope)(authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			postHandler.ListPosts(w, r)
@@ -31,10 +68,50 @@ func New(authHandler *handler.AuthHandler, postHandler *handler.PostHandler, jwt
 		default:
 			response.Error(w, apperrors.New(http.StatusMethodNotAllowed, "method not allowed"))
 		}
-	})))
+	}))))
+
+	// Single post routes and post actions (edit/delete/history/replies/thread/like/unlike)
+	mux.Handle("/posts/", middleware.AllowAccessTokens(postScope)(authMiddleware(http.HandlerFunc(postHandler.HandlePostAction))))
+
+	// Home timeline (own posts and posts from followed users)
+	mux.Handle("/timeline", middleware.AllowAccessTokens(postScope)(authMiddleware(http.HandlerFunc(postHandler.Timeline))))
 
-	// Post actions (like/unlike)
-	mux.Handle("/posts/", authMiddleware(http.HandlerFunc(postHandler.HandlePostAction)))
+	// Direct messages, only visible to their sender and recipient
+	mux.Handle("/messages", authMiddleware(http.HandlerFunc(messageHandler.HandleMessages)))
+	mux.Handle("/messages/", authMiddleware(http.HandlerFunc(messageHandler.HandleMessageAction)))
+	mux.Handle("/conversations", authMiddleware(http.HandlerFunc(messageHandler.HandleConversations)))
+	mux.Handle("/conversations/", authMiddleware(http.HandlerFunc(messageHandler.HandleConversationAction)))
+
+	// Notifications of the current user
+	mux.Handle("/notifications", authMiddleware(http.HandlerFunc(notificationHandler.HandleNotifications)))
+	mux.Handle("/notifications/", authMiddleware(http.HandlerFunc(notificationHandler.HandleNotificationAction)))
+
+	// Real-time events (Server-Sent Events): new posts, likes counts and notifications
+	mux.Handle("/stream", authMiddleware(http.HandlerFunc(streamHandler.Stream)))
+
+	// WebSocket gateway: new messages, typing indicators, presence and delivery acknowledgements
+	mux.Handle("/ws", authMiddleware(http.HandlerFunc(gatewayHandler.Connect)))
+
+	// User profiles and actions (follow/unfollow/followers/following)
+	mux.Handle("/users/", authMiddleware(http.HandlerFunc(userHandler.HandleUserAction)))
+
+	// Admin routes, each restricted to the roles granting its permission
+	mux.Handle("/admin/suspensions/", authMiddleware(middleware.RequirePermission(user.PermissionSuspendUsers)(http.HandlerFunc(adminHandler.HandleSuspension))))
+	mux.Handle("/admin/roles/", authMiddleware(middleware.RequirePermission(user.PermissionAssignRoles)(http.HandlerFunc(adminHandler.HandleRole))))
+	mux.Handle("/admin/posts/", authMiddleware(middleware.RequirePermission(user.PermissionTakedownPosts)(http.HandlerFunc(adminHandler.HandlePostTakedown))))
+	mux.Handle("/admin/invites/", authMiddleware(middleware.RequirePermission(user.PermissionManageInvites)(http.HandlerFunc(inviteHandler.HandleUserInvites))))
 
 	return mux
 }
+
+// postScope returns the scope a personal access token needs for a posts or timeline request
+func postScope(r *http.Request) string {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/like"), strings.HasSuffix(r.URL.Path, "/unlike"):
+		return accesstoken.ScopeLikesWrite
+	case r.Method == http.MethodGet:
+		return accesstoken.ScopePostsRead
+	default:
+		return accesstoken.ScopePostsWrite
+	}
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -10,9 +13,18 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	TwoFactor TwoFactorConfig
+	Mail      MailConfig
+	Account   AccountConfig
+	Admin     AdminConfig
+	OAuth     OAuthConfig
+	Lockout   LockoutConfig
+	Password  PasswordConfig
+	Signup    SignupConfig
+	Stream    StreamConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -21,6 +33,7 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	TrustedProxies  []*net.IPNet // proxies allowed to set X-Forwarded-For
 }
 
 // DatabaseConfig holds database configuration
@@ -30,20 +43,112 @@ type DatabaseConfig struct {
 
 // JWTConfig holds JWT token configuration
 type JWTConfig struct {
-	Secret []byte
-	TTL    time.Duration
+	Algorithm   string        // HS256, RS256 or EdDSA
+	Secret      []byte        // required for HS256, kept to verify older HS256 tokens otherwise
+	KeysDir     string        // directory of PEM signing keys for RS256/EdDSA, named <kid>.pem
+	ActiveKeyID string        // kid of the signing key, defaults to the last one in lexical order
+	TTL         time.Duration // access token lifetime
+	RefreshTTL  time.Duration // refresh token lifetime
 }
 
+// TwoFactorConfig holds TOTP two-factor authentication configuration
+type TwoFactorConfig struct {
+	Issuer       string        // name shown in authenticator apps
+	ChallengeTTL time.Duration // time allowed between the password and the TOTP steps of a login
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	Driver       string // "log" (development: log and optionally write .eml files) or "smtp"
+	From         string
+	Dir          string // directory where the log mailer writes messages, empty to only log them
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// AccountConfig holds account recovery, verification and deletion configuration
+type AccountConfig struct {
+	BaseURL              string        // client application URL used in the links sent by email
+	RequireVerifiedEmail bool          // only users with a verified email address may publish
+	DeletionGracePeriod  time.Duration // time during which a deleted account can be restored by logging in
+	PurgeInterval        time.Duration // how often accounts past their grace period are purged
+}
+
+// AdminConfig holds the bootstrap of the first admin account
+type AdminConfig struct {
+	Email    string // account promoted to admin at startup while no admin exists
+	Password string // password used to create that account if it does not exist yet
+}
+
+// OAuthConfig holds the OAuth 2.0 authorization server configuration
+type OAuthConfig struct {
+	CodeTTL time.Duration // lifetime of authorization codes
+}
+
+// LockoutConfig holds the brute-force protection of logins
+type LockoutConfig struct {
+	Store         string        // "memory" (single node) or "database" (shared by replicas)
+	MaxAttempts   int           // failed logins on an account before it is locked
+	IPMaxAttempts int           // failed logins from an IP address before it is blocked
+	Duration      time.Duration // lockout duration, also the window over which failures are counted
+}
+
+// PasswordConfig holds the password policy and the hashing of new passwords. Existing hashes
+// made with other settings are upgraded when their owner logs in.
+type PasswordConfig struct {
+	MinLength         int
+	MaxLength         int
+	MinEntropy        int      // minimum estimated strength, in bits
+	BannedWords       []string // words refused in every password
+	BreachedPath      string   // breached password dataset (range files directory or sorted file), empty to disable
+	Algorithm         string   // "argon2id" or "bcrypt"
+	Argon2Memory      int      // memory in KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+}
+
+// SignupConfig holds the registration settings
+type SignupConfig struct {
+	Mode        string // "open", "invite" (an invite code is required) or "closed"
+	InviteQuota int    // active invites per user, admins excepted
+}
+
+// StreamConfig holds the real-time event stream settings
+type StreamConfig struct {
+	HeartbeatInterval time.Duration // how often idle streams get a heartbeat
+	ReplayBuffer      int           // number of recent events kept for clients resuming a stream
+}
+
+// defaultTrustedProxies are the proxies trusted when TRUSTED_PROXIES is not set: loopback and
+// private networks, where the API gateway usually runs
+const defaultTrustedProxies = "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Try to load .env file (ignore error if not exists)
 	_ = godotenv.Load()
 
+	jwtAlgorithm := os.Getenv("JWT_ALGORITHM")
+	if jwtAlgorithm == "" {
+		jwtAlgorithm = "HS256"
+	}
+	if jwtAlgorithm != "HS256" && jwtAlgorithm != "RS256" && jwtAlgorithm != "EdDSA" {
+		return nil, fmt.Errorf("JWT_ALGORITHM must be one of HS256, RS256, EdDSA: %q", jwtAlgorithm)
+	}
+
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
+	if jwtSecret == "" && jwtAlgorithm == "HS256" {
 		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
 	}
 
+	jwtKeysDir := os.Getenv("JWT_KEYS_DIR")
+	if jwtKeysDir == "" {
+		jwtKeysDir = "keys"
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -54,19 +159,337 @@ func Load() (*Config, error) {
 		dbPath = "data.db"
 	}
 
+	accessTTL, err := getDuration("JWT_ACCESS_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL, err := getDuration("JWT_REFRESH_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "Social Network"
+	}
+
+	challengeTTL, err := getDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	mailDriver := os.Getenv("MAILER")
+	if mailDriver == "" {
+		mailDriver = "log"
+	}
+	if mailDriver != "log" && mailDriver != "smtp" {
+		return nil, fmt.Errorf("MAILER must be one of log, smtp: %q", mailDriver)
+	}
+
+	smtpHost := os.Getenv("SMTP_HOST")
+	if smtpHost == "" && mailDriver == "smtp" {
+		return nil, fmt.Errorf("SMTP_HOST environment variable is required with MAILER=smtp")
+	}
+
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "no-reply@localhost"
+	}
+
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	requireVerifiedEmail, err := getBool("REQUIRE_VERIFIED_EMAIL", false)
+	if err != nil {
+		return nil, err
+	}
+
+	deletionGracePeriod, err := getDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeInterval, err := getDuration("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthCodeTTL, err := getDuration("OAUTH_CODE_TTL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutStore := os.Getenv("LOGIN_LOCKOUT_STORE")
+	if lockoutStore == "" {
+		lockoutStore = "memory"
+	}
+	if lockoutStore != "memory" && lockoutStore != "database" {
+		return nil, fmt.Errorf("LOGIN_LOCKOUT_STORE must be one of memory, database: %q", lockoutStore)
+	}
+
+	maxAttempts, err := getInt("LOGIN_MAX_ATTEMPTS", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	ipMaxAttempts, err := getInt("LOGIN_IP_MAX_ATTEMPTS", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutDuration, err := getDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies := os.Getenv("TRUSTED_PROXIES")
+	if trustedProxies == "" {
+		trustedProxies = defaultTrustedProxies
+	}
+	proxies, err := parseNetworks("TRUSTED_PROXIES", trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	registrationMode := os.Getenv("REGISTRATION_MODE")
+	if registrationMode == "" {
+		registrationMode = "open"
+	}
+	if registrationMode != "open" && registrationMode != "invite" && registrationMode != "closed" {
+		return nil, fmt.Errorf("REGISTRATION_MODE must be one of open, invite, closed: %q", registrationMode)
+	}
+
+	inviteQuota, err := getInt("INVITE_QUOTA", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	streamHeartbeat, err := getDuration("STREAM_HEARTBEAT_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	streamReplayBuffer, err := getInt("STREAM_REPLAY_BUFFER", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinLength, err := getInt("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMaxLength, err := getInt("PASSWORD_MAX_LENGTH", 128)
+	if err != nil {
+		return nil, err
+	}
+	if passwordMaxLength < passwordMinLength {
+		return nil, fmt.Errorf("PASSWORD_MAX_LENGTH must be at least PASSWORD_MIN_LENGTH: %d", passwordMaxLength)
+	}
+
+	passwordMinEntropy, err := getInt("PASSWORD_MIN_ENTROPY", 30)
+	if err != nil {
+		return nil, err
+	}
+
+	var bannedWords []string
+	if value := os.Getenv("PASSWORD_BANNED_WORDS"); value != "" {
+		bannedWords = strings.Split(value, ",")
+	}
+
+	passwordAlgorithm := os.Getenv("PASSWORD_HASH_ALGORITHM")
+	if passwordAlgorithm == "" {
+		passwordAlgorithm = "argon2id"
+	}
+	if passwordAlgorithm != "argon2id" && passwordAlgorithm != "bcrypt" {
+		return nil, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be one of argon2id, bcrypt: %q", passwordAlgorithm)
+	}
+
+	argon2Memory, err := getInt("ARGON2_MEMORY", 64*1024)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Iterations, err := getInt("ARGON2_ITERATIONS", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Parallelism, err := getInt("ARGON2_PARALLELISM", 2)
+	if err != nil {
+		return nil, err
+	}
+	if argon2Parallelism > 255 {
+		return nil, fmt.Errorf("ARGON2_PARALLELISM must be at most 255: %d", argon2Parallelism)
+	}
+
+	bcryptCost, err := getInt("BCRYPT_COST", 10)
+	if err != nil {
+		return nil, err
+	}
+	if bcryptCost < 4 || bcryptCost > 31 {
+		return nil, fmt.Errorf("BCRYPT_COST must be between 4 and 31: %d", bcryptCost)
+	}
+
 	return &Config{
 		Server: ServerConfig{
 			Port:            port,
 			ReadTimeout:     15 * time.Second,
 			WriteTimeout:    15 * time.Second,
 			ShutdownTimeout: 5 * time.Second,
+			TrustedProxies:  proxies,
 		},
 		Database: DatabaseConfig{
 			Path: dbPath,
 		},
 		JWT: JWTConfig{
-			Secret: []byte(jwtSecret),
-			TTL:    24 * time.Hour,
+			Algorithm:   jwtAlgorithm,
+			Secret:      []byte(jwtSecret),
+			KeysDir:     jwtKeysDir,
+			ActiveKeyID: os.Getenv("JWT_ACTIVE_KID"),
+			TTL:         accessTTL,
+			RefreshTTL:  refreshTTL,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:       totpIssuer,
+			ChallengeTTL: challengeTTL,
+		},
+		Mail: MailConfig{
+			Driver:       mailDriver,
+			From:         mailFrom,
+			Dir:          os.Getenv("MAIL_DIR"),
+			SMTPHost:     smtpHost,
+			SMTPPort:     smtpPort,
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
+		Account: AccountConfig{
+			BaseURL:              baseURL,
+			RequireVerifiedEmail: requireVerifiedEmail,
+			DeletionGracePeriod:  deletionGracePeriod,
+			PurgeInterval:        purgeInterval,
+		},
+		Admin: AdminConfig{
+			Email:    os.Getenv("BOOTSTRAP_ADMIN_EMAIL"),
+			Password: os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"),
+		},
+		OAuth: OAuthConfig{
+			CodeTTL: oauthCodeTTL,
+		},
+		Lockout: LockoutConfig{
+			Store:         lockoutStore,
+			MaxAttempts:   maxAttempts,
+			IPMaxAttempts: ipMaxAttempts,
+			Duration:      lockoutDuration,
+		},
+		Password: PasswordConfig{
+			MinLength:         passwordMinLength,
+			MaxLength:         passwordMaxLength,
+			MinEntropy:        passwordMinEntropy,
+			BannedWords:       bannedWords,
+			BreachedPath:      os.Getenv("BREACHED_PASSWORDS_PATH"),
+			Algorithm:         passwordAlgorithm,
+			Argon2Memory:      argon2Memory,
+			Argon2Iterations:  argon2Iterations,
+			Argon2Parallelism: argon2Parallelism,
+			BcryptCost:        bcryptCost,
+		},
+		Signup: SignupConfig{
+			Mode:        registrationMode,
+			InviteQuota: inviteQuota,
+		},
+		Stream: StreamConfig{
+			HeartbeatInterval: streamHeartbeat,
+			ReplayBuffer:      streamReplayBuffer,
 		},
 	}, nil
 }
+
+// getDuration reads a duration (e.g. "15m", "720h") from an environment variable
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration (e.g. 15m, 24h): %q", key, value)
+	}
+
+	return d, nil
+}
+
+// getBool reads a boolean (true/false, 1/0) from an environment variable
+func getBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean (true or false): %q", key, value)
+	}
+
+	return b, nil
+}
+
+// getInt reads a positive integer from an environment variable
+func getInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer: %q", key, value)
+	}
+
+	return n, nil
+}
+
+// parseNetworks parses a comma-separated list of CIDR networks or IP addresses ("none" for an empty list)
+func parseNetworks(key, value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	if value == "none" {
+		return networks, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%s must list CIDR networks or IP addresses: %q", key, entry)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s must list CIDR networks or IP addresses: %q", key, entry)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
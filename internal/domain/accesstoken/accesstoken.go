@@ -0,0 +1,58 @@
+package accesstoken
+
+import (
+	"slices"
+	"time"
+)
+
+// Prefix starts every personal access token, which tells them apart from JWTs
+const Prefix = "snp_"
+
+// Scopes a personal access token can be granted
+const (
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+	ScopeLikesWrite = "likes:write"
+)
+
+// Scopes lists every scope, in display order
+var Scopes = []string{ScopePostsRead, ScopePostsWrite, ScopeLikesWrite}
+
+// Token represents a long-lived personal access token used by bots and integrations
+type Token struct {
+	ID         string
+	UserID     string
+	Name       string // label chosen by the user, e.g. the bot using the token
+	Scopes     []string
+	TokenHash  string // SHA-256 of the token, the token itself is only shown once
+	CreatedAt  time.Time
+	LastUsedAt time.Time // zero until the token is used
+	ExpiresAt  time.Time // zero if the token never expires
+}
+
+// NewToken creates a new Token instance. A zero ttl creates a token that never expires.
+func NewToken(id, userID, name, tokenHash string, scopes []string, ttl time.Duration) *Token {
+	now := time.Now()
+	t := &Token{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		t.ExpiresAt = now.Add(ttl)
+	}
+	return t
+}
+
+// IsExpired reports whether the token has expired
+func (t *Token) IsExpired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// ValidScope reports whether scope is a known scope
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
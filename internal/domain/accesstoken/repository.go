@@ -0,0 +1,28 @@
+package accesstoken
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for personal access token data access
+type Repository interface {
+	// Create stores a new token
+	Create(ctx context.Context, token *Token) error
+
+	// GetByHash retrieves a token by the hash of its value
+	GetByHash(ctx context.Context, tokenHash string) (*Token, error)
+
+	// ListForUser retrieves the tokens of a user, newest first
+	ListForUser(ctx context.Context, userID string) ([]*Token, error)
+
+	// Touch updates the token's last-used time
+	Touch(ctx context.Context, id string, usedAt time.Time) error
+
+	// Delete deletes one of the user's tokens.
+	// Returns false if the user has no token with this ID.
+	Delete(ctx context.Context, userID, id string) (bool, error)
+
+	// DeleteAllForUser deletes every token of a user, e.g. once their password is reset
+	DeleteAllForUser(ctx context.Context, userID string) error
+}
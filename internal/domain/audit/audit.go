@@ -0,0 +1,30 @@
+package audit
+
+import "time"
+
+// Audited actions
+const (
+	ActionAccountLocked = "account.locked" // too many failed logins on an account
+	ActionIPBlocked     = "ip.blocked"     // too many failed logins from an IP address
+)
+
+// Entry represents a security-relevant event kept for later investigation
+type Entry struct {
+	ID        int64
+	Action    string
+	Subject   string // what the action is about, e.g. the email address of a locked account
+	IPAddress string
+	Details   string
+	CreatedAt time.Time
+}
+
+// NewEntry creates a new Entry instance
+func NewEntry(action, subject, ipAddress, details string) *Entry {
+	return &Entry{
+		Action:    action,
+		Subject:   subject,
+		IPAddress: ipAddress,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,9 @@
+package audit
+
+import "context"
+
+// Repository defines the interface for audit log data access
+type Repository interface {
+	// Create appends an entry to the audit log
+	Create(ctx context.Context, entry *Entry) error
+}
@@ -0,0 +1,29 @@
+package follow
+
+import "time"
+
+// Follow represents a user following another user
+type Follow struct {
+	FollowerID string // ID of the user who follows
+	FolloweeID string // ID of the user being followed
+	CreatedAt  time.Time
+	// Profile is the public profile of the listed user (the follower in
+	// follower lists, the followee in following lists)
+	Profile Profile
+}
+
+// Profile represents the public profile of a user in follow lists
+type Profile struct {
+	Handle      string
+	DisplayName string
+	AvatarURL   string
+}
+
+// NewFollow creates a new Follow instance
+func NewFollow(followerID, followeeID string) *Follow {
+	return &Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	}
+}
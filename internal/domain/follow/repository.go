@@ -0,0 +1,18 @@
+package follow
+
+import "context"
+
+// Repository defines the interface for follow data access
+type Repository interface {
+	// Create records a follow relationship (no-op if it already exists)
+	Create(ctx context.Context, follow *Follow) error
+
+	// Delete removes a follow relationship (no-op if it does not exist)
+	Delete(ctx context.Context, followerID, followeeID string) error
+
+	// ListFollowers retrieves the users following the given user, most recent first
+	ListFollowers(ctx context.Context, userID string, page, limit int) ([]*Follow, error)
+
+	// ListFollowing retrieves the users followed by the given user, most recent first
+	ListFollowing(ctx context.Context, userID string, page, limit int) ([]*Follow, error)
+}
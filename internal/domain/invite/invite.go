@@ -0,0 +1,45 @@
+package invite
+
+import "time"
+
+// Invite represents an invite code allowing new users to register while registration is
+// restricted to invited users
+type Invite struct {
+	ID        string
+	CreatedBy string // ID of the user who created the invite
+	CodeHash  string // SHA-256 of the normalized code, the code itself is only shown once
+	MaxUses   int    // number of accounts that can be registered with the code
+	Uses      int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time // zero unless the creator revoked the invite
+	Invitees  []string  // handles of the users who registered with the invite, filled when listing
+}
+
+// NewInvite creates a new Invite instance
+func NewInvite(id, createdBy, codeHash string, maxUses int, ttl time.Duration) *Invite {
+	now := time.Now()
+	return &Invite{
+		ID:        id,
+		CreatedBy: createdBy,
+		CodeHash:  codeHash,
+		MaxUses:   maxUses,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// IsExpired reports whether the invite has expired
+func (i *Invite) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+// IsRevoked reports whether the invite was revoked
+func (i *Invite) IsRevoked() bool {
+	return !i.RevokedAt.IsZero()
+}
+
+// IsActive reports whether the invite can still be used to register
+func (i *Invite) IsActive() bool {
+	return !i.IsRevoked() && !i.IsExpired() && i.Uses < i.MaxUses
+}
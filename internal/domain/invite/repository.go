@@ -0,0 +1,31 @@
+package invite
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for invite data access
+type Repository interface {
+	// Create stores a new invite
+	Create(ctx context.Context, invite *Invite) error
+
+	// GetByID retrieves an invite by ID
+	GetByID(ctx context.Context, id string) (*Invite, error)
+
+	// ListByCreator retrieves the invites created by a user, newest first, with their invitees
+	ListByCreator(ctx context.Context, userID string) ([]*Invite, error)
+
+	// CountActive counts the invites of a user that can still be used at the given time
+	CountActive(ctx context.Context, userID string, at time.Time) (int, error)
+
+	// Redeem atomically counts a use of the active invite with the given code hash and
+	// returns it. It returns ErrInvalidInvite if there is no such invite.
+	Redeem(ctx context.Context, codeHash string, at time.Time) (*Invite, error)
+
+	// Release gives back a use of an invite, when the registration it was redeemed for failed
+	Release(ctx context.Context, id string) error
+
+	// Revoke marks an invite as revoked
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,16 @@
+package loginattempt
+
+import "time"
+
+// Record tracks the failed login attempts made on an account or from an IP address
+type Record struct {
+	Key         string // e.g. account:<email> or ip:<address>
+	Failures    int    // consecutive failures, forgotten once the tracking window elapses
+	LastFailure time.Time
+	LockedUntil time.Time // zero unless the key is locked out
+}
+
+// IsLocked reports whether the key is locked out at the given time
+func (r *Record) IsLocked(now time.Time) bool {
+	return now.Before(r.LockedUntil)
+}
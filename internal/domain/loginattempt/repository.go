@@ -0,0 +1,23 @@
+package loginattempt
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for failed login attempt tracking.
+// The in-memory implementation suits single-node deployments; replicas must share a store.
+type Repository interface {
+	// Get retrieves the record of a key, empty if the key has no recent failure
+	Get(ctx context.Context, key string) (*Record, error)
+
+	// RecordFailure atomically counts a failure for a key and returns the updated record.
+	// Failures older than window are forgotten first.
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*Record, error)
+
+	// Lock locks a key out until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+
+	// Reset forgets the failures of a key, e.g. after a successful login
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,154 @@
+package message
+
+import "time"
+
+// Member roles in group conversations
+const (
+	RoleAdmin  = "admin"  // can rename the conversation and add or remove members
+	RoleMember = "member" // can read, send messages and leave
+)
+
+// Conversation represents a one-to-one conversation between two users, or a group conversation
+type Conversation struct {
+	ID        string
+	IsGroup   bool
+	Name      string // group conversations only, optional
+	CreatedBy string // ID of the user who created the group (empty for one-to-one conversations)
+	CreatedAt time.Time
+	// LastMessageAt is when the latest message was sent (the creation time until then)
+	LastMessageAt time.Time
+	Members       []Member
+	// LastMessage is the latest message of the conversation (nil if there is none yet)
+	LastMessage *Message
+	// UnreadCount is the number of messages the viewer received and has not read
+	UnreadCount int
+}
+
+// Member represents a participant of a conversation
+type Member struct {
+	UserID   string
+	Role     string
+	JoinedAt time.Time
+	// LastReadSeq is the Seq of the last message the member read (group conversations only,
+	// one-to-one conversations record read receipts on each message)
+	LastReadSeq int64
+	Profile     Profile
+}
+
+// NewDirectConversation creates a new one-to-one Conversation instance
+func NewDirectConversation(id, userID, otherID string) *Conversation {
+	now := time.Now()
+	return &Conversation{
+		ID:            id,
+		CreatedAt:     now,
+		LastMessageAt: now,
+		Members: []Member{
+			{UserID: userID, Role: RoleMember, JoinedAt: now},
+			{UserID: otherID, Role: RoleMember, JoinedAt: now},
+		},
+	}
+}
+
+// NewGroupConversation creates a new group Conversation instance, administered by its creator
+func NewGroupConversation(id, creatorID, name string, memberIDs []string) *Conversation {
+	now := time.Now()
+	c := &Conversation{
+		ID:            id,
+		IsGroup:       true,
+		Name:          name,
+		CreatedBy:     creatorID,
+		CreatedAt:     now,
+		LastMessageAt: now,
+		Members:       []Member{{UserID: creatorID, Role: RoleAdmin, JoinedAt: now}},
+	}
+	for _, memberID := range memberIDs {
+		c.Members = append(c.Members, Member{UserID: memberID, Role: RoleMember, JoinedAt: now})
+	}
+	return c
+}
+
+// Member returns the participant with the given user ID (nil if the user is not a member)
+func (c *Conversation) Member(userID string) *Member {
+	for i := range c.Members {
+		if c.Members[i].UserID == userID {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+// HasMember reports whether the user takes part in the conversation
+func (c *Conversation) HasMember(userID string) bool {
+	return c.Member(userID) != nil
+}
+
+// IsAdmin reports whether the user administers the group conversation
+func (c *Conversation) IsAdmin(userID string) bool {
+	m := c.Member(userID)
+	return m != nil && m.Role == RoleAdmin
+}
+
+// Message represents a message sent in a conversation
+type Message struct {
+	ID             string
+	Seq            int64 // insertion order, to sort messages sent within the same second
+	ConversationID string
+	SenderID       string
+	RecipientID    string // one-to-one conversations only, empty in group conversations
+	Subject        string // optional
+	Content        string
+	CreatedAt      time.Time
+	ReadAt         time.Time // read receipt: when the recipient read the message (zero if unread)
+	// UnreadByViewer reports, in group conversations, whether the message comes after the
+	// viewer's last-read pointer
+	UnreadByViewer bool
+	// SenderProfile and RecipientProfile are the public profiles of the participants
+	SenderProfile    Profile
+	RecipientProfile Profile
+}
+
+// NewMessage creates a new Message instance
+func NewMessage(id, conversationID, senderID, recipientID, subject, content string) *Message {
+	return &Message{
+		ID:             id,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		RecipientID:    recipientID,
+		Subject:        subject,
+		Content:        content,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// IsDirect reports whether the message was sent in a one-to-one conversation
+func (m *Message) IsDirect() bool {
+	return m.RecipientID != ""
+}
+
+// IsRead reports whether the recipient read the message (one-to-one conversations)
+func (m *Message) IsRead() bool {
+	return !m.ReadAt.IsZero()
+}
+
+// Profile represents the public profile of a participant
+type Profile struct {
+	Handle      string
+	DisplayName string
+	AvatarURL   string
+}
+
+// Contact represents a user who shares at least one conversation with another
+type Contact struct {
+	UserID  string
+	Profile Profile
+}
+
+// ReadStatus filters messages on whether they were read
+type ReadStatus int
+
+// Read status filters
+const (
+	AnyStatus ReadStatus = iota
+	OnlyRead
+	OnlyUnread
+)
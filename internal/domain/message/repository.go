@@ -0,0 +1,69 @@
+package message
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for message and conversation data access
+type Repository interface {
+	// EnsureDirectConversation stores a one-to-one conversation unless its two members already
+	// have one, and returns the stored conversation
+	EnsureDirectConversation(ctx context.Context, conversation *Conversation) (*Conversation, error)
+
+	// CreateGroupConversation stores a new group conversation with its members
+	CreateGroupConversation(ctx context.Context, conversation *Conversation) error
+
+	// GetConversation retrieves a conversation by ID with its members, computing the unread
+	// count for viewerID
+	GetConversation(ctx context.Context, id, viewerID string) (*Conversation, error)
+
+	// ListConversations retrieves the conversations of a user with their latest message,
+	// most recently active first
+	ListConversations(ctx context.Context, userID string, page, limit int) ([]*Conversation, error)
+
+	// ListContacts retrieves the users who share at least one conversation with a user
+	ListContacts(ctx context.Context, userID string) ([]Contact, error)
+
+	// Rename changes the name of a group conversation
+	Rename(ctx context.Context, id, name string) error
+
+	// AddMembers adds members to a group conversation (existing members are left untouched).
+	// Their last-read pointer starts at the latest message.
+	AddMembers(ctx context.Context, id string, userIDs []string, joinedAt time.Time) error
+
+	// RemoveMember removes a member from a group conversation, promotes the oldest remaining
+	// member when the last admin leaves, and deletes the conversation once nobody is left
+	RemoveMember(ctx context.Context, id, userID string) error
+
+	// MarkConversationRead marks the messages of a conversation up to upToSeq as read by the
+	// user: the user's last-read pointer moves there, and the messages the user received in
+	// a one-to-one conversation get a read receipt
+	MarkConversationRead(ctx context.Context, id, userID string, upToSeq int64, at time.Time) error
+
+	// SetLastRead moves the last-read pointer of a member of a group conversation
+	SetLastRead(ctx context.Context, id, userID string, seq int64) error
+
+	// Create stores a new message and updates the activity of its conversation
+	Create(ctx context.Context, message *Message) error
+
+	// GetByID retrieves a message by ID
+	GetByID(ctx context.Context, id string) (*Message, error)
+
+	// ListReceived retrieves the messages received by a user in one-to-one conversations,
+	// newest first
+	ListReceived(ctx context.Context, userID string, status ReadStatus, page, limit int) ([]*Message, error)
+
+	// ListByConversation retrieves the messages of a conversation sent before beforeSeq
+	// (all of them if 0), newest first
+	ListByConversation(ctx context.Context, conversationID string, beforeSeq int64, page, limit int) ([]*Message, error)
+
+	// CountUnread counts the messages a user has not read, in all their conversations
+	CountUnread(ctx context.Context, userID string) (int, error)
+
+	// SetReadAt records when a message was read (a zero time marks it as unread)
+	SetReadAt(ctx context.Context, id string, readAt time.Time) error
+
+	// Delete deletes a message
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,54 @@
+package notification
+
+import "time"
+
+// Notification types
+const (
+	TypeLike    = "like"    // someone liked one of the user's posts
+	TypeReply   = "reply"   // someone replied to one of the user's posts
+	TypeMention = "mention" // someone mentioned the user in a post
+	TypeFollow  = "follow"  // someone followed the user
+)
+
+// Notification tells a user about what other users did. Events of the same type about the
+// same post (or, for follows, about the user) are aggregated into a single notification
+// while it is unread: "X and 4 others liked your post".
+type Notification struct {
+	ID     string
+	UserID string // ID of the notified user
+	Type   string
+	// PostID is the liked post, the post replied to, or the post mentioning the user
+	// (empty for follows)
+	PostID string
+	// Seq orders the notifications of a user and serves as read cursor: it moves forward
+	// whenever an actor is added
+	Seq int64
+	// Actors are the latest users behind the notification, most recent first
+	Actors []Actor
+	// ActorsCount is the total number of users behind the notification
+	ActorsCount int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time // when the latest actor was added
+	ReadAt      time.Time // zero while unread
+}
+
+// IsRead reports whether the user read the notification
+func (n *Notification) IsRead() bool {
+	return !n.ReadAt.IsZero()
+}
+
+// Actor represents the public profile of a user behind a notification
+type Actor struct {
+	UserID      string
+	Handle      string
+	DisplayName string
+	AvatarURL   string
+}
+
+// Event represents something a user (the actor) did that concerns another user (the recipient)
+type Event struct {
+	Type        string
+	RecipientID string
+	ActorID     string
+	PostID      string // empty for follows
+}
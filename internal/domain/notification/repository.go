@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for notification data access
+type Repository interface {
+	// Record adds the actor of an event to the recipient's unread notification of the same
+	// type about the same post, or creates a notification with the given ID if there is none.
+	// Nothing is recorded if the actor already appears in a notification of the same type
+	// about the same post, so that repeated actions only notify once. It returns the ID of
+	// the notification, or an empty string if nothing was recorded.
+	Record(ctx context.Context, id string, event Event, at time.Time) (string, error)
+
+	// Withdraw removes the actor of an event from the recipient's unread notifications of the
+	// same type about the same post, deleting the notifications left without actors
+	Withdraw(ctx context.Context, event Event) error
+
+	// GetByID retrieves a notification by ID
+	GetByID(ctx context.Context, id string) (*Notification, error)
+
+	// List retrieves the notifications of a user with a Seq lower than beforeSeq (all of them
+	// if 0), most recent first. Notifications about deleted posts are skipped.
+	List(ctx context.Context, userID string, beforeSeq int64, page, limit int) ([]*Notification, error)
+
+	// CountUnread counts the notifications a user has not read
+	CountUnread(ctx context.Context, userID string) (int, error)
+
+	// MarkRead marks the notifications of a user up to upToSeq as read (all of them if 0)
+	MarkRead(ctx context.Context, userID string, upToSeq int64, at time.Time) error
+}
@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"slices"
+	"time"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method accepted (RFC 7636), plain challenges being refused
+const CodeChallengeMethodS256 = "S256"
+
+// Client represents a third-party application registered to act on behalf of users
+type Client struct {
+	ID           string
+	OwnerID      string // user who registered the client
+	Name         string // shown to users on the consent screen
+	SecretHash   string // SHA-256 of the client secret, empty for public clients (e.g. mobile apps)
+	RedirectURIs []string
+	Scopes       []string // scopes the client may request
+	CreatedAt    time.Time
+}
+
+// NewClient creates a new Client instance. An empty secretHash creates a public client.
+func NewClient(id, ownerID, name, secretHash string, redirectURIs, scopes []string) *Client {
+	return &Client{
+		ID:           id,
+		OwnerID:      ownerID,
+		Name:         name,
+		SecretHash:   secretHash,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// IsConfidential reports whether the client authenticates with a secret
+func (c *Client) IsConfidential() bool {
+	return c.SecretHash != ""
+}
+
+// HasRedirectURI reports whether uri is one of the client's registered redirect URIs (exact match)
+func (c *Client) HasRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AuthorizationCode represents a single-use code a client exchanges for tokens once the user consented
+type AuthorizationCode struct {
+	ID                  string
+	CodeHash            string // SHA-256 of the code, the code itself is only sent to the client
+	ClientID            string
+	UserID              string
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+	UsedAt              time.Time // zero until the code is exchanged
+	SessionID           string    // session the code was exchanged for, revoked if the code is replayed
+}
+
+// NewAuthorizationCode creates a new AuthorizationCode instance
+func NewAuthorizationCode(id, codeHash, clientID, userID, redirectURI string, scopes []string, codeChallenge, codeChallengeMethod string, ttl time.Duration) *AuthorizationCode {
+	now := time.Now()
+	return &AuthorizationCode{
+		ID:                  id,
+		CodeHash:            codeHash,
+		ClientID:            clientID,
+		UserID:              userID,
+		RedirectURI:         redirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		CreatedAt:           now,
+		ExpiresAt:           now.Add(ttl),
+	}
+}
+
+// IsUsed reports whether the code has already been exchanged
+func (c *AuthorizationCode) IsUsed() bool {
+	return !c.UsedAt.IsZero()
+}
+
+// IsExpired reports whether the code has expired
+func (c *AuthorizationCode) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// Consent records the scopes a user granted to a client
+type Consent struct {
+	UserID    string
+	ClientID  string
+	Scopes    []string
+	UpdatedAt time.Time
+}
+
+// Covers reports whether the consent includes every scope in scopes
+func (c *Consent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package oauth
+
+import "context"
+
+// Repository defines the interface for OAuth clients, authorization codes and consents data access
+type Repository interface {
+	// CreateClient stores a new client
+	CreateClient(ctx context.Context, client *Client) error
+
+	// GetClient retrieves a client by ID
+	GetClient(ctx context.Context, id string) (*Client, error)
+
+	// ListClientsByOwner retrieves the clients registered by a user, newest first
+	ListClientsByOwner(ctx context.Context, ownerID string) ([]*Client, error)
+
+	// DeleteClient deletes a client along with its codes and consents
+	DeleteClient(ctx context.Context, id string) error
+
+	// CreateCode stores a new authorization code
+	CreateCode(ctx context.Context, code *AuthorizationCode) error
+
+	// GetCodeByHash retrieves an authorization code by the hash of its value
+	GetCodeByHash(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+
+	// UseCode marks an authorization code as exchanged.
+	// Returns false if the code had already been used (concurrent exchange).
+	UseCode(ctx context.Context, id string) (bool, error)
+
+	// SetCodeSession records the session an authorization code was exchanged for
+	SetCodeSession(ctx context.Context, id, sessionID string) error
+
+	// GetConsent retrieves the consent a user gave to a client, or nil if there is none
+	GetConsent(ctx context.Context, userID, clientID string) (*Consent, error)
+
+	// SaveConsent creates or replaces the consent a user gave to a client
+	SaveConsent(ctx context.Context, consent *Consent) error
+}
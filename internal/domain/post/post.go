@@ -4,22 +4,80 @@ import "time"
 
 // Post represents a post in the system
 type Post struct {
-	ID         string
-	Author     string
-	Content    string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	LikesCount int
+	ID           string
+	AuthorID     string // ID of the author, empty for tombstones
+	Content      string
+	ParentID     string // empty for top-level posts
+	RootID       string // top-level post of the conversation, empty for top-level posts
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LikesCount   int
+	RepliesCount int
+	// Deleted marks a tombstone left in place of a deleted post that still has replies
+	Deleted bool
+	// LikedByMe reports whether the user viewing the post has liked it
+	LikedByMe     bool
+	AuthorProfile AuthorProfile
+}
+
+// NewReply creates a new Post instance replying to the given parent
+func NewReply(id, authorID, content string, parent *Post) *Post {
+	p := NewPost(id, authorID, content)
+	p.ParentID = parent.ID
+	p.RootID = parent.RootID
+	if p.RootID == "" {
+		p.RootID = parent.ID
+	}
+	return p
+}
+
+// AuthorProfile represents the public profile of a post's author
+type AuthorProfile struct {
+	Handle      string
+	DisplayName string
+	AvatarURL   string
+	PostsCount  int
 }
 
 // NewPost creates a new Post instance
-func NewPost(id, author, content string) *Post {
+func NewPost(id, authorID, content string) *Post {
 	now := time.Now()
 	return &Post{
 		ID:        id,
-		Author:    author,
+		AuthorID:  authorID,
 		Content:   content,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
+
+// Revision represents a previous version of a post's content
+type Revision struct {
+	ID         int64
+	PostID     string
+	Content    string
+	CreatedAt  time.Time // when this version was written
+	ReplacedAt time.Time // when this version was replaced by an edit
+}
+
+// ThreadNode represents a post and its loaded replies in a conversation tree
+type ThreadNode struct {
+	Post    *Post
+	Replies []*ThreadNode
+	// HasMoreReplies reports whether some replies were not loaded (depth or page limit reached)
+	HasMoreReplies bool
+}
+
+// Thread represents a conversation tree rooted at a post
+type Thread struct {
+	// Ancestors lists the posts above the root node down to its parent, from the conversation's
+	// top-level post unless the chain is too long (the first ancestor then has a parent)
+	Ancestors []*Post
+	Root      *ThreadNode
+}
+
+// LikesChange represents the likes count of a post after a user liked or unliked it
+type LikesChange struct {
+	PostID     string
+	LikesCount int
+}
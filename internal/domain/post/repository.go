@@ -1,24 +1,58 @@
 package post
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for post data access
 type Repository interface {
 	// Create creates a new post
 	Create(ctx context.Context, post *Post) error
 
-	// GetByID retrieves a post by ID
-	GetByID(ctx context.Context, id string) (*Post, error)
+	// GetByID retrieves a post by ID, computing viewer-specific fields for viewerID
+	GetByID(ctx context.Context, id, viewerID string) (*Post, error)
 
-	// ListBefore retrieves posts created before a given timestamp with pagination
-	ListBefore(ctx context.Context, beforeTimestamp int64, page, limit int) ([]*Post, error)
+	// ListBefore retrieves top-level posts created before a given timestamp with pagination,
+	// computing viewer-specific fields for viewerID
+	ListBefore(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*Post, error)
+
+	// ListTimeline retrieves top-level posts from the viewer and the users they follow,
+	// created before a given timestamp with pagination
+	ListTimeline(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*Post, error)
+
+	// ListReplies retrieves, for each parent, its direct replies in chronological order,
+	// skipping the first offset replies and returning at most limit replies per parent
+	ListReplies(ctx context.Context, viewerID string, parentIDs []string, offset, limit int) ([]*Post, error)
+
+	// ListAncestors retrieves the posts above a post, from the nearest up to limit levels,
+	// returned top-down (the farthest first)
+	ListAncestors(ctx context.Context, id, viewerID string, limit int) ([]*Post, error)
+
+	// ListByAuthor retrieves every post and reply of the given author, oldest first (tombstones excluded)
+	ListByAuthor(ctx context.Context, authorID string) ([]*Post, error)
+
+	// ListLikedBy retrieves every post liked by the given user, oldest first
+	ListLikedBy(ctx context.Context, userID string) ([]*Post, error)
+
+	// Update saves the post's new content and records the previous version as a revision
+	Update(ctx context.Context, post *Post) error
+
+	// Delete deletes a post along with its likes and revisions
+	Delete(ctx context.Context, id string) error
+
+	// Tombstone clears a post's content, likes and revisions but keeps it in place for its replies
+	Tombstone(ctx context.Context, id string, deletedAt time.Time) error
+
+	// ListRevisions retrieves the previous versions of a post, newest first
+	ListRevisions(ctx context.Context, postID string) ([]*Revision, error)
 
 	// AddLike adds a like to a post
-	AddLike(ctx context.Context, userEmail, postID string) error
+	AddLike(ctx context.Context, userID, postID string) error
 
 	// RemoveLike removes a like from a post
-	RemoveLike(ctx context.Context, userEmail, postID string) error
+	RemoveLike(ctx context.Context, userID, postID string) error
 
-	// Exists checks if a post with the given ID exists
+	// Exists checks if a post with the given ID exists and is not a tombstone
 	Exists(ctx context.Context, postID string) (bool, error)
 }
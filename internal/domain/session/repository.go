@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for session data access
+type Repository interface {
+	// Create creates a new session along with its first refresh token
+	Create(ctx context.Context, session *Session, token *RefreshToken) error
+
+	// GetByID retrieves a session by ID
+	GetByID(ctx context.Context, id string) (*Session, error)
+
+	// ListActiveForUser retrieves the non-revoked sessions of a user, most recently seen first
+	ListActiveForUser(ctx context.Context, userID string) ([]*Session, error)
+
+	// RecordAccess updates the session's last-seen time, client details and latest access token ID
+	RecordAccess(ctx context.Context, session *Session) error
+
+	// Touch updates the session's last-seen time
+	Touch(ctx context.Context, id string, seenAt time.Time) error
+
+	// GetRefreshToken retrieves a refresh token by the hash of its value
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+
+	// Rotate marks the old refresh token as used and stores its replacement atomically.
+	// It returns ErrRefreshTokenReused if the old token was already used.
+	Rotate(ctx context.Context, oldTokenID string, newToken *RefreshToken) error
+
+	// Revoke revokes a session
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+
+	// RevokeAllForUser revokes every active session of a user
+	RevokeAllForUser(ctx context.Context, userID string, revokedAt time.Time) error
+
+	// RevokeAllForClient revokes every active session granted to an OAuth client
+	RevokeAllForClient(ctx context.Context, clientID string, revokedAt time.Time) error
+}
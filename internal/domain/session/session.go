@@ -0,0 +1,74 @@
+package session
+
+import "time"
+
+// Session represents a login session, i.e. a family of rotating refresh tokens
+type Session struct {
+	ID            string
+	UserID        string
+	UserAgent     string
+	IPAddress     string
+	AccessTokenID string // jti of the latest access token issued for the session
+	CreatedAt     time.Time
+	LastSeenAt    time.Time
+	RevokedAt     time.Time // zero while the session is active
+	// ClientID is the OAuth client the user granted the session to (empty for logins),
+	// restricted to Scopes
+	ClientID string
+	Scopes   []string
+}
+
+// NewSession creates a new Session instance for the device described by userAgent and ipAddress
+func NewSession(id, userID, userAgent, ipAddress string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+// IsDelegated reports whether the session was granted to an OAuth client
+func (s *Session) IsDelegated() bool {
+	return s.ClientID != ""
+}
+
+// IsRevoked reports whether the session has been revoked
+func (s *Session) IsRevoked() bool {
+	return !s.RevokedAt.IsZero()
+}
+
+// RefreshToken represents a single-use opaque refresh token belonging to a session
+type RefreshToken struct {
+	ID        string
+	SessionID string
+	TokenHash string // SHA-256 of the opaque token, the token itself is never stored
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero until the token is exchanged
+}
+
+// NewRefreshToken creates a new RefreshToken instance
+func NewRefreshToken(id, sessionID, tokenHash string, ttl time.Duration) *RefreshToken {
+	now := time.Now()
+	return &RefreshToken{
+		ID:        id,
+		SessionID: sessionID,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// IsUsed reports whether the token has already been exchanged
+func (t *RefreshToken) IsUsed() bool {
+	return !t.UsedAt.IsZero()
+}
+
+// IsExpired reports whether the token has expired
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -1,6 +1,9 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for user data access
 type Repository interface {
@@ -10,6 +13,73 @@ type Repository interface {
 	// GetByEmail retrieves a user by email
 	GetByEmail(ctx context.Context, email string) (*User, error)
 
+	// GetByID retrieves a user by ID
+	GetByID(ctx context.Context, id string) (*User, error)
+
+	// GetByHandle retrieves a user by handle
+	GetByHandle(ctx context.Context, handle string) (*User, error)
+
+	// UpdateProfile saves the user's public profile fields (handle, display name, bio, avatar)
+	UpdateProfile(ctx context.Context, user *User) error
+
+	// UpdatePassword saves a new password hash for the user
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+
+	// RehashPassword replaces the password hash of the user with an equivalent one, unless the
+	// password was changed meanwhile
+	RehashPassword(ctx context.Context, id, oldHash, newHash string) error
+
+	// ChangeEmail sets a new email address for the user.
+	// The new address starts unverified and pending email tokens are discarded.
+	ChangeEmail(ctx context.Context, id, newEmail string) error
+
+	// MarkEmailVerified records that the user proved ownership of their email address
+	MarkEmailVerified(ctx context.Context, id string) error
+
+	// UpdateTwoFactor saves the user's TOTP secret and enabled flag
+	UpdateTwoFactor(ctx context.Context, user *User) error
+
+	// UseTOTPStep records the time step of an accepted TOTP code.
+	// Returns false if that step, or a later one, was already used (replayed code).
+	UseTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes replaces the user's recovery codes with the given hashes
+	ReplaceRecoveryCodes(ctx context.Context, id string, codeHashes []string) error
+
+	// UseRecoveryCode consumes the unused recovery code with the given hash.
+	// Returns false if no such code exists.
+	UseRecoveryCode(ctx context.Context, id, codeHash string) (bool, error)
+
+	// CountRecoveryCodes counts the user's unused recovery codes
+	CountRecoveryCodes(ctx context.Context, id string) (int, error)
+
+	// UpdateRole saves the user's role
+	UpdateRole(ctx context.Context, id string, role Role) error
+
+	// UpdateSuspension suspends the user's account at the given time, or lifts the suspension
+	// when the time is zero
+	UpdateSuspension(ctx context.Context, id string, suspendedAt time.Time) error
+
+	// CountByRole counts the users having the given role
+	CountByRole(ctx context.Context, role Role) (int, error)
+
+	// ScheduleDeletion records that the user's account is to be purged at the given time
+	ScheduleDeletion(ctx context.Context, id string, at time.Time) error
+
+	// CancelDeletion cancels the pending deletion of the user's account.
+	// Returns false if no deletion was pending.
+	CancelDeletion(ctx context.Context, id string) (bool, error)
+
+	// ListDueForDeletion retrieves up to limit users whose scheduled deletion time has passed
+	ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*User, error)
+
+	// Purge erases the user's account: personal data, sessions, likes, follows and posts.
+	// Posts other users replied to are kept, attributed to an anonymous account.
+	Purge(ctx context.Context, id string) error
+
 	// Exists checks if a user with the given email exists
 	Exists(ctx context.Context, email string) (bool, error)
+
+	// HandleExists checks if a user with the given handle exists
+	HandleExists(ctx context.Context, handle string) (bool, error)
 }
@@ -0,0 +1,47 @@
+package user
+
+import "slices"
+
+// Role determines which administrative actions a user may perform
+type Role string
+
+// Roles, from least to most privileged
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permissions granted by roles, checked by the admin routes
+const (
+	PermissionSuspendUsers  = "users:suspend"
+	PermissionTakedownPosts = "posts:takedown"
+	PermissionAssignRoles   = "users:roles"
+	PermissionManageInvites = "invites:manage"
+)
+
+// Roles lists every role, from least to most privileged
+var Roles = []Role{RoleUser, RoleModerator, RoleAdmin}
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[Role][]string{
+	RoleUser:      nil,
+	RoleModerator: {PermissionSuspendUsers, PermissionTakedownPosts},
+	RoleAdmin:     {PermissionSuspendUsers, PermissionTakedownPosts, PermissionAssignRoles, PermissionManageInvites},
+}
+
+// Valid reports whether the role exists
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions returns the permissions granted by the role
+func (r Role) Permissions() []string {
+	return slices.Clone(rolePermissions[r])
+}
+
+// Outranks reports whether the role is more privileged than the other one
+func (r Role) Outranks(other Role) bool {
+	return slices.Index(Roles, r) > slices.Index(Roles, other)
+}
@@ -1,22 +1,66 @@
 package user
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
-	Email        string
-	PasswordHash string // bcrypt hash (salt is embedded in the hash)
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID            string // opaque, stable identifier; the email address can change
+	Email         string
+	PasswordHash  string // argon2id or bcrypt hash (salt and parameters are embedded in the hash)
+	EmailVerified bool   // whether the user proved ownership of the email address
+	Handle        string // unique public identifier, displayed as @handle
+	DisplayName   string
+	Bio           string
+	AvatarURL     string
+	TOTPSecret    string // base32 TOTP secret, set at enrollment and in use once TOTPEnabled is true
+	TOTPEnabled   bool   // whether login requires a second factor
+	Role          Role
+	SuspendedAt   time.Time // when a moderator suspended the account (zero if not suspended)
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// DeletionScheduledAt is when the account will be purged after its owner asked for its
+	// deletion (zero if no deletion is pending)
+	DeletionScheduledAt time.Time
+	InvitedBy           string // ID of the user whose invite was used to register (empty if none)
+	InviteID            string // ID of that invite
+}
+
+// IsSuspended reports whether the account is suspended
+func (u *User) IsSuspended() bool {
+	return !u.SuspendedAt.IsZero()
+}
+
+// IsDeletionPending reports whether the account is scheduled for deletion
+func (u *User) IsDeletionPending() bool {
+	return !u.DeletionScheduledAt.IsZero()
 }
 
 // NewUser creates a new User instance
-func NewUser(email, passwordHash string) *User {
+func NewUser(id, email, handle, passwordHash string) *User {
 	now := time.Now()
 	return &User{
+		ID:           id,
 		Email:        email,
 		PasswordHash: passwordHash,
+		Handle:       handle,
+		Role:         RoleUser,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 }
+
+// NormalizeHandle trims and lowercases a handle, removing the leading @
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// ProfileUpdate holds the profile fields to change; nil fields are left untouched
+type ProfileUpdate struct {
+	Handle      *string
+	DisplayName *string
+	Bio         *string
+	AvatarURL   *string
+}
@@ -0,0 +1,16 @@
+package verification
+
+import "context"
+
+// Repository defines the interface for verification token data access
+type Repository interface {
+	// Create stores a new token, replacing the user's unused tokens with the same purpose
+	Create(ctx context.Context, token *Token) error
+
+	// GetByHash retrieves a token by the hash of its value
+	GetByHash(ctx context.Context, tokenHash string) (*Token, error)
+
+	// MarkUsed marks a token as used.
+	// Returns false if the token had already been used (concurrent use).
+	MarkUsed(ctx context.Context, id string) (bool, error)
+}
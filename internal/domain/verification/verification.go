@@ -0,0 +1,43 @@
+package verification
+
+import "time"
+
+// Token purposes
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+
+// Token represents a single-use token sent by email to prove ownership of the address
+type Token struct {
+	ID        string
+	UserID    string
+	Purpose   string
+	TokenHash string // SHA-256 of the token, the token itself is only sent by email
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero until the token is used
+}
+
+// NewToken creates a new Token instance
+func NewToken(id, userID, purpose, tokenHash string, ttl time.Duration) *Token {
+	now := time.Now()
+	return &Token{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// IsUsed reports whether the token has already been used
+func (t *Token) IsUsed() bool {
+	return !t.UsedAt.IsZero()
+}
+
+// IsExpired reports whether the token has expired
+func (t *Token) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
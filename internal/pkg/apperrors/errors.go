@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // AppError represents an application error with HTTP status code
@@ -13,6 +14,8 @@ type AppError struct {
 	Err     error
 	// ValidationErrors contains field-specific validation errors
 	ValidationErrors map[string]string
+	// RetryAfter tells the client how long to wait before retrying (sent as the Retry-After header)
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface
@@ -54,19 +57,65 @@ func Wrap(err error, code int, message string) *AppError {
 	}
 }
 
+// WithRetryAfter returns a copy of the error telling the client to retry after d
+func (e *AppError) WithRetryAfter(d time.Duration) *AppError {
+	withRetry := *e
+	withRetry.RetryAfter = d
+	return &withRetry
+}
+
 // Common application errors
 var (
-	ErrBadRequest         = New(http.StatusBadRequest, "bad request")
-	ErrUnauthorized       = New(http.StatusUnauthorized, "unauthorized")
-	ErrForbidden          = New(http.StatusForbidden, "forbidden")
-	ErrNotFound           = New(http.StatusNotFound, "not found")
-	ErrConflict           = New(http.StatusConflict, "conflict")
-	ErrInternalServer     = New(http.StatusInternalServerError, "internal server error")
-	ErrInvalidCredentials = New(http.StatusUnauthorized, "invalid credentials")
-	ErrUserAlreadyExists  = New(http.StatusConflict, "user already exists")
-	ErrPostNotFound       = New(http.StatusNotFound, "post not found")
-	ErrInvalidToken       = New(http.StatusUnauthorized, "invalid token")
-	ErrMissingAuth        = New(http.StatusUnauthorized, "missing authorization header")
+	ErrBadRequest               = New(http.StatusBadRequest, "bad request")
+	ErrUnauthorized             = New(http.StatusUnauthorized, "unauthorized")
+	ErrForbidden                = New(http.StatusForbidden, "forbidden")
+	ErrNotFound                 = New(http.StatusNotFound, "not found")
+	ErrConflict                 = New(http.StatusConflict, "conflict")
+	ErrInternalServer           = New(http.StatusInternalServerError, "internal server error")
+	ErrInvalidCredentials       = New(http.StatusUnauthorized, "invalid credentials")
+	ErrUserAlreadyExists        = New(http.StatusConflict, "user already exists")
+	ErrUserNotFound             = New(http.StatusNotFound, "user not found")
+	ErrEmailTaken               = New(http.StatusConflict, "email address already in use")
+	ErrInvalidPassword          = New(http.StatusForbidden, "current password is incorrect")
+	ErrPasswordTooLong          = New(http.StatusBadRequest, "password is too long for the configured hashing algorithm (72 bytes max)")
+	ErrHandleTaken              = New(http.StatusConflict, "handle already taken")
+	ErrCannotFollowSelf         = New(http.StatusBadRequest, "you cannot follow yourself")
+	ErrPostNotFound             = New(http.StatusNotFound, "post not found")
+	ErrNotPostAuthor            = New(http.StatusForbidden, "only the author can modify this post")
+	ErrInvalidToken             = New(http.StatusUnauthorized, "invalid token")
+	ErrMissingAuth              = New(http.StatusUnauthorized, "missing authorization header")
+	ErrSessionNotFound          = New(http.StatusNotFound, "session not found")
+	ErrSessionRevoked           = New(http.StatusUnauthorized, "session revoked")
+	ErrInvalidRefreshToken      = New(http.StatusUnauthorized, "invalid refresh token")
+	ErrRefreshTokenReused       = New(http.StatusUnauthorized, "refresh token reused, session revoked")
+	ErrInvalidChallenge         = New(http.StatusUnauthorized, "invalid or expired two-factor challenge")
+	ErrInvalidOTP               = New(http.StatusUnauthorized, "invalid two-factor code")
+	ErrTwoFactorEnabled         = New(http.StatusConflict, "two-factor authentication is already enabled")
+	ErrTwoFactorDisabled        = New(http.StatusBadRequest, "two-factor authentication is not enabled")
+	ErrTwoFactorNotPending      = New(http.StatusBadRequest, "two-factor enrollment has not been started")
+	ErrInvalidVerificationToken = New(http.StatusBadRequest, "invalid or expired token")
+	ErrEmailNotVerified         = New(http.StatusForbidden, "email address not verified")
+	ErrEmailAlreadyVerified     = New(http.StatusConflict, "email address already verified")
+	ErrAccountSuspended         = New(http.StatusForbidden, "account suspended")
+	ErrCannotModerateSelf       = New(http.StatusForbidden, "you cannot moderate your own account")
+	ErrAccessTokenNotFound      = New(http.StatusNotFound, "access token not found")
+	ErrInsufficientScope        = New(http.StatusForbidden, "token does not grant the scope required by this route")
+	ErrOAuthClientNotFound      = New(http.StatusNotFound, "OAuth client not found")
+	ErrTooManyAttempts          = New(http.StatusTooManyRequests, "too many failed login attempts, try again later")
+	ErrTooManyRequests          = New(http.StatusTooManyRequests, "too many requests, try again later")
+	ErrAccountLocked            = New(http.StatusLocked, "account temporarily locked after too many failed login attempts")
+	ErrRegistrationClosed       = New(http.StatusForbidden, "registration is closed")
+	ErrInvalidInvite            = New(http.StatusBadRequest, "invite code is invalid, expired or used up")
+	ErrInviteNotFound           = New(http.StatusNotFound, "invite not found")
+	ErrInviteQuotaExceeded      = New(http.StatusForbidden, "too many active invites")
+	ErrConversationNotFound     = New(http.StatusNotFound, "conversation not found")
+	ErrMessageNotFound          = New(http.StatusNotFound, "message not found")
+	ErrCannotMessageSelf        = New(http.StatusBadRequest, "you cannot send a message to yourself")
+	ErrNotMessageRecipient      = New(http.StatusForbidden, "only the recipient can change the read status of this message")
+	ErrNotMessageSender         = New(http.StatusForbidden, "only the sender can delete this message")
+	ErrNotGroupConversation     = New(http.StatusBadRequest, "this action is only available in group conversations")
+	ErrNotConversationAdmin     = New(http.StatusForbidden, "only conversation admins can do this")
+	ErrNotConversationMember    = New(http.StatusNotFound, "user is not a member of this conversation")
 )
 
 // AsAppError converts an error to AppError if possible
@@ -0,0 +1,163 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypePost         = "post"         // a post or a reply was published (data: *post.Post)
+	TypeLikes        = "likes"        // the likes count of a post changed (data: post.LikesChange)
+	TypeNotification = "notification" // a notification was created or updated (data: *notification.Notification)
+)
+
+// subscriptionBuffer is the number of events a subscriber can lag behind before being dropped
+const subscriptionBuffer = 64
+
+// Event is something that happened in the application, pushed to the subscribers
+type Event struct {
+	ID     int64
+	Type   string
+	UserID string      // user the event is meant for, empty if every subscriber receives it
+	Data   interface{} // payload, a domain object
+}
+
+// Broker fans events out to in-process subscribers and keeps the latest ones, so that
+// subscribers who lost their connection can resume where they left off
+type Broker struct {
+	mu            sync.Mutex
+	lastID        int64
+	replay        []Event // ring buffer of the latest events
+	next          int     // position of the next event in the ring buffer
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+}
+
+// NewBroker creates a new broker keeping the given number of events for replay
+func NewBroker(replaySize int) *Broker {
+	return &Broker{
+		// IDs start from the current time so that the IDs of a previous process are
+		// recognized as unknown rather than replayed from the wrong position
+		lastID:        time.Now().UnixMicro(),
+		replay:        make([]Event, 0, replaySize),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers it is meant for (every subscriber if userID is
+// empty). Subscribers too slow to keep up are dropped.
+func (b *Broker) Publish(eventType, userID string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, UserID: userID, Data: data}
+
+	if len(b.replay) < cap(b.replay) {
+		b.replay = append(b.replay, event)
+	} else if cap(b.replay) > 0 {
+		b.replay[b.next] = event
+		b.next = (b.next + 1) % cap(b.replay)
+	}
+
+	for s := range b.subscriptions {
+		if !s.receives(event) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the events meant for userID. If lastID is set, the
+// events published after it are returned for replay; resumed is false when they are no
+// longer all available (or lastID is unknown), and the subscriber should then reload its state.
+func (b *Broker) Subscribe(userID string, lastID int64) (s *Subscription, replay []Event, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s = &Subscription{
+		broker: b,
+		userID: userID,
+		events: make(chan Event, subscriptionBuffer),
+	}
+	if b.closed {
+		close(s.events)
+		return s, nil, false
+	}
+	b.subscriptions[s] = struct{}{}
+
+	if lastID == 0 {
+		return s, nil, true
+	}
+
+	// Events in the ring buffer, oldest first
+	ordered := append(append([]Event{}, b.replay[b.next:]...), b.replay[:b.next]...)
+	oldestID := b.lastID + 1
+	if len(ordered) > 0 {
+		oldestID = ordered[0].ID
+	}
+	resumed = lastID >= oldestID-1 && lastID <= b.lastID
+
+	for _, event := range ordered {
+		if event.ID > lastID && s.receives(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	return s, replay, resumed
+}
+
+// Close drops every subscriber and stops accepting new ones, on shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscriptions {
+		b.remove(s)
+	}
+}
+
+// remove unregisters a subscriber and closes its channel (the lock must be held)
+func (b *Broker) remove(s *Subscription) {
+	if _, ok := b.subscriptions[s]; !ok {
+		return
+	}
+	delete(b.subscriptions, s)
+	close(s.events)
+}
+
+// Subscription receives the events meant for a user
+type Subscription struct {
+	broker *Broker
+	userID string
+	events chan Event
+}
+
+// Events returns the channel delivering the events. It is closed when the subscriber is
+// dropped for being too slow or the broker closes.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Unsubscribe stops the delivery of events
+func (s *Subscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+// receives reports whether an event is meant for the subscriber
+func (s *Subscription) receives(event Event) bool {
+	return event.UserID == "" || event.UserID == s.userID
+}
@@ -0,0 +1,202 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Hub event types
+const (
+	TypeMessage   = "message"   // a message was sent in a conversation of the user (data: *message.Message)
+	TypeTyping    = "typing"    // a member started or stopped typing (data: gateway.Typing)
+	TypePresence  = "presence"  // the presence of a contact changed (data: gateway.Presence)
+	TypeDelivered = "delivered" // a message the user sent was delivered (data: gateway.Delivery)
+)
+
+// Presence statuses
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusOffline = "offline" // no open connection
+)
+
+// clientBuffer is the number of events a client can lag behind before being dropped
+const clientBuffer = 64
+
+// ErrHubClosed is returned when connecting to a hub that is shutting down
+var ErrHubClosed = errors.New("hub closed")
+
+// Hub tracks the live connections of users and delivers events to them, along with the
+// presence of each user. Unlike the Broker, it keeps nothing for disconnected users.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[string]map[*Client]struct{} // by user ID
+	closed  bool
+	wg      sync.WaitGroup // connections not unregistered yet
+}
+
+// NewHub creates a new hub
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Client is a connection of a user to the hub
+type Client struct {
+	UserID  string
+	status  string
+	events  chan Event
+	dropped bool // events closed, on shutdown or because the client was too slow
+}
+
+// Events returns the channel delivering the events. It is closed when the hub shuts down or
+// the client was too slow to keep up.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Register connects a client for the user, online. changed reports whether the presence of
+// the user changed (it was their first connection, or all the others were away).
+func (h *Hub) Register(userID string) (c *Client, changed bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, false, ErrHubClosed
+	}
+
+	before := h.status(userID)
+	c = &Client{
+		UserID: userID,
+		status: StatusOnline,
+		events: make(chan Event, clientBuffer),
+	}
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*Client]struct{})
+	}
+	h.clients[userID][c] = struct{}{}
+	h.wg.Add(1)
+
+	return c, h.status(userID) != before, nil
+}
+
+// Unregister disconnects a client, which must be done once the connection is closed.
+// status is the presence of the user afterwards and changed whether it changed.
+func (h *Hub) Unregister(c *Client) (status string, changed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	before := h.status(c.UserID)
+	delete(h.clients[c.UserID], c)
+	if len(h.clients[c.UserID]) == 0 {
+		delete(h.clients, c.UserID)
+	}
+	h.drop(c)
+	h.wg.Done()
+
+	status = h.status(c.UserID)
+	return status, status != before
+}
+
+// SetStatus changes the presence status of a client (online or away). status is the
+// presence of the user afterwards, which is online if any of their clients is.
+func (h *Hub) SetStatus(c *Client, clientStatus string) (status string, changed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	before := h.status(c.UserID)
+	c.status = clientStatus
+
+	status = h.status(c.UserID)
+	return status, status != before
+}
+
+// Status returns the presence of a user
+func (h *Hub) Status(userID string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.status(userID)
+}
+
+// Send delivers an event to the connected clients of a user. Clients too slow to keep up
+// are dropped.
+func (h *Hub) Send(userID, eventType string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	event := Event{Type: eventType, UserID: userID, Data: data}
+	for c := range h.clients[userID] {
+		if c.dropped {
+			continue
+		}
+		select {
+		case c.events <- event:
+		default:
+			h.drop(c)
+		}
+	}
+}
+
+// Closed reports whether the hub is shutting down
+func (h *Hub) Closed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.closed
+}
+
+// Close drops every client and refuses new ones, on shutdown. The connections are then
+// expected to close and unregister, which Wait waits for.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, clients := range h.clients {
+		for c := range clients {
+			h.drop(c)
+		}
+	}
+}
+
+// Wait blocks until every client is unregistered, or the context is done
+func (h *Hub) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drop stops delivering events to a client (the lock must be held)
+func (h *Hub) drop(c *Client) {
+	if c.dropped {
+		return
+	}
+	c.dropped = true
+	close(c.events)
+}
+
+// status computes the presence of a user from their clients (the lock must be held)
+func (h *Hub) status(userID string) string {
+	clients := h.clients[userID]
+	if len(clients) == 0 {
+		return StatusOffline
+	}
+	for c := range clients {
+		if c.status == StatusOnline {
+			return StatusOnline
+		}
+	}
+	return StatusAway
+}
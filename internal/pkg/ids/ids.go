@@ -0,0 +1,15 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New generates a random unique ID (96 bits, hex-encoded)
+func New() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
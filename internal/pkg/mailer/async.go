@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"ynov-social-api/internal/pkg/logger"
+)
+
+const (
+	// asyncWorkers is the number of messages sent at the same time
+	asyncWorkers = 4
+	// asyncQueueSize is the number of messages waiting to be sent beyond which new ones are dropped
+	asyncQueueSize = 100
+)
+
+// AsyncMailer sends messages in the background so that requests do not wait for (nor reveal,
+// through their timing) the delivery of an email. A fixed pool of workers sends the queued
+// messages; failures, and messages dropped because the queue is full, are logged.
+type AsyncMailer struct {
+	next   Mailer
+	logger *logger.Logger
+	mu     sync.Mutex
+	queue  chan Message
+	closed bool
+	wg     sync.WaitGroup // running workers
+}
+
+// NewAsyncMailer wraps a mailer to send messages in the background, and starts its workers
+func NewAsyncMailer(next Mailer, logger *logger.Logger) *AsyncMailer {
+	m := &AsyncMailer{
+		next:   next,
+		logger: logger,
+		queue:  make(chan Message, asyncQueueSize),
+	}
+
+	m.wg.Add(asyncWorkers)
+	for range asyncWorkers {
+		go m.work()
+	}
+	return m
+}
+
+// Send queues the message and returns immediately. The message is dropped when the queue is
+// full or the mailer is shutting down; no error is returned, so that callers behave the same
+// whether or not an email is sent.
+func (m *AsyncMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		m.logger.Error("Failed to send mail to %s: mailer shutting down", msg.To)
+		return nil
+	}
+
+	select {
+	case m.queue <- msg:
+	default:
+		m.logger.Error("Failed to send mail to %s: queue full", msg.To)
+	}
+	return nil
+}
+
+// Wait stops accepting messages and blocks until the queued ones are delivered (or failed),
+// or the context is done
+func (m *AsyncMailer) Wait(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work sends queued messages until the queue is closed and drained
+func (m *AsyncMailer) work() {
+	defer m.wg.Done()
+
+	for msg := range m.queue {
+		if err := m.next.Send(context.Background(), msg); err != nil {
+			m.logger.Error("Failed to send mail to %s: %v", msg.To, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/pkg/logger"
+)
+
+// LogMailer is a development mailer: messages are logged and, when a directory is set,
+// written to it as .eml files instead of being delivered
+type LogMailer struct {
+	logger *logger.Logger
+	dir    string
+	from   string
+}
+
+// NewLogMailer creates a new log mailer. dir may be empty to only log messages.
+func NewLogMailer(logger *logger.Logger, dir, from string) *LogMailer {
+	return &LogMailer{
+		logger: logger,
+		dir:    dir,
+		from:   from,
+	}
+}
+
+// Send logs the message and writes it to the mail directory
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o600)
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -9,6 +10,8 @@ import (
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
+var handleRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{3,30}$`)
+
 // Validator provides validation utilities
 type Validator struct {
 	Errors map[string]string
@@ -55,6 +58,17 @@ func (v *Validator) Email(value string, field string) {
 	v.Check(emailRegex.MatchString(value), field, "must be a valid email address")
 }
 
+// Handle checks if a value is a valid user handle (3 to 30 letters, digits or underscores)
+func (v *Validator) Handle(value string, field string) {
+	v.Check(handleRegex.MatchString(value), field, "must be 3 to 30 letters, digits or underscores")
+}
+
+// URL checks if a value is an absolute http(s) URL
+func (v *Validator) URL(value string, field string) {
+	u, err := url.Parse(value)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "must be a valid http(s) URL")
+}
+
 // MinLength checks if a value has at least n characters
 func (v *Validator) MinLength(value string, n int, field string) {
 	v.Check(utf8.RuneCountInString(value) >= n, field, fmt.Sprintf("must be at least %d characters", n))
@@ -0,0 +1,400 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types (frame opcodes)
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	continuationFrame = 0
+	closeFrame        = 8
+	pingFrame         = 9
+	pongFrame         = 10
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+	CloseTryAgainLater   = 1013
+)
+
+// Connection settings
+const (
+	// writeTimeout bounds each write to the connection
+	writeTimeout = 10 * time.Second
+	// closeTimeout is how long the peer has to answer a close frame
+	closeTimeout = 5 * time.Second
+	// maxControlPayload is the maximum payload of control frames (RFC 6455 section 5.5)
+	maxControlPayload = 125
+)
+
+// errCloseSent is returned when writing after the closing handshake started
+var errCloseSent = errors.New("websocket: close already sent")
+
+// acceptGUID is appended to the client's key to compute the handshake answer (RFC 6455 section 1.3)
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError is returned by Upgrade when the request is not a valid WebSocket opening
+// handshake. Nothing has been written to the response yet.
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e *HandshakeError) Error() string {
+	return e.Message
+}
+
+// CloseError is returned by ReadMessage when the peer closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Reason)
+}
+
+// Conn is a server-side WebSocket connection. Reads must happen from a single goroutine;
+// writes can happen from any goroutine.
+type Conn struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	maxMessageSize int64
+	readTimeout    atomic.Int64 // nanoseconds allowed between two frames, 0 for none
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+// IsUpgrade reports whether the request asks to switch to the WebSocket protocol
+func IsUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completes the opening handshake of a WebSocket connection and takes over the
+// underlying connection. Messages larger than maxMessageSize bytes are refused.
+func Upgrade(w http.ResponseWriter, r *http.Request, maxMessageSize int64) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsUpgrade(r) {
+		return nil, &HandshakeError{Status: http.StatusBadRequest, Message: "websocket upgrade required"}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Message: "unsupported websocket version"}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, &HandshakeError{Status: http.StatusBadRequest, Message: "invalid websocket key"}
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take over the connection: %w", err)
+	}
+
+	// Hijacked connections keep the deadlines the server set for the request
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	if err := netConn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if _, err := netConn.Write([]byte(handshake)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:           netConn,
+		reader:         rw.Reader,
+		maxMessageSize: maxMessageSize,
+	}, nil
+}
+
+// SetReadTimeout sets how long the peer may stay silent (no frame at all, pongs included)
+// before reads fail
+func (c *Conn) SetReadTimeout(d time.Duration) {
+	c.readTimeout.Store(int64(d))
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered and fragmented
+// messages reassembled. When the peer closes the connection, the close frame is answered and
+// a *CloseError returned; protocol violations close the connection with the matching code.
+func (c *Conn) ReadMessage() (messageType int, payload []byte, err error) {
+	for {
+		fin, opcode, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case pingFrame:
+			// Pings received while closing are left unanswered
+			if err := c.writeFrame(pongFrame, data); err != nil && !errors.Is(err, errCloseSent) {
+				return 0, nil, err
+			}
+			continue
+		case pongFrame:
+			continue
+		case closeFrame:
+			return 0, nil, c.handleClose(data)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected a continuation frame")
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(payload)+len(data)) > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		payload = append(payload, data...)
+
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(payload) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return messageType, payload, nil
+		}
+	}
+}
+
+// WriteMessage sends a text or binary message in a single frame
+func (c *Conn) WriteMessage(messageType int, payload []byte) error {
+	return c.writeFrame(messageType, payload)
+}
+
+// WritePing sends a ping, which the peer answers with a pong
+func (c *Conn) WritePing() error {
+	return c.writeFrame(pingFrame, nil)
+}
+
+// WriteClose starts the closing handshake: the peer has a few seconds to answer before
+// ReadMessage fails. Nothing else can be written afterwards.
+func (c *Conn) WriteClose(code int, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+
+	c.readTimeout.Store(int64(closeTimeout))
+	if err := c.conn.SetReadDeadline(time.Now().Add(closeTimeout)); err != nil {
+		return err
+	}
+
+	return c.writeFrameLocked(closeFrame, closePayload(code, reason))
+}
+
+// Close closes the underlying connection, without closing handshake
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// handleClose answers a close frame received from the peer
+func (c *Conn) handleClose(data []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(data) == 1:
+		return c.fail(CloseProtocolError, "invalid close frame")
+	case len(data) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(data))
+		closeErr.Reason = string(data[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(closeErr.Reason) {
+			return c.fail(CloseInvalidPayload, "invalid UTF-8")
+		}
+	}
+
+	// Echo the status code (RFC 6455 section 5.5.1)
+	code := closeErr.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	if err := c.WriteClose(code, ""); err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+// fail closes the connection because of a protocol violation by the peer
+func (c *Conn) fail(code int, reason string) error {
+	_ = c.WriteClose(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// readFrame reads a single frame and unmasks its payload
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	if timeout := time.Duration(c.readTimeout.Load()); timeout > 0 {
+		if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	// Clients must mask their frames (RFC 6455 section 5.1)
+	if !masked {
+		return false, 0, nil, c.fail(CloseProtocolError, "unmasked frame")
+	}
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]))
+	}
+
+	if opcode >= closeFrame && (!fin || length > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length < 0 || length > c.maxMessageSize {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends a single unmasked frame
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return errCloseSent
+	}
+
+	return c.writeFrameLocked(opcode, payload)
+}
+
+// writeFrameLocked sends a frame, the write lock being held
+func (c *Conn) writeFrameLocked(opcode int, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// closePayload builds the payload of a close frame
+func closePayload(code int, reason string) []byte {
+	// Reasons are truncated to fit in a control frame
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return append(payload, reason...)
+}
+
+// validCloseCode reports whether a close code can be sent in a close frame (RFC 6455
+// section 7.4): codes reserved for local use (1005, 1006, 1015) and undefined ones cannot
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// headerContains reports whether a comma-separated header contains a token (case-insensitive)
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
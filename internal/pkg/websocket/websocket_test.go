@@ -0,0 +1,503 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// frame is a frame decoded from what the server wrote
+type frame struct {
+	opcode  int
+	payload []byte
+}
+
+// clientFrame encodes a masked frame, as clients send them
+func clientFrame(fin bool, opcode int, payload []byte) []byte {
+	return encodeFrame(fin, opcode, payload, true)
+}
+
+// encodeFrame encodes a frame with the shortest length encoding
+func encodeFrame(fin bool, opcode int, payload []byte, masked bool) []byte {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+
+	out := []byte{b0}
+	switch length := len(payload); {
+	case length < 126:
+		out = append(out, maskBit|byte(length))
+	case length <= 0xffff:
+		out = append(out, maskBit|126)
+		out = binary.BigEndian.AppendUint16(out, uint16(length))
+	default:
+		out = append(out, maskBit|127)
+		out = binary.BigEndian.AppendUint64(out, uint64(length))
+	}
+
+	if !masked {
+		return append(out, payload...)
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	out = append(out, mask[:]...)
+	for i, b := range payload {
+		out = append(out, b^mask[i%4])
+	}
+	return out
+}
+
+// closeFrameData builds the payload of a close frame sent by a client
+func closeFrameData(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// concat joins frames into a single input
+func concat(frames ...[]byte) []byte {
+	return bytes.Join(frames, nil)
+}
+
+// decodeFrames decodes the unmasked frames written by the server
+func decodeFrames(t *testing.T, data []byte) []frame {
+	t.Helper()
+
+	var frames []frame
+	for len(data) > 0 {
+		if len(data) < 2 {
+			t.Fatalf("truncated frame header: %x", data)
+		}
+		opcode := int(data[0] & 0x0f)
+		length := uint64(data[1] & 0x7f)
+		data = data[2:]
+		switch length {
+		case 126:
+			length = uint64(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		case 127:
+			length = binary.BigEndian.Uint64(data)
+			data = data[8:]
+		}
+		if uint64(len(data)) < length {
+			t.Fatalf("truncated frame payload: want %d bytes, got %d", length, len(data))
+		}
+		frames = append(frames, frame{opcode: opcode, payload: data[:length]})
+		data = data[length:]
+	}
+	return frames
+}
+
+// readResult is the outcome of a ReadMessage call
+type readResult struct {
+	messageType int
+	payload     []byte
+	err         error
+	sent        []frame // frames the server wrote meanwhile
+}
+
+// readMessage feeds input to a connection as a client would and reads one message
+func readMessage(t *testing.T, input []byte, maxMessageSize int64) readResult {
+	t.Helper()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	c := &Conn{conn: server, reader: bufio.NewReader(server), maxMessageSize: maxMessageSize}
+
+	// Writes to a pipe block until read: the input is written and the output drained
+	// concurrently, until the server end is closed
+	go func() { _, _ = client.Write(input) }()
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(client)
+		output <- data
+	}()
+
+	var res readResult
+	res.messageType, res.payload, res.err = c.ReadMessage()
+	server.Close()
+	res.sent = decodeFrames(t, <-output)
+	return res
+}
+
+func TestReadMessage(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	huge := bytes.Repeat([]byte("b"), 70000)
+
+	tests := []struct {
+		name        string
+		input       []byte
+		maxSize     int64
+		wantType    int
+		wantPayload []byte
+		wantSent    []frame // frames the server must write, in order
+	}{
+		{
+			name:        "text message",
+			input:       clientFrame(true, TextMessage, []byte("hello")),
+			wantType:    TextMessage,
+			wantPayload: []byte("hello"),
+		},
+		{
+			name:        "16-bit length",
+			input:       clientFrame(true, BinaryMessage, long),
+			wantType:    BinaryMessage,
+			wantPayload: long,
+		},
+		{
+			name:        "64-bit length",
+			input:       clientFrame(true, BinaryMessage, huge),
+			maxSize:     100000,
+			wantType:    BinaryMessage,
+			wantPayload: huge,
+		},
+		{
+			name:        "empty message",
+			input:       clientFrame(true, TextMessage, nil),
+			wantType:    TextMessage,
+			wantPayload: []byte{},
+		},
+		{
+			name: "fragmented message",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("hel")),
+				clientFrame(false, continuationFrame, []byte("l")),
+				clientFrame(true, continuationFrame, []byte("o")),
+			),
+			wantType:    TextMessage,
+			wantPayload: []byte("hello"),
+		},
+		{
+			name: "control frames between fragments",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("hel")),
+				clientFrame(true, pingFrame, []byte("p1")),
+				clientFrame(true, pongFrame, []byte("ignored")),
+				clientFrame(true, pingFrame, []byte("p2")),
+				clientFrame(true, continuationFrame, []byte("lo")),
+			),
+			wantType:    TextMessage,
+			wantPayload: []byte("hello"),
+			wantSent:    []frame{{pongFrame, []byte("p1")}, {pongFrame, []byte("p2")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxSize == 0 {
+				tt.maxSize = 1024
+			}
+			res := readMessage(t, tt.input, tt.maxSize)
+
+			if res.err != nil {
+				t.Fatalf("ReadMessage() error = %v", res.err)
+			}
+			if res.messageType != tt.wantType {
+				t.Errorf("message type = %d, want %d", res.messageType, tt.wantType)
+			}
+			if !bytes.Equal(res.payload, tt.wantPayload) {
+				t.Errorf("payload = %q (%d bytes), want %d bytes", truncate(res.payload), len(res.payload), len(tt.wantPayload))
+			}
+			assertFrames(t, res.sent, tt.wantSent)
+		})
+	}
+}
+
+func TestReadMessageClose(t *testing.T) {
+	// A 64-bit length with the most significant bit set, negative once converted to int64
+	negativeLength := concat(
+		[]byte{0x82, 0x80 | 127},
+		binary.BigEndian.AppendUint64(nil, 1<<63|5),
+		[]byte{0x12, 0x34, 0x56, 0x78},
+	)
+
+	tests := []struct {
+		name      string
+		input     []byte
+		maxSize   int64
+		wantCode  int    // code of the returned *CloseError
+		wantEcho  int    // code of the close frame the server must write
+		wantPongs []byte // payloads of the pongs written before the close frame
+	}{
+		{
+			name:     "peer closes",
+			input:    clientFrame(true, closeFrame, closeFrameData(CloseGoingAway, "bye")),
+			wantCode: CloseGoingAway,
+			wantEcho: CloseGoingAway,
+		},
+		{
+			name:     "peer closes with an application code",
+			input:    clientFrame(true, closeFrame, closeFrameData(4000, "")),
+			wantCode: 4000,
+			wantEcho: 4000,
+		},
+		{
+			name:     "peer closes without status",
+			input:    clientFrame(true, closeFrame, nil),
+			wantCode: CloseNoStatus,
+			wantEcho: CloseNormal,
+		},
+		{
+			name: "peer closes between fragments",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("hel")),
+				clientFrame(true, pingFrame, []byte("p")),
+				clientFrame(true, closeFrame, closeFrameData(CloseNormal, "")),
+			),
+			wantCode:  CloseNormal,
+			wantEcho:  CloseNormal,
+			wantPongs: []byte("p"),
+		},
+		{
+			name:     "close code reserved for local use",
+			input:    clientFrame(true, closeFrame, closeFrameData(CloseNoStatus, "")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "abnormal closure code",
+			input:    clientFrame(true, closeFrame, closeFrameData(1006, "")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "undefined close code",
+			input:    clientFrame(true, closeFrame, closeFrameData(1004, "")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "close code below the range",
+			input:    clientFrame(true, closeFrame, closeFrameData(999, "")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "close code above the range",
+			input:    clientFrame(true, closeFrame, closeFrameData(5000, "")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "one-byte close payload",
+			input:    clientFrame(true, closeFrame, []byte{0x03}),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "close reason not UTF-8",
+			input:    clientFrame(true, closeFrame, closeFrameData(CloseNormal, "\xff")),
+			wantCode: CloseInvalidPayload,
+			wantEcho: CloseInvalidPayload,
+		},
+		{
+			name:     "unmasked frame",
+			input:    encodeFrame(true, TextMessage, []byte("hello"), false),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "reserved bits",
+			input:    append([]byte{0x80 | 0x40 | TextMessage}, clientFrame(true, TextMessage, []byte("x"))[1:]...),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "unknown opcode",
+			input:    clientFrame(true, 3, []byte("x")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "negative 64-bit length",
+			input:    negativeLength,
+			wantCode: CloseMessageTooBig,
+			wantEcho: CloseMessageTooBig,
+		},
+		{
+			name:     "oversize frame",
+			input:    clientFrame(true, TextMessage, bytes.Repeat([]byte("a"), 11)),
+			maxSize:  10,
+			wantCode: CloseMessageTooBig,
+			wantEcho: CloseMessageTooBig,
+		},
+		{
+			name:     "oversize 64-bit length",
+			input:    clientFrame(true, BinaryMessage, bytes.Repeat([]byte("a"), 70000)),
+			maxSize:  65536,
+			wantCode: CloseMessageTooBig,
+			wantEcho: CloseMessageTooBig,
+		},
+		{
+			name: "oversize fragmented message",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("123456")),
+				clientFrame(true, continuationFrame, []byte("789012")),
+			),
+			maxSize:  10,
+			wantCode: CloseMessageTooBig,
+			wantEcho: CloseMessageTooBig,
+		},
+		{
+			name:     "continuation without a message",
+			input:    clientFrame(true, continuationFrame, []byte("x")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name: "new message before the last one ended",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("hel")),
+				clientFrame(true, TextMessage, []byte("lo")),
+			),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "fragmented control frame",
+			input:    clientFrame(false, pingFrame, []byte("p")),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "oversize control frame",
+			input:    clientFrame(true, pingFrame, bytes.Repeat([]byte("p"), maxControlPayload+1)),
+			wantCode: CloseProtocolError,
+			wantEcho: CloseProtocolError,
+		},
+		{
+			name:     "text message not UTF-8",
+			input:    clientFrame(true, TextMessage, []byte("\xc3\x28")),
+			wantCode: CloseInvalidPayload,
+			wantEcho: CloseInvalidPayload,
+		},
+		{
+			name: "text message split inside a UTF-8 sequence",
+			input: concat(
+				clientFrame(false, TextMessage, []byte("caf\xc3")),
+				clientFrame(true, continuationFrame, []byte("\xa9")),
+				clientFrame(true, closeFrame, closeFrameData(CloseNormal, "")),
+			),
+			wantCode: 0, // the message is valid once reassembled
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxSize == 0 {
+				tt.maxSize = 1024
+			}
+			res := readMessage(t, tt.input, tt.maxSize)
+
+			if tt.wantCode == 0 {
+				if res.err != nil {
+					t.Fatalf("ReadMessage() error = %v, want none", res.err)
+				}
+				return
+			}
+
+			var closeErr *CloseError
+			if !errors.As(res.err, &closeErr) {
+				t.Fatalf("ReadMessage() error = %v, want a *CloseError", res.err)
+			}
+			if closeErr.Code != tt.wantCode {
+				t.Errorf("close code = %d, want %d", closeErr.Code, tt.wantCode)
+			}
+
+			var want []frame
+			if tt.wantPongs != nil {
+				want = append(want, frame{pongFrame, tt.wantPongs})
+			}
+			want = append(want, frame{closeFrame, binary.BigEndian.AppendUint16(nil, uint16(tt.wantEcho))})
+			assertFrames(t, res.sent, want)
+		})
+	}
+}
+
+func TestWriteMessageLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		wantHeader []byte
+	}{
+		{"7-bit length", 125, []byte{0x81, 125}},
+		{"smallest 16-bit length", 126, []byte{0x81, 126, 0x00, 126}},
+		{"largest 16-bit length", 0xffff, []byte{0x81, 126, 0xff, 0xff}},
+		{"64-bit length", 0x10000, []byte{0x81, 127, 0, 0, 0, 0, 0, 0x01, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+			c := &Conn{conn: server, reader: bufio.NewReader(server)}
+
+			output := make(chan []byte)
+			go func() {
+				data, _ := io.ReadAll(client)
+				output <- data
+			}()
+
+			payload := bytes.Repeat([]byte("a"), tt.size)
+			if err := c.WriteMessage(TextMessage, payload); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			server.Close()
+			data := <-output
+
+			if !bytes.HasPrefix(data, tt.wantHeader) {
+				t.Errorf("header = %x, want %x", data[:min(len(data), len(tt.wantHeader))], tt.wantHeader)
+			}
+			if !bytes.Equal(data[len(tt.wantHeader):], payload) {
+				t.Errorf("payload of %d bytes, want %d", len(data)-len(tt.wantHeader), tt.size)
+			}
+		})
+	}
+}
+
+func TestClosePayload(t *testing.T) {
+	payload := closePayload(CloseGoingAway, string(bytes.Repeat([]byte("r"), 200)))
+
+	if len(payload) != maxControlPayload {
+		t.Errorf("close payload of %d bytes, want %d", len(payload), maxControlPayload)
+	}
+	if code := binary.BigEndian.Uint16(payload); code != CloseGoingAway {
+		t.Errorf("close code = %d, want %d", code, CloseGoingAway)
+	}
+}
+
+// assertFrames checks the frames the server wrote
+func assertFrames(t *testing.T, got, want []frame) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("server wrote %d frames %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if got[i].opcode != want[i].opcode {
+			t.Errorf("frame %d: opcode = %d, want %d", i, got[i].opcode, want[i].opcode)
+		}
+		// Close frames are compared by their code, the reason is informative
+		gotPayload := got[i].payload
+		if want[i].opcode == closeFrame && len(gotPayload) > 2 {
+			gotPayload = gotPayload[:2]
+		}
+		if !bytes.Equal(gotPayload, want[i].payload) {
+			t.Errorf("frame %d: payload = %q, want %q", i, gotPayload, want[i].payload)
+		}
+	}
+}
+
+// truncate shortens a payload for error messages
+func truncate(payload []byte) []byte {
+	if len(payload) > 32 {
+		return payload[:32]
+	}
+	return payload
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ynov-social-api/internal/domain/loginattempt"
+)
+
+// LoginAttemptRepository implements loginattempt.Repository interface in process memory.
+// Records are lost on restart and not shared between replicas: use the SQLite store when
+// running several instances.
+type LoginAttemptRepository struct {
+	mu        sync.Mutex
+	records   map[string]*loginattempt.Record
+	lastSweep time.Time
+}
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		records:   make(map[string]*loginattempt.Record),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get retrieves the record of a key
+func (r *LoginAttemptRepository) Get(_ context.Context, key string) (*loginattempt.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[key]; ok {
+		copied := *record
+		return &copied, nil
+	}
+	return &loginattempt.Record{Key: key}, nil
+}
+
+// RecordFailure counts a failure for a key
+func (r *LoginAttemptRepository) RecordFailure(_ context.Context, key string, at time.Time, window time.Duration) (*loginattempt.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(at, window)
+
+	record, ok := r.records[key]
+	if !ok {
+		record = &loginattempt.Record{Key: key}
+		r.records[key] = record
+	}
+	if record.LastFailure.Before(at.Add(-window)) {
+		record.Failures = 0
+	}
+	record.Failures++
+	record.LastFailure = at
+
+	copied := *record
+	return &copied, nil
+}
+
+// Lock locks a key out until the given time
+func (r *LoginAttemptRepository) Lock(_ context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[key]; ok {
+		record.LockedUntil = until
+	}
+	return nil
+}
+
+// Reset forgets the failures of a key
+func (r *LoginAttemptRepository) Reset(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
+
+// sweep drops the records that are neither recent nor locked, at most once per window, so
+// that addresses trying a password once do not accumulate. The caller must hold the lock.
+func (r *LoginAttemptRepository) sweep(now time.Time, window time.Duration) {
+	if now.Sub(r.lastSweep) < window {
+		return
+	}
+	r.lastSweep = now
+
+	for key, record := range r.records {
+		if record.LastFailure.Before(now.Add(-window)) && !record.IsLocked(now) {
+			delete(r.records, key)
+		}
+	}
+}
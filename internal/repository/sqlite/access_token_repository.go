@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// AccessTokenRepository implements accesstoken.Repository interface
+type AccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenRepository creates a new AccessTokenRepository
+func NewAccessTokenRepository(db *gorm.DB) *AccessTokenRepository {
+	return &AccessTokenRepository{db: db}
+}
+
+// Create stores a new token
+func (r *AccessTokenRepository) Create(ctx context.Context, t *accesstoken.Token) error {
+	model := &accessTokenModel{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Scopes:    strings.Join(t.Scopes, " "),
+		TokenHash: t.TokenHash,
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+	if !t.ExpiresAt.IsZero() {
+		model.ExpiresAt = t.ExpiresAt.Unix()
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to create access token")
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a token by the hash of its value
+func (r *AccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*accesstoken.Token, error) {
+	var model accessTokenModel
+	err := r.db.WithContext(ctx).First(&model, "token_hash = ?", tokenHash).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidToken
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get access token")
+	}
+
+	return model.toDomain(), nil
+}
+
+// ListForUser retrieves the tokens of a user, newest first
+func (r *AccessTokenRepository) ListForUser(ctx context.Context, userID string) ([]*accesstoken.Token, error) {
+	var models []accessTokenModel
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list access tokens")
+	}
+
+	tokens := make([]*accesstoken.Token, 0, len(models))
+	for i := range models {
+		tokens = append(tokens, models[i].toDomain())
+	}
+
+	return tokens, nil
+}
+
+// Touch updates the token's last-used time
+func (r *AccessTokenRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&accessTokenModel{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update access token")
+	}
+
+	return nil
+}
+
+// Delete deletes one of the user's tokens
+func (r *AccessTokenRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&accessTokenModel{})
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to delete access token")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteAllForUser deletes every token of a user
+func (r *AccessTokenRepository) DeleteAllForUser(ctx context.Context, userID string) error {
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&accessTokenModel{}).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to delete access tokens")
+	}
+
+	return nil
+}
+
+// toDomain maps an access token model to the domain model
+func (m *accessTokenModel) toDomain() *accesstoken.Token {
+	return &accesstoken.Token{
+		ID:         m.ID,
+		UserID:     m.UserID,
+		Name:       m.Name,
+		Scopes:     strings.Fields(m.Scopes),
+		TokenHash:  m.TokenHash,
+		CreatedAt:  time.Unix(m.CreatedAt, 0),
+		LastUsedAt: unixOrZero(m.LastUsedAt),
+		ExpiresAt:  unixOrZero(m.ExpiresAt),
+	}
+}
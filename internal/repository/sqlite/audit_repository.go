@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"context"
+
+	"ynov-social-api/internal/domain/audit"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository implements audit.Repository interface
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new AuditRepository
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create appends an entry to the audit log
+func (r *AuditRepository) Create(ctx context.Context, entry *audit.Entry) error {
+	model := &auditEntryModel{
+		Action:    entry.Action,
+		Subject:   entry.Subject,
+		IPAddress: entry.IPAddress,
+		Details:   entry.Details,
+		CreatedAt: entry.CreatedAt.Unix(),
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to create audit entry")
+	}
+
+	entry.ID = model.ID
+	return nil
+}
@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -39,13 +41,156 @@ func (db *DB) Close() error {
 	return sqlDB.Close()
 }
 
+// models lists the GORM models managed by migrations
+var models = []interface{}{
+	&userModel{},
+	&postModel{},
+	&likeModel{},
+	&revisionModel{},
+	&followModel{},
+	&sessionModel{},
+	&refreshTokenModel{},
+	&recoveryCodeModel{},
+	&verificationTokenModel{},
+	&accessTokenModel{},
+	&oauthClientModel{},
+	&oauthCodeModel{},
+	&oauthConsentModel{},
+	&loginAttemptModel{},
+	&auditEntryModel{},
+	&inviteModel{},
+	&conversationModel{},
+	&conversationMemberModel{},
+	&messageModel{},
+	&notificationModel{},
+	&notificationActorModel{},
+}
+
 // migrate runs database migrations
 func (db *DB) migrate() error {
-	return db.conn.AutoMigrate(
-		&userModel{},
-		&postModel{},
-		&likeModel{},
-	)
+	// Databases created before user IDs existed reference users by email
+	if db.conn.Migrator().HasTable(&userModel{}) && !db.conn.Migrator().HasColumn(&userModel{}, "id") {
+		if err := db.conn.Transaction(migrateUserIDs); err != nil {
+			return fmt.Errorf("failed to migrate to user IDs: %w", err)
+		}
+	}
+
+	if err := db.conn.AutoMigrate(models...); err != nil {
+		return err
+	}
+
+	// Posts created before edits were tracked have no update timestamp
+	if err := db.conn.Exec("UPDATE posts SET updated_at = created_at WHERE updated_at IS NULL OR updated_at = 0").Error; err != nil {
+		return err
+	}
+
+	// Users created before profiles existed get a random handle they can change later
+	return db.conn.Exec("UPDATE users SET handle = 'user_' || lower(hex(randomblob(4))) WHERE handle IS NULL OR handle = ''").Error
+}
+
+// emailReferences lists, for each table that referenced users by email, the former email
+// columns and the ID columns replacing them (in migration order: users first)
+var emailReferences = []struct {
+	table   string
+	columns map[string]string // ID column -> former email column
+}{
+	{"users", nil},
+	{"posts", map[string]string{"user_id": "user_email"}},
+	{"liked_posts", map[string]string{"user_id": "user_email"}},
+	{"follows", map[string]string{"follower_id": "follower_email", "followee_id": "followee_email"}},
+	{"sessions", map[string]string{"user_id": "user_email"}},
+	{"recovery_codes", map[string]string{"user_id": "user_email"}},
+	{"verification_tokens", map[string]string{"user_id": "user_email"}},
+}
+
+// migrateUserIDs gives every user an opaque ID and rewrites the tables referencing users by
+// email to reference that ID. SQLite cannot change primary keys in place, so each table is
+// renamed, recreated from its model and refilled.
+func migrateUserIDs(tx *gorm.DB) error {
+	// Keep references from untouched tables (e.g. post_revisions -> posts) pointing at the new tables
+	if err := tx.Exec("PRAGMA legacy_alter_table = ON").Error; err != nil {
+		return err
+	}
+	defer tx.Exec("PRAGMA legacy_alter_table = OFF")
+
+	var legacyTables []string
+	for _, ref := range emailReferences {
+		if !tx.Migrator().HasTable(ref.table) {
+			continue
+		}
+
+		// Index names are global: drop them so the new tables can reuse them
+		var indexes []string
+		if err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", ref.table).
+			Scan(&indexes).Error; err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			if err := tx.Exec(fmt.Sprintf("DROP INDEX `%s`", index)).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE `%s` RENAME TO `%s_legacy`", ref.table, ref.table)).Error; err != nil {
+			return err
+		}
+		legacyTables = append(legacyTables, ref.table)
+	}
+
+	if err := tx.AutoMigrate(models...); err != nil {
+		return err
+	}
+
+	for _, ref := range emailReferences {
+		if !slices.Contains(legacyTables, ref.table) {
+			continue
+		}
+		if err := copyLegacyTable(tx, ref.table, ref.columns); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range legacyTables {
+		if err := tx.Exec(fmt.Sprintf("DROP TABLE `%s_legacy`", table)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyLegacyTable copies the rows of <table>_legacy into table, resolving former email columns
+// to user IDs. Columns missing from the legacy table keep their default value; rows referencing
+// users that no longer exist are dropped.
+func copyLegacyTable(tx *gorm.DB, table string, idColumns map[string]string) error {
+	var newColumns, legacyColumns []string
+	if err := tx.Raw("SELECT name FROM pragma_table_info(?)", table).Scan(&newColumns).Error; err != nil {
+		return err
+	}
+	if err := tx.Raw("SELECT name FROM pragma_table_info(?)", table+"_legacy").Scan(&legacyColumns).Error; err != nil {
+		return err
+	}
+
+	var columns, values, joins []string
+	for _, column := range newColumns {
+		switch emailColumn, isReference := idColumns[column]; {
+		case table == "users" && column == "id":
+			values = append(values, "lower(hex(randomblob(12)))")
+		case isReference:
+			alias := "ref_" + column
+			joins = append(joins, fmt.Sprintf("JOIN users AS %s ON %s.email = LOWER(legacy.%s)", alias, alias, emailColumn))
+			values = append(values, alias+".id")
+		case slices.Contains(legacyColumns, column):
+			values = append(values, "legacy."+column)
+		default:
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	query := fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s_legacy` AS legacy %s",
+		table, strings.Join(columns, ", "), strings.Join(values, ", "), table, strings.Join(joins, " "))
+	return tx.Exec(query).Error
 }
 
 // GetConn returns the underlying GORM connection
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"ynov-social-api/internal/domain/follow"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// FollowRepository implements follow.Repository interface
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+// NewFollowRepository creates a new FollowRepository
+func NewFollowRepository(db *gorm.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// Create records a follow relationship (no-op if it already exists)
+func (r *FollowRepository) Create(ctx context.Context, f *follow.Follow) error {
+	model := &followModel{
+		FollowerID: f.FollowerID,
+		FolloweeID: f.FolloweeID,
+		CreatedAt:  f.CreatedAt.Unix(),
+	}
+
+	// Use FirstOrCreate to avoid duplicate follows
+	err := r.db.WithContext(ctx).
+		Where(followModel{FollowerID: f.FollowerID, FolloweeID: f.FolloweeID}).
+		FirstOrCreate(model).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to follow user")
+	}
+
+	return nil
+}
+
+// Delete removes a follow relationship (no-op if it does not exist)
+func (r *FollowRepository) Delete(ctx context.Context, followerID, followeeID string) error {
+	err := r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&followModel{}).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to unfollow user")
+	}
+
+	return nil
+}
+
+// ListFollowers retrieves the users following the given user, most recent first
+func (r *FollowRepository) ListFollowers(ctx context.Context, userID string, page, limit int) ([]*follow.Follow, error) {
+	return r.list(ctx, "follows.followee_id = ?", "follows.follower_id", userID, page, limit)
+}
+
+// ListFollowing retrieves the users followed by the given user, most recent first
+func (r *FollowRepository) ListFollowing(ctx context.Context, userID string, page, limit int) ([]*follow.Follow, error) {
+	return r.list(ctx, "follows.follower_id = ?", "follows.followee_id", userID, page, limit)
+}
+
+// list retrieves follow relationships matching the given condition with pagination,
+// along with the profile of the user in profileColumn
+func (r *FollowRepository) list(ctx context.Context, condition, profileColumn, userID string, page, limit int) ([]*follow.Follow, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	var rows []struct {
+		FollowerID  string
+		FolloweeID  string
+		CreatedAt   int64
+		Handle      string
+		DisplayName string
+		AvatarURL   string
+	}
+	err := r.db.WithContext(ctx).
+		Table("follows").
+		Select("follows.follower_id, follows.followee_id, follows.created_at, users.handle, users.display_name, users.avatar_url").
+		Joins("JOIN users ON users.id = "+profileColumn).
+		Where(condition, userID).
+		Order("follows.created_at DESC, follows.follower_id, follows.followee_id").
+		Offset(offset).
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list follows")
+	}
+
+	follows := make([]*follow.Follow, 0, len(rows))
+	for _, row := range rows {
+		follows = append(follows, &follow.Follow{
+			FollowerID: row.FollowerID,
+			FolloweeID: row.FolloweeID,
+			CreatedAt:  time.Unix(row.CreatedAt, 0),
+			Profile: follow.Profile{
+				Handle:      row.Handle,
+				DisplayName: row.DisplayName,
+				AvatarURL:   row.AvatarURL,
+			},
+		})
+	}
+
+	return follows, nil
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ynov-social-api/internal/domain/invite"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// InviteRepository implements invite.Repository interface
+type InviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository creates a new InviteRepository
+func NewInviteRepository(db *gorm.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+// Create stores a new invite
+func (r *InviteRepository) Create(ctx context.Context, i *invite.Invite) error {
+	model := &inviteModel{
+		ID:        i.ID,
+		CreatedBy: i.CreatedBy,
+		CodeHash:  i.CodeHash,
+		MaxUses:   i.MaxUses,
+		CreatedAt: i.CreatedAt.Unix(),
+		ExpiresAt: i.ExpiresAt.Unix(),
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to create invite")
+	}
+
+	return nil
+}
+
+// GetByID retrieves an invite by ID
+func (r *InviteRepository) GetByID(ctx context.Context, id string) (*invite.Invite, error) {
+	var model inviteModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInviteNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get invite")
+	}
+
+	return model.toDomain(), nil
+}
+
+// ListByCreator retrieves the invites created by a user, newest first, with their invitees
+func (r *InviteRepository) ListByCreator(ctx context.Context, userID string) ([]*invite.Invite, error) {
+	var models []inviteModel
+	err := r.db.WithContext(ctx).
+		Where("created_by = ?", userID).
+		Order("created_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list invites")
+	}
+
+	invites := make([]*invite.Invite, 0, len(models))
+	byID := make(map[string]*invite.Invite, len(models))
+	for i := range models {
+		inv := models[i].toDomain()
+		inv.Invitees = []string{}
+		invites = append(invites, inv)
+		byID[inv.ID] = inv
+	}
+
+	if len(invites) == 0 {
+		return invites, nil
+	}
+
+	var invitees []struct {
+		InviteID string
+		Handle   string
+	}
+	err = r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Select("invite_id, handle").
+		Where("invited_by = ? AND deleted_at = 0", userID).
+		Order("rowid").
+		Scan(&invitees).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list invitees")
+	}
+
+	for _, invitee := range invitees {
+		if inv, ok := byID[invitee.InviteID]; ok {
+			inv.Invitees = append(inv.Invitees, invitee.Handle)
+		}
+	}
+
+	return invites, nil
+}
+
+// CountActive counts the invites of a user that can still be used at the given time
+func (r *InviteRepository) CountActive(ctx context.Context, userID string, at time.Time) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&inviteModel{}).
+		Where("created_by = ? AND revoked_at = 0 AND expires_at > ? AND uses < max_uses", userID, at.Unix()).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperrors.Wrap(err, 500, "failed to count invites")
+	}
+
+	return int(count), nil
+}
+
+// Redeem counts a use of the active invite with the given code hash and returns it
+func (r *InviteRepository) Redeem(ctx context.Context, codeHash string, at time.Time) (*invite.Invite, error) {
+	var model inviteModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The conditions make concurrent registrations unable to exceed the usage limit
+		result := tx.Model(&inviteModel{}).
+			Where("code_hash = ? AND revoked_at = 0 AND expires_at > ? AND uses < max_uses", codeHash, at.Unix()).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrInvalidInvite
+		}
+
+		return tx.First(&model, "code_hash = ?", codeHash).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidInvite) {
+			return nil, apperrors.ErrInvalidInvite
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to redeem invite")
+	}
+
+	return model.toDomain(), nil
+}
+
+// Release gives back a use of an invite
+func (r *InviteRepository) Release(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&inviteModel{}).
+		Where("id = ? AND uses > 0", id).
+		Update("uses", gorm.Expr("uses - 1")).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to release invite")
+	}
+
+	return nil
+}
+
+// Revoke marks an invite as revoked
+func (r *InviteRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&inviteModel{}).
+		Where("id = ? AND revoked_at = 0", id).
+		Update("revoked_at", at.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to revoke invite")
+	}
+
+	return nil
+}
+
+// toDomain maps an invite model to the domain model
+func (m *inviteModel) toDomain() *invite.Invite {
+	return &invite.Invite{
+		ID:        m.ID,
+		CreatedBy: m.CreatedBy,
+		CodeHash:  m.CodeHash,
+		MaxUses:   m.MaxUses,
+		Uses:      m.Uses,
+		CreatedAt: time.Unix(m.CreatedAt, 0),
+		ExpiresAt: time.Unix(m.ExpiresAt, 0),
+		RevokedAt: unixOrZero(m.RevokedAt),
+	}
+}
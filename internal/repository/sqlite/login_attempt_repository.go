@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ynov-social-api/internal/domain/loginattempt"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttemptRepository implements loginattempt.Repository interface. It lets several
+// replicas sharing the database enforce the same limits.
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Get retrieves the record of a key
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (*loginattempt.Record, error) {
+	var model loginAttemptModel
+	err := r.db.WithContext(ctx).First(&model, "key = ?", key).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &loginattempt.Record{Key: key}, nil
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get login attempts")
+	}
+
+	return model.toDomain(), nil
+}
+
+// RecordFailure counts a failure for a key. The count is updated in a single statement so
+// that concurrent failures on several replicas are all counted.
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*loginattempt.Record, error) {
+	var model loginAttemptModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO login_attempts (key, failures, last_failure, locked_until) VALUES (?, 1, ?, 0)
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN last_failure < ? THEN 1 ELSE failures + 1 END,
+				last_failure = excluded.last_failure`,
+			key, at.Unix(), at.Add(-window).Unix()).Error
+		if err != nil {
+			return err
+		}
+		return tx.First(&model, "key = ?", key).Error
+	})
+
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to record login attempt")
+	}
+
+	return model.toDomain(), nil
+}
+
+// Lock locks a key out until the given time
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&loginAttemptModel{}).
+		Where("key = ?", key).
+		Update("locked_until", until.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to lock login attempts")
+	}
+
+	return nil
+}
+
+// Reset forgets the failures of a key
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if err := r.db.WithContext(ctx).Where("key = ?", key).Delete(&loginAttemptModel{}).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to reset login attempts")
+	}
+
+	return nil
+}
+
+// toDomain maps a login attempt model to the domain model
+func (m *loginAttemptModel) toDomain() *loginattempt.Record {
+	return &loginattempt.Record{
+		Key:         m.Key,
+		Failures:    m.Failures,
+		LastFailure: unixOrZero(m.LastFailure),
+		LockedUntil: unixOrZero(m.LockedUntil),
+	}
+}
@@ -0,0 +1,633 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/message"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageRepository implements message.Repository interface
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageRepository creates a new MessageRepository
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// messageColumns selects a message and the public profiles of its sender and recipient
+// (empty for messages of group conversations)
+const messageColumns = "messages.seq, messages.id, messages.conversation_id, messages.sender_id, messages.recipient_id, " +
+	"messages.subject, messages.content, messages.created_at, messages.read_at, " +
+	"senders.handle AS sender_handle, senders.display_name AS sender_display_name, senders.avatar_url AS sender_avatar_url, " +
+	"COALESCE(recipients.handle, '') AS recipient_handle, COALESCE(recipients.display_name, '') AS recipient_display_name, " +
+	"COALESCE(recipients.avatar_url, '') AS recipient_avatar_url"
+
+// messageRow represents a message joined with the profiles of its participants
+type messageRow struct {
+	Seq                  int64
+	ID                   string
+	ConversationID       string
+	SenderID             string
+	RecipientID          string
+	Subject              string
+	Content              string
+	CreatedAt            int64
+	ReadAt               int64
+	SenderHandle         string
+	SenderDisplayName    string
+	SenderAvatarURL      string
+	RecipientHandle      string
+	RecipientDisplayName string
+	RecipientAvatarURL   string
+}
+
+// EnsureDirectConversation stores a one-to-one conversation unless its two members already
+// have one, and returns the stored conversation
+func (r *MessageRepository) EnsureDirectConversation(ctx context.Context, c *message.Conversation) (*message.Conversation, error) {
+	memberIDs := make([]string, 0, len(c.Members))
+	for _, member := range c.Members {
+		memberIDs = append(memberIDs, member.UserID)
+	}
+	slices.Sort(memberIDs)
+	directKey := strings.Join(memberIDs, ":")
+
+	model := conversationModel{
+		ID:            c.ID,
+		DirectKey:     &directKey,
+		CreatedAt:     c.CreatedAt.Unix(),
+		LastMessageAt: c.LastMessageAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Concurrent first messages between the same users end up in the same conversation
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			model = conversationModel{}
+			return tx.First(&model, "direct_key = ?", directKey).Error
+		}
+
+		return tx.Create(memberModels(model.ID, c.Members)).Error
+	})
+
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to create conversation")
+	}
+
+	stored := &message.Conversation{
+		ID:            model.ID,
+		CreatedAt:     time.Unix(model.CreatedAt, 0),
+		LastMessageAt: time.Unix(model.LastMessageAt, 0),
+	}
+	for _, userID := range memberIDs {
+		stored.Members = append(stored.Members, message.Member{UserID: userID, Role: message.RoleMember, JoinedAt: stored.CreatedAt})
+	}
+
+	return stored, nil
+}
+
+// CreateGroupConversation stores a new group conversation with its members
+func (r *MessageRepository) CreateGroupConversation(ctx context.Context, c *message.Conversation) error {
+	model := &conversationModel{
+		ID:            c.ID,
+		Name:          c.Name,
+		CreatedBy:     c.CreatedBy,
+		CreatedAt:     c.CreatedAt.Unix(),
+		LastMessageAt: c.LastMessageAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(memberModels(c.ID, c.Members)).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to create conversation")
+	}
+
+	return nil
+}
+
+// memberModels maps conversation members to database models
+func memberModels(conversationID string, members []message.Member) []conversationMemberModel {
+	models := make([]conversationMemberModel, 0, len(members))
+	for _, member := range members {
+		models = append(models, conversationMemberModel{
+			ConversationID: conversationID,
+			UserID:         member.UserID,
+			Role:           member.Role,
+			JoinedAt:       member.JoinedAt.Unix(),
+			LastReadSeq:    member.LastReadSeq,
+		})
+	}
+	return models
+}
+
+// GetConversation retrieves a conversation by ID with its members, computing the unread
+// count for viewerID
+func (r *MessageRepository) GetConversation(ctx context.Context, id, viewerID string) (*message.Conversation, error) {
+	var model conversationModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrConversationNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get conversation")
+	}
+
+	conversations, err := r.loadConversations(ctx, []conversationModel{model}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversations[0], nil
+}
+
+// ListConversations retrieves the conversations of a user with their latest message,
+// most recently active first
+func (r *MessageRepository) ListConversations(ctx context.Context, userID string, page, limit int) ([]*message.Conversation, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	var models []conversationModel
+	err := r.db.WithContext(ctx).
+		Select("conversations.*").
+		Joins("JOIN conversation_members ON conversation_members.conversation_id = conversations.id").
+		Where("conversation_members.user_id = ?", userID).
+		Order("conversations.last_message_at DESC, conversations.id").
+		Offset(offset).
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list conversations")
+	}
+
+	return r.loadConversations(ctx, models, userID)
+}
+
+// ListContacts retrieves the users who share at least one conversation with a user
+func (r *MessageRepository) ListContacts(ctx context.Context, userID string) ([]message.Contact, error) {
+	var rows []struct {
+		UserID      string
+		Handle      string
+		DisplayName string
+		AvatarURL   string
+	}
+	err := r.db.WithContext(ctx).
+		Table("users").
+		Select("users.id AS user_id, users.handle, users.display_name, users.avatar_url").
+		Where("users.id != ? AND users.id IN (SELECT others.user_id FROM conversation_members AS others "+
+			"JOIN conversation_members AS own ON own.conversation_id = others.conversation_id WHERE own.user_id = ?)", userID, userID).
+		Order("users.handle").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list contacts")
+	}
+
+	contacts := make([]message.Contact, 0, len(rows))
+	for _, row := range rows {
+		contacts = append(contacts, message.Contact{
+			UserID: row.UserID,
+			Profile: message.Profile{
+				Handle:      row.Handle,
+				DisplayName: row.DisplayName,
+				AvatarURL:   row.AvatarURL,
+			},
+		})
+	}
+
+	return contacts, nil
+}
+
+// unreadCondition matches the messages the member joined as conversation_members has not
+// read: messages received without a read receipt in one-to-one conversations, messages of
+// other members after the last-read pointer in group conversations
+const unreadCondition = "((messages.recipient_id = conversation_members.user_id AND messages.read_at = 0) OR " +
+	"(messages.recipient_id = '' AND messages.sender_id != conversation_members.user_id AND messages.seq > conversation_members.last_read_seq))"
+
+// loadConversations maps conversation models to domain models, along with their members,
+// their latest message and the number of messages viewerID has not read
+func (r *MessageRepository) loadConversations(ctx context.Context, models []conversationModel, viewerID string) ([]*message.Conversation, error) {
+	conversations := make([]*message.Conversation, 0, len(models))
+	byID := make(map[string]*message.Conversation, len(models))
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		c := &message.Conversation{
+			ID:            model.ID,
+			IsGroup:       model.DirectKey == nil,
+			Name:          model.Name,
+			CreatedBy:     model.CreatedBy,
+			CreatedAt:     time.Unix(model.CreatedAt, 0),
+			LastMessageAt: time.Unix(model.LastMessageAt, 0),
+			Members:       []message.Member{},
+		}
+		conversations = append(conversations, c)
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+
+	if len(ids) == 0 {
+		return conversations, nil
+	}
+
+	var members []struct {
+		ConversationID string
+		UserID         string
+		Role           string
+		JoinedAt       int64
+		LastReadSeq    int64
+		Handle         string
+		DisplayName    string
+		AvatarURL      string
+	}
+	err := r.db.WithContext(ctx).
+		Table("conversation_members").
+		Select("conversation_members.conversation_id, conversation_members.user_id, conversation_members.role, conversation_members.joined_at, conversation_members.last_read_seq, users.handle, users.display_name, users.avatar_url").
+		Joins("JOIN users ON users.id = conversation_members.user_id").
+		Where("conversation_members.conversation_id IN ?", ids).
+		Order("conversation_members.joined_at, conversation_members.user_id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list conversation members")
+	}
+
+	for _, member := range members {
+		c := byID[member.ConversationID]
+		c.Members = append(c.Members, message.Member{
+			UserID:      member.UserID,
+			Role:        member.Role,
+			JoinedAt:    time.Unix(member.JoinedAt, 0),
+			LastReadSeq: member.LastReadSeq,
+			Profile: message.Profile{
+				Handle:      member.Handle,
+				DisplayName: member.DisplayName,
+				AvatarURL:   member.AvatarURL,
+			},
+		})
+	}
+
+	var lastMessages []messageRow
+	err = r.messages(ctx).
+		Where("messages.seq IN (SELECT MAX(seq) FROM messages WHERE conversation_id IN ? GROUP BY conversation_id)", ids).
+		Scan(&lastMessages).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to get latest messages")
+	}
+
+	for _, row := range lastMessages {
+		byID[row.ConversationID].LastMessage = row.toDomain()
+	}
+
+	var unread []struct {
+		ConversationID string
+		Count          int
+	}
+	err = r.db.WithContext(ctx).
+		Table("messages").
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Joins("JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id AND conversation_members.user_id = ?", viewerID).
+		Where("messages.conversation_id IN ? AND "+unreadCondition, ids).
+		Group("messages.conversation_id").
+		Scan(&unread).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to count unread messages")
+	}
+
+	for _, row := range unread {
+		byID[row.ConversationID].UnreadCount = row.Count
+	}
+
+	return conversations, nil
+}
+
+// Rename changes the name of a group conversation
+func (r *MessageRepository) Rename(ctx context.Context, id, name string) error {
+	err := r.db.WithContext(ctx).
+		Model(&conversationModel{}).
+		Where("id = ?", id).
+		Update("name", name).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to rename conversation")
+	}
+
+	return nil
+}
+
+// AddMembers adds members to a group conversation (existing members are left untouched).
+// Their last-read pointer starts at the latest message.
+func (r *MessageRepository) AddMembers(ctx context.Context, id string, userIDs []string, joinedAt time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var lastSeq int64
+		if err := tx.Model(&messageModel{}).
+			Select("COALESCE(MAX(seq), 0)").
+			Where("conversation_id = ?", id).
+			Scan(&lastSeq).Error; err != nil {
+			return err
+		}
+
+		members := make([]conversationMemberModel, 0, len(userIDs))
+		for _, userID := range userIDs {
+			members = append(members, conversationMemberModel{
+				ConversationID: id,
+				UserID:         userID,
+				Role:           message.RoleMember,
+				JoinedAt:       joinedAt.Unix(),
+				LastReadSeq:    lastSeq,
+			})
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to add conversation members")
+	}
+
+	return nil
+}
+
+// RemoveMember removes a member from a group conversation, promotes the oldest remaining
+// member when the last admin leaves, and deletes the conversation once nobody is left
+func (r *MessageRepository) RemoveMember(ctx context.Context, id, userID string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ? AND user_id = ?", id, userID).
+			Delete(&conversationMemberModel{}).Error; err != nil {
+			return err
+		}
+
+		return cleanUpConversations(tx)
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to remove conversation member")
+	}
+
+	return nil
+}
+
+// cleanUpConversations runs after members leave conversations: group conversations left
+// without an admin get their oldest member promoted, and conversations left without
+// members are deleted along with their messages
+func cleanUpConversations(tx *gorm.DB) error {
+	if err := tx.Exec(`UPDATE conversation_members SET role = ?
+		WHERE NOT EXISTS (SELECT 1 FROM conversation_members AS admins
+			WHERE admins.conversation_id = conversation_members.conversation_id AND admins.role = ?)
+		AND user_id = (SELECT oldest.user_id FROM conversation_members AS oldest
+			WHERE oldest.conversation_id = conversation_members.conversation_id
+			ORDER BY oldest.joined_at, oldest.user_id LIMIT 1)
+		AND conversation_id IN (SELECT id FROM conversations WHERE direct_key IS NULL)`,
+		message.RoleAdmin, message.RoleAdmin).Error; err != nil {
+		return err
+	}
+
+	empty := tx.Model(&conversationModel{}).Select("id").
+		Where("NOT EXISTS (SELECT 1 FROM conversation_members WHERE conversation_members.conversation_id = conversations.id)")
+	if err := tx.Where("conversation_id IN (?)", empty).Delete(&messageModel{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("NOT EXISTS (SELECT 1 FROM conversation_members WHERE conversation_members.conversation_id = conversations.id)").
+		Delete(&conversationModel{}).Error
+}
+
+// MarkConversationRead marks the messages of a conversation up to upToSeq as read by the
+// user: the user's last-read pointer moves there, and the messages the user received in
+// a one-to-one conversation get a read receipt
+func (r *MessageRepository) MarkConversationRead(ctx context.Context, id, userID string, upToSeq int64, at time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&conversationMemberModel{}).
+			Where("conversation_id = ? AND user_id = ? AND last_read_seq < ?", id, userID, upToSeq).
+			Update("last_read_seq", upToSeq).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&messageModel{}).
+			Where("conversation_id = ? AND recipient_id = ? AND seq <= ? AND read_at = 0", id, userID, upToSeq).
+			Update("read_at", at.Unix()).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to mark conversation as read")
+	}
+
+	return nil
+}
+
+// SetLastRead moves the last-read pointer of a member of a group conversation
+func (r *MessageRepository) SetLastRead(ctx context.Context, id, userID string, seq int64) error {
+	err := r.db.WithContext(ctx).
+		Model(&conversationMemberModel{}).
+		Where("conversation_id = ? AND user_id = ?", id, userID).
+		Update("last_read_seq", seq).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update last-read pointer")
+	}
+
+	return nil
+}
+
+// Create stores a new message and updates the activity of its conversation
+func (r *MessageRepository) Create(ctx context.Context, m *message.Message) error {
+	model := &messageModel{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		RecipientID:    m.RecipientID,
+		Subject:        m.Subject,
+		Content:        m.Content,
+		CreatedAt:      m.CreatedAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&conversationModel{}).
+			Where("id = ?", m.ConversationID).
+			Update("last_message_at", model.CreatedAt).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to send message")
+	}
+
+	m.Seq = model.Seq
+	return nil
+}
+
+// GetByID retrieves a message by ID
+func (r *MessageRepository) GetByID(ctx context.Context, id string) (*message.Message, error) {
+	var rows []messageRow
+	err := r.messages(ctx).
+		Where("messages.id = ?", id).
+		Limit(1).
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to get message")
+	}
+	if len(rows) == 0 {
+		return nil, apperrors.ErrMessageNotFound
+	}
+
+	return rows[0].toDomain(), nil
+}
+
+// ListReceived retrieves the messages received by a user in one-to-one conversations,
+// newest first
+func (r *MessageRepository) ListReceived(ctx context.Context, userID string, status message.ReadStatus, page, limit int) ([]*message.Message, error) {
+	query := r.messages(ctx).Where("messages.recipient_id = ?", userID)
+	switch status {
+	case message.OnlyRead:
+		query = query.Where("messages.read_at != 0")
+	case message.OnlyUnread:
+		query = query.Where("messages.read_at = 0")
+	}
+
+	return r.list(query, page, limit)
+}
+
+// ListByConversation retrieves the messages of a conversation sent before beforeSeq
+// (all of them if 0), newest first
+func (r *MessageRepository) ListByConversation(ctx context.Context, conversationID string, beforeSeq int64, page, limit int) ([]*message.Message, error) {
+	query := r.messages(ctx).Where("messages.conversation_id = ?", conversationID)
+	if beforeSeq > 0 {
+		query = query.Where("messages.seq < ?", beforeSeq)
+	}
+
+	return r.list(query, page, limit)
+}
+
+// list retrieves the messages matching a query with pagination, newest first
+func (r *MessageRepository) list(query *gorm.DB, page, limit int) ([]*message.Message, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	var rows []messageRow
+	err := query.
+		Order("messages.seq DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list messages")
+	}
+
+	messages := make([]*message.Message, 0, len(rows))
+	for i := range rows {
+		messages = append(messages, rows[i].toDomain())
+	}
+
+	return messages, nil
+}
+
+// CountUnread counts the messages a user has not read, in all their conversations
+func (r *MessageRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("messages").
+		Joins("JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id AND conversation_members.user_id = ?", userID).
+		Where(unreadCondition).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperrors.Wrap(err, 500, "failed to count unread messages")
+	}
+
+	return int(count), nil
+}
+
+// SetReadAt records when a message was read (a zero time marks it as unread)
+func (r *MessageRepository) SetReadAt(ctx context.Context, id string, readAt time.Time) error {
+	var ts int64
+	if !readAt.IsZero() {
+		ts = readAt.Unix()
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&messageModel{}).
+		Where("id = ?", id).
+		Update("read_at", ts).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update message")
+	}
+
+	return nil
+}
+
+// Delete deletes a message
+func (r *MessageRepository) Delete(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&messageModel{}).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to delete message")
+	}
+
+	return nil
+}
+
+// messages starts a query selecting messages with the profiles of their participants
+func (r *MessageRepository) messages(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Table("messages").
+		Select(messageColumns).
+		Joins("JOIN users AS senders ON senders.id = messages.sender_id").
+		Joins("LEFT JOIN users AS recipients ON recipients.id = messages.recipient_id")
+}
+
+// toDomain maps a message row to the domain model
+func (row *messageRow) toDomain() *message.Message {
+	return &message.Message{
+		ID:             row.ID,
+		Seq:            row.Seq,
+		ConversationID: row.ConversationID,
+		SenderID:       row.SenderID,
+		RecipientID:    row.RecipientID,
+		Subject:        row.Subject,
+		Content:        row.Content,
+		CreatedAt:      time.Unix(row.CreatedAt, 0),
+		ReadAt:         unixOrZero(row.ReadAt),
+		SenderProfile: message.Profile{
+			Handle:      row.SenderHandle,
+			DisplayName: row.SenderDisplayName,
+			AvatarURL:   row.SenderAvatarURL,
+		},
+		RecipientProfile: message.Profile{
+			Handle:      row.RecipientHandle,
+			DisplayName: row.RecipientDisplayName,
+			AvatarURL:   row.RecipientAvatarURL,
+		},
+	}
+}
@@ -2,8 +2,26 @@ package sqlite
 
 // userModel represents the database model for users
 type userModel struct {
-	Email        string `gorm:"primaryKey"`
-	PasswordHash string // bcrypt hash (salt is embedded in the hash)
+	ID            string  `gorm:"primaryKey"` // opaque ID referenced by every other table
+	Email         string  `gorm:"uniqueIndex;not null"`
+	PasswordHash  string  // argon2id (PHC string) or bcrypt hash, embedding salt and parameters
+	EmailVerified bool    `gorm:"not null;default:false"`
+	Handle        *string `gorm:"uniqueIndex"` // stored lowercase; nullable only until legacy rows are backfilled
+	DisplayName   string
+	Bio           string
+	AvatarURL     string
+	TOTPSecret    string `gorm:"column:totp_secret"`
+	TOTPEnabled   bool   `gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastStep  int64  `gorm:"column:totp_last_step;not null;default:0"` // last accepted TOTP time step (replay protection)
+	Role          string `gorm:"not null;default:user;index"`
+	SuspendedAt   int64  `gorm:"not null;default:0"` // 0 unless a moderator suspended the account
+	// DeletionScheduledAt is when the account will be purged (0 if no deletion is pending)
+	DeletionScheduledAt int64 `gorm:"column:deletion_scheduled_at;not null;default:0;index"`
+	// DeletedAt is set when a purged account is kept, anonymised, for the posts other users replied to
+	DeletedAt int64 `gorm:"column:deleted_at;not null;default:0"`
+	// InvitedBy and InviteID record who invited the user and with which invite (empty if none)
+	InvitedBy string `gorm:"column:invited_by;index"`
+	InviteID  string `gorm:"column:invite_id;index"`
 }
 
 // TableName overrides the table name
@@ -11,14 +29,34 @@ func (userModel) TableName() string {
 	return "users"
 }
 
+// recoveryCodeModel represents the database model for two-factor recovery codes
+type recoveryCodeModel struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    string `gorm:"column:user_id;index;not null"`
+	CodeHash  string `gorm:"not null"` // SHA-256 of the code, the code itself is only shown once
+	CreatedAt int64
+	UsedAt    int64 `gorm:"not null;default:0"` // 0 while unused
+}
+
+// TableName overrides the table name
+func (recoveryCodeModel) TableName() string {
+	return "recovery_codes"
+}
+
 // postModel represents the database model for posts
 type postModel struct {
-	ID        string `gorm:"primaryKey"`
-	UserEmail string `gorm:"column:user_email;index;not null"`
+	ID        string  `gorm:"primaryKey"`
+	UserID    string  `gorm:"column:user_id;index;not null"`
+	ParentID  *string `gorm:"column:parent_id;index"`
+	RootID    *string `gorm:"column:root_id;index"`
 	Content   string
 	CreatedAt int64 `gorm:"index"`
+	UpdatedAt int64
+	// DeletedAt is set when the post is replaced by a tombstone (0 otherwise).
+	// Replies reference their parent without a cascading constraint so they survive its deletion.
+	DeletedAt int64 `gorm:"column:deleted_at;default:0;index"`
 	// GORM relation
-	User userModel `gorm:"foreignKey:UserEmail;references:Email;constraint:OnDelete:CASCADE"`
+	User userModel `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 // TableName overrides the table name
@@ -28,10 +66,10 @@ func (postModel) TableName() string {
 
 // likeModel represents the database model for likes
 type likeModel struct {
-	UserEmail string `gorm:"primaryKey;column:user_email;not null"`
-	PostID    string `gorm:"primaryKey;column:post_id;index;not null"`
+	UserID string `gorm:"primaryKey;column:user_id;not null"`
+	PostID string `gorm:"primaryKey;column:post_id;index;not null"`
 	// GORM relations (using pointers to avoid circular reference issues)
-	User *userModel `gorm:"foreignKey:UserEmail;references:Email;constraint:OnDelete:CASCADE"`
+	User *userModel `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 	Post *postModel `gorm:"foreignKey:PostID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
@@ -39,3 +77,301 @@ type likeModel struct {
 func (likeModel) TableName() string {
 	return "liked_posts"
 }
+
+// revisionModel represents the database model for post revisions
+type revisionModel struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"`
+	PostID     string `gorm:"column:post_id;index;not null"`
+	Content    string
+	CreatedAt  int64
+	ReplacedAt int64 `gorm:"index"`
+	// GORM relation
+	Post *postModel `gorm:"foreignKey:PostID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (revisionModel) TableName() string {
+	return "post_revisions"
+}
+
+// followModel represents the database model for follow relationships
+type followModel struct {
+	FollowerID string `gorm:"primaryKey;column:follower_id;not null"`
+	FolloweeID string `gorm:"primaryKey;column:followee_id;index;not null"`
+	CreatedAt  int64  `gorm:"index"`
+	// GORM relations
+	Follower *userModel `gorm:"foreignKey:FollowerID;references:ID;constraint:OnDelete:CASCADE"`
+	Followee *userModel `gorm:"foreignKey:FolloweeID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (followModel) TableName() string {
+	return "follows"
+}
+
+// sessionModel represents the database model for login sessions (refresh token families)
+type sessionModel struct {
+	ID            string `gorm:"primaryKey"`
+	UserID        string `gorm:"column:user_id;index;not null"`
+	UserAgent     string
+	IPAddress     string
+	AccessTokenID string // jti of the latest access token
+	CreatedAt     int64
+	LastSeenAt    int64
+	RevokedAt     int64  `gorm:"default:0"`
+	ClientID      string `gorm:"column:client_id;not null;default:'';index"` // OAuth client, empty for logins
+	Scopes        string `gorm:"not null;default:''"`                        // space-separated scopes granted to the OAuth client
+	// GORM relation
+	User *userModel `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (sessionModel) TableName() string {
+	return "sessions"
+}
+
+// refreshTokenModel represents the database model for refresh tokens
+type refreshTokenModel struct {
+	ID        string `gorm:"primaryKey"`
+	SessionID string `gorm:"column:session_id;index;not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"` // SHA-256 of the opaque token
+	CreatedAt int64
+	ExpiresAt int64
+	UsedAt    int64 `gorm:"default:0"`
+	// GORM relation
+	Session *sessionModel `gorm:"foreignKey:SessionID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (refreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+// verificationTokenModel represents the database model for password reset and email verification tokens
+type verificationTokenModel struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"column:user_id;index;not null"`
+	Purpose   string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"` // SHA-256 of the token
+	CreatedAt int64
+	ExpiresAt int64
+	UsedAt    int64 `gorm:"default:0"`
+	// GORM relation
+	User *userModel `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (verificationTokenModel) TableName() string {
+	return "verification_tokens"
+}
+
+// accessTokenModel represents the database model for personal access tokens
+type accessTokenModel struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"column:user_id;index;not null"`
+	Name       string `gorm:"not null"`
+	Scopes     string `gorm:"not null"`             // space-separated list of scopes
+	TokenHash  string `gorm:"uniqueIndex;not null"` // SHA-256 of the token
+	CreatedAt  int64  `gorm:"index"`
+	LastUsedAt int64  `gorm:"default:0"`
+	ExpiresAt  int64  `gorm:"default:0"` // 0 if the token never expires
+	// GORM relation
+	User *userModel `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (accessTokenModel) TableName() string {
+	return "access_tokens"
+}
+
+// oauthClientModel represents the database model for OAuth clients
+type oauthClientModel struct {
+	ID           string `gorm:"primaryKey"`
+	OwnerID      string `gorm:"column:owner_id;index;not null"`
+	Name         string `gorm:"not null"`
+	SecretHash   string // SHA-256 of the client secret, empty for public clients
+	RedirectURIs string `gorm:"column:redirect_uris;not null"` // space-separated list of redirect URIs
+	Scopes       string `gorm:"not null"`                      // space-separated list of scopes
+	CreatedAt    int64  `gorm:"index"`
+	// GORM relation
+	Owner *userModel `gorm:"foreignKey:OwnerID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (oauthClientModel) TableName() string {
+	return "oauth_clients"
+}
+
+// oauthCodeModel represents the database model for OAuth authorization codes
+type oauthCodeModel struct {
+	ID                  string `gorm:"primaryKey"`
+	CodeHash            string `gorm:"uniqueIndex;not null"` // SHA-256 of the code
+	ClientID            string `gorm:"column:client_id;index;not null"`
+	UserID              string `gorm:"column:user_id;index;not null"`
+	RedirectURI         string `gorm:"column:redirect_uri;not null"`
+	Scopes              string `gorm:"not null"`
+	CodeChallenge       string `gorm:"not null"`
+	CodeChallengeMethod string `gorm:"not null"`
+	CreatedAt           int64
+	ExpiresAt           int64 `gorm:"index"`
+	UsedAt              int64 `gorm:"default:0"`
+	SessionID           string
+	// GORM relations
+	Client *oauthClientModel `gorm:"foreignKey:ClientID;references:ID;constraint:OnDelete:CASCADE"`
+	User   *userModel        `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (oauthCodeModel) TableName() string {
+	return "oauth_codes"
+}
+
+// oauthConsentModel represents the database model for the scopes users granted to OAuth clients
+type oauthConsentModel struct {
+	UserID    string `gorm:"primaryKey;column:user_id;not null"`
+	ClientID  string `gorm:"primaryKey;column:client_id;index;not null"`
+	Scopes    string `gorm:"not null"`
+	UpdatedAt int64
+	// GORM relations
+	User   *userModel        `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Client *oauthClientModel `gorm:"foreignKey:ClientID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (oauthConsentModel) TableName() string {
+	return "oauth_consents"
+}
+
+// loginAttemptModel represents the database model for failed login attempt tracking
+type loginAttemptModel struct {
+	Key         string `gorm:"primaryKey"` // account:<email> or ip:<address>
+	Failures    int    `gorm:"not null;default:0"`
+	LastFailure int64  `gorm:"index"`
+	LockedUntil int64  `gorm:"default:0"`
+}
+
+// TableName overrides the table name
+func (loginAttemptModel) TableName() string {
+	return "login_attempts"
+}
+
+// auditEntryModel represents the database model for audit log entries
+type auditEntryModel struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	Action    string `gorm:"index;not null"`
+	Subject   string `gorm:"index"`
+	IPAddress string `gorm:"column:ip_address"`
+	Details   string
+	CreatedAt int64 `gorm:"index"`
+}
+
+// TableName overrides the table name
+func (auditEntryModel) TableName() string {
+	return "audit_log"
+}
+
+// inviteModel represents the database model for invite codes
+type inviteModel struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedBy string `gorm:"column:created_by;index;not null"`
+	CodeHash  string `gorm:"uniqueIndex;not null"` // SHA-256 of the normalized code
+	MaxUses   int    `gorm:"not null"`
+	Uses      int    `gorm:"not null;default:0"`
+	CreatedAt int64  `gorm:"index"`
+	ExpiresAt int64  `gorm:"index"`
+	RevokedAt int64  `gorm:"not null;default:0"`
+	// GORM relation
+	Creator *userModel `gorm:"foreignKey:CreatedBy;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (inviteModel) TableName() string {
+	return "invites"
+}
+
+// conversationModel represents the database model for conversations
+type conversationModel struct {
+	ID string `gorm:"primaryKey"`
+	// DirectKey identifies one-to-one conversations by their two members (sorted IDs joined
+	// with ":"), so that two users share a single conversation
+	DirectKey     *string `gorm:"column:direct_key;uniqueIndex"`
+	Name          string  // group conversations only
+	CreatedBy     string  `gorm:"column:created_by;not null;default:''"` // creator of a group conversation
+	CreatedAt     int64
+	LastMessageAt int64 `gorm:"index"`
+}
+
+// TableName overrides the table name
+func (conversationModel) TableName() string {
+	return "conversations"
+}
+
+// conversationMemberModel represents the database model for conversation participants
+type conversationMemberModel struct {
+	ConversationID string `gorm:"primaryKey;column:conversation_id;not null"`
+	UserID         string `gorm:"primaryKey;column:user_id;index;not null"`
+	Role           string `gorm:"not null;default:member"`
+	JoinedAt       int64
+	LastReadSeq    int64 `gorm:"column:last_read_seq;not null;default:0"` // group conversations: seq of the last message read
+	// GORM relations
+	Conversation *conversationModel `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE"`
+	User         *userModel         `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (conversationMemberModel) TableName() string {
+	return "conversation_members"
+}
+
+// messageModel represents the database model for direct messages
+type messageModel struct {
+	Seq            int64  `gorm:"primaryKey;autoIncrement"` // insertion order
+	ID             string `gorm:"uniqueIndex;not null"`
+	ConversationID string `gorm:"column:conversation_id;index;not null"`
+	SenderID       string `gorm:"column:sender_id;index;not null"`
+	RecipientID    string `gorm:"column:recipient_id;index;not null"` // empty in group conversations
+	Subject        string
+	Content        string
+	CreatedAt      int64
+	ReadAt         int64 `gorm:"not null;default:0"` // 0 until the recipient reads the message
+	// GORM relation
+	Conversation *conversationModel `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (messageModel) TableName() string {
+	return "messages"
+}
+
+// notificationModel represents the database model for notifications
+type notificationModel struct {
+	ID     string `gorm:"primaryKey"`
+	UserID string `gorm:"column:user_id;index;not null"` // notified user
+	Type   string `gorm:"not null"`
+	PostID string `gorm:"column:post_id;index;not null;default:''"` // empty for follows
+	// Seq is the seq of the latest actor, it orders the notifications of a user
+	Seq       int64 `gorm:"not null;default:0;index"`
+	CreatedAt int64
+	UpdatedAt int64 `gorm:"autoUpdateTime:false"` // when the latest actor was added
+	ReadAt    int64 `gorm:"not null;default:0"`   // 0 until the user reads the notification
+}
+
+// TableName overrides the table name
+func (notificationModel) TableName() string {
+	return "notifications"
+}
+
+// notificationActorModel represents the database model for the users behind a notification
+type notificationActorModel struct {
+	Seq            int64  `gorm:"primaryKey;autoIncrement"` // insertion order
+	NotificationID string `gorm:"column:notification_id;uniqueIndex:idx_notification_actor;not null"`
+	ActorID        string `gorm:"column:actor_id;uniqueIndex:idx_notification_actor;index;not null"`
+	CreatedAt      int64
+	// GORM relation
+	Notification *notificationModel `gorm:"foreignKey:NotificationID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName overrides the table name
+func (notificationActorModel) TableName() string {
+	return "notification_actors"
+}
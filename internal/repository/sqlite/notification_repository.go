@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ynov-social-api/internal/domain/notification"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// NotificationRepository implements notification.Repository interface
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// actorsPreview is the number of actors loaded with each notification
+const actorsPreview = 3
+
+// visibleNotification excludes the notifications about posts that were deleted since
+const visibleNotification = "(notifications.post_id = '' OR notifications.post_id IN (SELECT id FROM posts WHERE deleted_at = 0))"
+
+// sameNotification matches the notifications of a recipient with the type and post of an event
+const sameNotification = "notifications.user_id = ? AND notifications.type = ? AND notifications.post_id = ?"
+
+// notificationRow represents a notification with its number of actors
+type notificationRow struct {
+	ID          string
+	UserID      string
+	Type        string
+	PostID      string
+	Seq         int64
+	CreatedAt   int64
+	UpdatedAt   int64
+	ReadAt      int64
+	ActorsCount int
+}
+
+// actorRow represents an actor of a notification with their public profile
+type actorRow struct {
+	NotificationID string
+	ActorID        string
+	Handle         string
+	DisplayName    string
+	AvatarURL      string
+}
+
+// Record adds the actor of an event to the recipient's unread notification of the same
+// type about the same post, or creates a notification with the given ID if there is none.
+// It returns the ID of the notification, or an empty string if nothing was recorded.
+func (r *NotificationRepository) Record(ctx context.Context, id string, e notification.Event, at time.Time) (string, error) {
+	var recordedID string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Repeated actions (liking a post again after unliking it...) only notify once
+		var notified int64
+		if err := tx.Model(&notificationActorModel{}).
+			Joins("JOIN notifications ON notifications.id = notification_actors.notification_id").
+			Where(sameNotification+" AND notification_actors.actor_id = ?", e.RecipientID, e.Type, e.PostID, e.ActorID).
+			Count(&notified).Error; err != nil {
+			return err
+		}
+		if notified > 0 {
+			return nil
+		}
+
+		var model notificationModel
+		err := tx.Where(sameNotification+" AND notifications.read_at = 0", e.RecipientID, e.Type, e.PostID).
+			Order("seq DESC").
+			Take(&model).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			model = notificationModel{
+				ID:        id,
+				UserID:    e.RecipientID,
+				Type:      e.Type,
+				PostID:    e.PostID,
+				CreatedAt: at.Unix(),
+			}
+			err = tx.Create(&model).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		actor := notificationActorModel{
+			NotificationID: model.ID,
+			ActorID:        e.ActorID,
+			CreatedAt:      at.Unix(),
+		}
+		if err := tx.Create(&actor).Error; err != nil {
+			return err
+		}
+
+		// Move the notification to the top of the feed
+		if err := tx.Model(&notificationModel{}).
+			Where("id = ?", model.ID).
+			Updates(map[string]interface{}{"seq": actor.Seq, "updated_at": at.Unix()}).Error; err != nil {
+			return err
+		}
+
+		recordedID = model.ID
+		return nil
+	})
+
+	if err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to record notification")
+	}
+
+	return recordedID, nil
+}
+
+// Withdraw removes the actor of an event from the recipient's unread notifications of the
+// same type about the same post, deleting the notifications left without actors
+func (r *NotificationRepository) Withdraw(ctx context.Context, e notification.Event) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		unread := tx.Model(&notificationModel{}).Select("id").
+			Where(sameNotification+" AND notifications.read_at = 0", e.RecipientID, e.Type, e.PostID)
+		if err := tx.Where("notification_id IN (?) AND actor_id = ?", unread, e.ActorID).
+			Delete(&notificationActorModel{}).Error; err != nil {
+			return err
+		}
+
+		return cleanUpNotifications(tx)
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to withdraw notification")
+	}
+
+	return nil
+}
+
+// cleanUpNotifications deletes the notifications left without actors
+func cleanUpNotifications(tx *gorm.DB) error {
+	return tx.Where("NOT EXISTS (SELECT 1 FROM notification_actors WHERE notification_actors.notification_id = notifications.id)").
+		Delete(&notificationModel{}).Error
+}
+
+// GetByID retrieves a notification by ID
+func (r *NotificationRepository) GetByID(ctx context.Context, id string) (*notification.Notification, error) {
+	notifications, err := r.find(ctx, r.notificationQuery(ctx).Where("notifications.id = ?", id))
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to get notification")
+	}
+	if len(notifications) == 0 {
+		return nil, apperrors.ErrNotFound
+	}
+
+	return notifications[0], nil
+}
+
+// List retrieves the notifications of a user with a Seq lower than beforeSeq (all of them
+// if 0), most recent first
+func (r *NotificationRepository) List(ctx context.Context, userID string, beforeSeq int64, page, limit int) ([]*notification.Notification, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	query := r.notificationQuery(ctx).
+		Where("notifications.user_id = ? AND "+visibleNotification, userID)
+	if beforeSeq > 0 {
+		query = query.Where("notifications.seq < ?", beforeSeq)
+	}
+
+	notifications, err := r.find(ctx, query.Order("notifications.seq DESC").Offset(offset).Limit(limit))
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list notifications")
+	}
+
+	return notifications, nil
+}
+
+// notificationQuery selects notifications with their number of actors
+func (r *NotificationRepository) notificationQuery(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Table("notifications").
+		Select("notifications.*, (SELECT COUNT(*) FROM notification_actors WHERE notification_actors.notification_id = notifications.id) AS actors_count")
+}
+
+// find runs a notification query and loads the latest actors of the notifications found
+func (r *NotificationRepository) find(ctx context.Context, query *gorm.DB) ([]*notification.Notification, error) {
+	var rows []notificationRow
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	notifications := make([]*notification.Notification, 0, len(rows))
+	byID := make(map[string]*notification.Notification, len(rows))
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		n := &notification.Notification{
+			ID:          row.ID,
+			UserID:      row.UserID,
+			Type:        row.Type,
+			PostID:      row.PostID,
+			Seq:         row.Seq,
+			Actors:      []notification.Actor{},
+			ActorsCount: row.ActorsCount,
+			CreatedAt:   time.Unix(row.CreatedAt, 0),
+			UpdatedAt:   time.Unix(row.UpdatedAt, 0),
+			ReadAt:      unixOrZero(row.ReadAt),
+		}
+		notifications = append(notifications, n)
+		byID[n.ID] = n
+		ids = append(ids, n.ID)
+	}
+	if len(ids) == 0 {
+		return notifications, nil
+	}
+
+	// Load the latest actors of every notification at once
+	ranked := r.db.WithContext(ctx).
+		Table("notification_actors").
+		Select("notification_actors.notification_id, notification_actors.actor_id, notification_actors.seq, "+
+			"users.handle, users.display_name, users.avatar_url, "+
+			"ROW_NUMBER() OVER (PARTITION BY notification_actors.notification_id ORDER BY notification_actors.seq DESC) AS actor_rank").
+		Joins("JOIN users ON users.id = notification_actors.actor_id").
+		Where("notification_actors.notification_id IN ?", ids)
+
+	var actors []actorRow
+	if err := r.db.WithContext(ctx).
+		Table("(?) AS ranked", ranked).
+		Where("actor_rank <= ?", actorsPreview).
+		Order("seq DESC").
+		Find(&actors).Error; err != nil {
+		return nil, err
+	}
+
+	for _, actor := range actors {
+		n := byID[actor.NotificationID]
+		n.Actors = append(n.Actors, notification.Actor{
+			UserID:      actor.ActorID,
+			Handle:      actor.Handle,
+			DisplayName: actor.DisplayName,
+			AvatarURL:   actor.AvatarURL,
+		})
+	}
+
+	return notifications, nil
+}
+
+// CountUnread counts the notifications a user has not read
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&notificationModel{}).
+		Where("notifications.user_id = ? AND notifications.read_at = 0 AND "+visibleNotification, userID).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, apperrors.Wrap(err, 500, "failed to count notifications")
+	}
+
+	return int(count), nil
+}
+
+// MarkRead marks the notifications of a user up to upToSeq as read (all of them if 0)
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID string, upToSeq int64, at time.Time) error {
+	query := r.db.WithContext(ctx).
+		Model(&notificationModel{}).
+		Where("user_id = ? AND read_at = 0", userID)
+	if upToSeq > 0 {
+		query = query.Where("seq <= ?", upToSeq)
+	}
+
+	if err := query.Update("read_at", at.Unix()).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to mark notifications as read")
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/oauth"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthRepository implements oauth.Repository interface
+type OAuthRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthRepository creates a new OAuthRepository
+func NewOAuthRepository(db *gorm.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient stores a new client
+func (r *OAuthRepository) CreateClient(ctx context.Context, c *oauth.Client) error {
+	model := &oauthClientModel{
+		ID:           c.ID,
+		OwnerID:      c.OwnerID,
+		Name:         c.Name,
+		SecretHash:   c.SecretHash,
+		RedirectURIs: strings.Join(c.RedirectURIs, " "),
+		Scopes:       strings.Join(c.Scopes, " "),
+		CreatedAt:    c.CreatedAt.Unix(),
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return apperrors.Wrap(err, 500, "failed to create OAuth client")
+	}
+
+	return nil
+}
+
+// GetClient retrieves a client by ID
+func (r *OAuthRepository) GetClient(ctx context.Context, id string) (*oauth.Client, error) {
+	var model oauthClientModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrOAuthClientNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get OAuth client")
+	}
+
+	return model.toDomain(), nil
+}
+
+// ListClientsByOwner retrieves the clients registered by a user, newest first
+func (r *OAuthRepository) ListClientsByOwner(ctx context.Context, ownerID string) ([]*oauth.Client, error) {
+	var models []oauthClientModel
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ?", ownerID).
+		Order("created_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list OAuth clients")
+	}
+
+	clients := make([]*oauth.Client, 0, len(models))
+	for i := range models {
+		clients = append(clients, models[i].toDomain())
+	}
+
+	return clients, nil
+}
+
+// DeleteClient deletes a client along with its codes and consents.
+// Foreign keys are not enforced by default in SQLite, so dependents are deleted explicitly.
+func (r *OAuthRepository) DeleteClient(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&oauthCodeModel{}, &oauthConsentModel{}} {
+			if err := tx.Where("client_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).Delete(&oauthClientModel{}).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to delete OAuth client")
+	}
+
+	return nil
+}
+
+// CreateCode stores a new authorization code, cleaning up expired ones
+func (r *OAuthRepository) CreateCode(ctx context.Context, c *oauth.AuthorizationCode) error {
+	model := &oauthCodeModel{
+		ID:                  c.ID,
+		CodeHash:            c.CodeHash,
+		ClientID:            c.ClientID,
+		UserID:              c.UserID,
+		RedirectURI:         c.RedirectURI,
+		Scopes:              strings.Join(c.Scopes, " "),
+		CodeChallenge:       c.CodeChallenge,
+		CodeChallengeMethod: c.CodeChallengeMethod,
+		CreatedAt:           c.CreatedAt.Unix(),
+		ExpiresAt:           c.ExpiresAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Expired codes can no longer be exchanged nor replayed
+		if err := tx.Where("expires_at < ?", time.Now().Unix()).Delete(&oauthCodeModel{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(model).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to create authorization code")
+	}
+
+	return nil
+}
+
+// GetCodeByHash retrieves an authorization code by the hash of its value
+func (r *OAuthRepository) GetCodeByHash(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	var model oauthCodeModel
+	err := r.db.WithContext(ctx).First(&model, "code_hash = ?", codeHash).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get authorization code")
+	}
+
+	return &oauth.AuthorizationCode{
+		ID:                  model.ID,
+		CodeHash:            model.CodeHash,
+		ClientID:            model.ClientID,
+		UserID:              model.UserID,
+		RedirectURI:         model.RedirectURI,
+		Scopes:              strings.Fields(model.Scopes),
+		CodeChallenge:       model.CodeChallenge,
+		CodeChallengeMethod: model.CodeChallengeMethod,
+		CreatedAt:           time.Unix(model.CreatedAt, 0),
+		ExpiresAt:           time.Unix(model.ExpiresAt, 0),
+		UsedAt:              unixOrZero(model.UsedAt),
+		SessionID:           model.SessionID,
+	}, nil
+}
+
+// UseCode marks an authorization code as exchanged, failing if it already was
+func (r *OAuthRepository) UseCode(ctx context.Context, id string) (bool, error) {
+	// The guard makes concurrent exchanges of the same code fail
+	result := r.db.WithContext(ctx).
+		Model(&oauthCodeModel{}).
+		Where("id = ? AND used_at = 0", id).
+		Update("used_at", time.Now().Unix())
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to use authorization code")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// SetCodeSession records the session an authorization code was exchanged for
+func (r *OAuthRepository) SetCodeSession(ctx context.Context, id, sessionID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&oauthCodeModel{}).
+		Where("id = ?", id).
+		Update("session_id", sessionID).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update authorization code")
+	}
+
+	return nil
+}
+
+// GetConsent retrieves the consent a user gave to a client, or nil if there is none
+func (r *OAuthRepository) GetConsent(ctx context.Context, userID, clientID string) (*oauth.Consent, error) {
+	var model oauthConsentModel
+	err := r.db.WithContext(ctx).First(&model, "user_id = ? AND client_id = ?", userID, clientID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get consent")
+	}
+
+	return &oauth.Consent{
+		UserID:    model.UserID,
+		ClientID:  model.ClientID,
+		Scopes:    strings.Fields(model.Scopes),
+		UpdatedAt: time.Unix(model.UpdatedAt, 0),
+	}, nil
+}
+
+// SaveConsent creates or replaces the consent a user gave to a client
+func (r *OAuthRepository) SaveConsent(ctx context.Context, c *oauth.Consent) error {
+	model := &oauthConsentModel{
+		UserID:    c.UserID,
+		ClientID:  c.ClientID,
+		Scopes:    strings.Join(c.Scopes, " "),
+		UpdatedAt: c.UpdatedAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+		}).
+		Create(model).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to save consent")
+	}
+
+	return nil
+}
+
+// toDomain maps an OAuth client model to the domain model
+func (m *oauthClientModel) toDomain() *oauth.Client {
+	return &oauth.Client{
+		ID:           m.ID,
+		OwnerID:      m.OwnerID,
+		Name:         m.Name,
+		SecretHash:   m.SecretHash,
+		RedirectURIs: strings.Fields(m.RedirectURIs),
+		Scopes:       strings.Fields(m.Scopes),
+		CreatedAt:    time.Unix(m.CreatedAt, 0),
+	}
+}
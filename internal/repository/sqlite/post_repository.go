@@ -25,9 +25,12 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
 	model := &postModel{
 		ID:        p.ID,
-		UserEmail: p.Author, // Author is the user email
+		UserID:    p.AuthorID,
+		ParentID:  nullableString(p.ParentID),
+		RootID:    nullableString(p.RootID),
 		Content:   p.Content,
 		CreatedAt: p.CreatedAt.Unix(),
+		UpdatedAt: p.UpdatedAt.Unix(),
 	}
 
 	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
@@ -37,36 +40,111 @@ func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
 	return nil
 }
 
-// GetByID retrieves a post by ID
-func (r *PostRepository) GetByID(ctx context.Context, id string) (*post.Post, error) {
-	var model postModel
-	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+// postRow represents a post row enriched with computed, viewer-specific columns
+type postRow struct {
+	ID                string
+	UserID            string
+	ParentID          *string
+	RootID            *string
+	Content           string
+	CreatedAt         int64
+	UpdatedAt         int64
+	DeletedAt         int64
+	LikesCount        int64
+	RepliesCount      int64
+	LikedByMe         bool
+	AuthorHandle      string
+	AuthorDisplayName string
+	AuthorAvatarURL   string
+	AuthorPostsCount  int64
+}
+
+// toDomain maps a post row to the domain model
+func (row *postRow) toDomain() *post.Post {
+	p := &post.Post{
+		ID:           row.ID,
+		AuthorID:     row.UserID,
+		ParentID:     stringValue(row.ParentID),
+		RootID:       stringValue(row.RootID),
+		Content:      row.Content,
+		CreatedAt:    time.Unix(row.CreatedAt, 0),
+		UpdatedAt:    time.Unix(row.UpdatedAt, 0),
+		LikesCount:   int(row.LikesCount),
+		RepliesCount: int(row.RepliesCount),
+		LikedByMe:    row.LikedByMe,
+		AuthorProfile: post.AuthorProfile{
+			Handle:      row.AuthorHandle,
+			DisplayName: row.AuthorDisplayName,
+			AvatarURL:   row.AuthorAvatarURL,
+			PostsCount:  int(row.AuthorPostsCount),
+		},
+	}
+
+	// Tombstones do not expose their former author
+	if row.DeletedAt != 0 {
+		p.Deleted = true
+		p.AuthorID = ""
+		p.AuthorProfile = post.AuthorProfile{}
+	}
+
+	return p
+}
+
+// postColumns lists the columns selected for posts, the single placeholder being the viewer ID.
+// Author columns come from the authorJoin clause.
+const postColumns = `posts.id, posts.user_id, posts.parent_id, posts.root_id, posts.content,
+	posts.created_at, posts.updated_at, posts.deleted_at,
+	authors.handle AS author_handle, authors.display_name AS author_display_name, authors.avatar_url AS author_avatar_url,
+	(SELECT COUNT(*) FROM liked_posts WHERE liked_posts.post_id = posts.id) AS likes_count,
+	(SELECT COUNT(*) FROM posts AS replies WHERE replies.parent_id = posts.id) AS replies_count,
+	EXISTS (SELECT 1 FROM liked_posts WHERE liked_posts.post_id = posts.id AND liked_posts.user_id = ?) AS liked_by_me,
+	(SELECT COUNT(*) FROM posts AS author_posts WHERE author_posts.user_id = posts.user_id AND author_posts.deleted_at = 0) AS author_posts_count`
+
+// authorJoin joins the author of each post
+const authorJoin = "LEFT JOIN users AS authors ON authors.id = posts.user_id"
+
+// postQuery builds the base query selecting posts with their computed columns for the given viewer
+func (r *PostRepository) postQuery(ctx context.Context, viewerID string) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Table("posts").
+		Select(postColumns, viewerID).
+		Joins(authorJoin)
+}
+
+// GetByID retrieves a post by ID as seen by the given viewer
+func (r *PostRepository) GetByID(ctx context.Context, id, viewerID string) (*post.Post, error) {
+	var rows []postRow
+	err := r.postQuery(ctx, viewerID).
+		Where("posts.id = ?", id).
+		Limit(1).
+		Find(&rows).Error
 
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, apperrors.ErrPostNotFound
-		}
 		return nil, apperrors.Wrap(err, 500, "failed to get post")
 	}
+	if len(rows) == 0 {
+		return nil, apperrors.ErrPostNotFound
+	}
 
-	// Get likes count
-	var likesCount int64
-	r.db.WithContext(ctx).
-		Model(&likeModel{}).
-		Where("post_id = ?", id).
-		Count(&likesCount)
+	return rows[0].toDomain(), nil
+}
+
+// ListBefore retrieves top-level posts created before a given timestamp with pagination as seen by the given viewer
+func (r *PostRepository) ListBefore(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
+	return r.listFeed(r.postQuery(ctx, viewerID), beforeTimestamp, page, limit)
+}
 
-	return &post.Post{
-		ID:         model.ID,
-		Author:     model.UserEmail, // UserEmail is the author email
-		Content:    model.Content,
-		CreatedAt:  time.Unix(model.CreatedAt, 0),
-		LikesCount: int(likesCount),
-	}, nil
+// ListTimeline retrieves top-level posts from the viewer and the users they follow,
+// created before a given timestamp with pagination
+func (r *PostRepository) ListTimeline(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
+	query := r.postQuery(ctx, viewerID).
+		Where("posts.user_id = ? OR posts.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)", viewerID, viewerID)
+
+	return r.listFeed(query, beforeTimestamp, page, limit)
 }
 
-// ListBefore retrieves posts created before a given timestamp with pagination
-func (r *PostRepository) ListBefore(ctx context.Context, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
+// listFeed paginates top-level posts of the given query, newest first
+func (r *PostRepository) listFeed(query *gorm.DB, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -76,46 +154,229 @@ func (r *PostRepository) ListBefore(ctx context.Context, beforeTimestamp int64,
 
 	offset := (page - 1) * limit
 
-	var results []struct {
-		ID         string
-		UserEmail  string
-		Content    string
-		CreatedAt  int64
-		LikesCount int64
-	}
-
-	query := r.db.WithContext(ctx).
-		Table("posts").
-		Select("posts.id, posts.user_email, posts.content, posts.created_at, COUNT(liked_posts.post_id) AS likes_count").
-		Joins("LEFT JOIN liked_posts ON liked_posts.post_id = posts.id").
-		Group("posts.id").
+	query = query.
+		Where("posts.parent_id IS NULL AND posts.deleted_at = 0").
 		Order("posts.created_at DESC, posts.id DESC")
 
 	if beforeTimestamp > 0 {
 		query = query.Where("posts.created_at < ?", beforeTimestamp)
 	}
 
-	err := query.Offset(offset).Limit(limit).Find(&results).Error
+	var rows []postRow
+	err := query.Offset(offset).Limit(limit).Find(&rows).Error
 	if err != nil {
 		return nil, apperrors.Wrap(err, 500, "failed to list posts")
 	}
 
-	posts := make([]*post.Post, 0, len(results))
-	for _, r := range results {
-		posts = append(posts, &post.Post{
-			ID:         r.ID,
-			Author:     r.UserEmail, // UserEmail is the author email
-			Content:    r.Content,
-			CreatedAt:  time.Unix(r.CreatedAt, 0),
-			LikesCount: int(r.LikesCount),
-		})
+	posts := make([]*post.Post, 0, len(rows))
+	for i := range rows {
+		posts = append(posts, rows[i].toDomain())
+	}
+
+	return posts, nil
+}
+
+// ListReplies retrieves, for each parent, its direct replies in chronological order,
+// skipping the first offset replies and returning at most limit replies per parent
+func (r *PostRepository) ListReplies(ctx context.Context, viewerID string, parentIDs []string, offset, limit int) ([]*post.Post, error) {
+	if len(parentIDs) == 0 {
+		return []*post.Post{}, nil
+	}
+
+	// Rank replies within each parent so a single query can page every parent at once
+	ranked := r.db.WithContext(ctx).
+		Table("posts").
+		Select(postColumns+", ROW_NUMBER() OVER (PARTITION BY posts.parent_id ORDER BY posts.created_at, posts.id) AS reply_rank", viewerID).
+		Joins(authorJoin).
+		Where("posts.parent_id IN ?", parentIDs)
+
+	var rows []postRow
+	err := r.db.WithContext(ctx).
+		Table("(?) AS ranked", ranked).
+		Where("reply_rank > ? AND reply_rank <= ?", offset, offset+limit).
+		Order("created_at, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list replies")
+	}
+
+	posts := make([]*post.Post, 0, len(rows))
+	for i := range rows {
+		posts = append(posts, rows[i].toDomain())
+	}
+
+	return posts, nil
+}
+
+// ListAncestors retrieves the posts above a post, from the nearest up to limit levels,
+// returned top-down (the farthest first)
+func (r *PostRepository) ListAncestors(ctx context.Context, id, viewerID string, limit int) ([]*post.Post, error) {
+	// Walk up the reply chain in a single query
+	var rows []postRow
+	err := r.db.WithContext(ctx).
+		Raw("WITH RECURSIVE chain(id, parent_id, level) AS ("+
+			"SELECT id, parent_id, 0 FROM posts WHERE id = ? "+
+			"UNION ALL SELECT posts.id, posts.parent_id, chain.level + 1 FROM posts JOIN chain ON posts.id = chain.parent_id WHERE chain.level < ?) "+
+			"SELECT "+postColumns+" FROM posts "+authorJoin+" JOIN chain ON chain.id = posts.id WHERE chain.level > 0 ORDER BY chain.level DESC",
+			id, limit, viewerID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list ancestors")
+	}
+
+	posts := make([]*post.Post, 0, len(rows))
+	for i := range rows {
+		posts = append(posts, rows[i].toDomain())
+	}
+
+	return posts, nil
+}
+
+// ListByAuthor retrieves every post and reply of the given author, oldest first (tombstones excluded)
+func (r *PostRepository) ListByAuthor(ctx context.Context, authorID string) ([]*post.Post, error) {
+	query := r.postQuery(ctx, authorID).
+		Where("posts.user_id = ? AND posts.deleted_at = 0", authorID)
+
+	return r.listAll(query)
+}
+
+// ListLikedBy retrieves every post liked by the given user, oldest first
+func (r *PostRepository) ListLikedBy(ctx context.Context, userID string) ([]*post.Post, error) {
+	query := r.postQuery(ctx, userID).
+		Where("posts.id IN (SELECT post_id FROM liked_posts WHERE user_id = ?)", userID)
+
+	return r.listAll(query)
+}
+
+// listAll retrieves every post of the given query, oldest first
+func (r *PostRepository) listAll(query *gorm.DB) ([]*post.Post, error) {
+	var rows []postRow
+	err := query.Order("posts.created_at, posts.id").Find(&rows).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list posts")
+	}
+
+	posts := make([]*post.Post, 0, len(rows))
+	for i := range rows {
+		posts = append(posts, rows[i].toDomain())
 	}
 
 	return posts, nil
 }
 
+// Update saves the post's new content and records the previous version as a revision
+func (r *PostRepository) Update(ctx context.Context, p *post.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current postModel
+		if err := tx.First(&current, "id = ?", p.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrPostNotFound
+			}
+			return apperrors.Wrap(err, 500, "failed to get post")
+		}
+
+		revision := &revisionModel{
+			PostID:     current.ID,
+			Content:    current.Content,
+			CreatedAt:  current.UpdatedAt,
+			ReplacedAt: p.UpdatedAt.Unix(),
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to save post revision")
+		}
+
+		err := tx.Model(&postModel{}).
+			Where("id = ?", p.ID).
+			Updates(map[string]interface{}{
+				"content":    p.Content,
+				"updated_at": p.UpdatedAt.Unix(),
+			}).Error
+		if err != nil {
+			return apperrors.Wrap(err, 500, "failed to update post")
+		}
+
+		return nil
+	})
+}
+
+// Delete deletes a post along with its likes and revisions
+func (r *PostRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Foreign keys are not enforced by default in SQLite, so dependents are removed explicitly
+		if err := tx.Where("post_id = ?", id).Delete(&likeModel{}).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to delete post likes")
+		}
+		if err := tx.Where("post_id = ?", id).Delete(&revisionModel{}).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to delete post revisions")
+		}
+
+		result := tx.Where("id = ?", id).Delete(&postModel{})
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, 500, "failed to delete post")
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrPostNotFound
+		}
+
+		return nil
+	})
+}
+
+// Tombstone clears a post's content, likes and revisions but keeps it in place for its replies
+func (r *PostRepository) Tombstone(ctx context.Context, id string, deletedAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", id).Delete(&likeModel{}).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to delete post likes")
+		}
+		if err := tx.Where("post_id = ?", id).Delete(&revisionModel{}).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to delete post revisions")
+		}
+
+		result := tx.Model(&postModel{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"content":    "",
+				"deleted_at": deletedAt.Unix(),
+				"updated_at": deletedAt.Unix(),
+			})
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, 500, "failed to delete post")
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrPostNotFound
+		}
+
+		return nil
+	})
+}
+
+// ListRevisions retrieves the previous versions of a post, newest first
+func (r *PostRepository) ListRevisions(ctx context.Context, postID string) ([]*post.Revision, error) {
+	var models []revisionModel
+	err := r.db.WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("replaced_at DESC, id DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list post revisions")
+	}
+
+	revisions := make([]*post.Revision, 0, len(models))
+	for _, m := range models {
+		revisions = append(revisions, &post.Revision{
+			ID:         m.ID,
+			PostID:     m.PostID,
+			Content:    m.Content,
+			CreatedAt:  time.Unix(m.CreatedAt, 0),
+			ReplacedAt: time.Unix(m.ReplacedAt, 0),
+		})
+	}
+
+	return revisions, nil
+}
+
 // AddLike adds a like to a post
-func (r *PostRepository) AddLike(ctx context.Context, userEmail, postID string) error {
+func (r *PostRepository) AddLike(ctx context.Context, userID, postID string) error {
 	// Check if post exists
 	exists, err := r.Exists(ctx, postID)
 	if err != nil {
@@ -126,13 +387,13 @@ func (r *PostRepository) AddLike(ctx context.Context, userEmail, postID string)
 	}
 
 	like := &likeModel{
-		UserEmail: userEmail,
-		PostID:    postID,
+		UserID: userID,
+		PostID: postID,
 	}
 
 	// Use FirstOrCreate to avoid duplicate likes
 	err = r.db.WithContext(ctx).
-		Where(likeModel{UserEmail: userEmail, PostID: postID}).
+		Where(likeModel{UserID: userID, PostID: postID}).
 		FirstOrCreate(like).Error
 
 	if err != nil {
@@ -143,7 +404,7 @@ func (r *PostRepository) AddLike(ctx context.Context, userEmail, postID string)
 }
 
 // RemoveLike removes a like from a post
-func (r *PostRepository) RemoveLike(ctx context.Context, userEmail, postID string) error {
+func (r *PostRepository) RemoveLike(ctx context.Context, userID, postID string) error {
 	// Check if post exists
 	exists, err := r.Exists(ctx, postID)
 	if err != nil {
@@ -154,7 +415,7 @@ func (r *PostRepository) RemoveLike(ctx context.Context, userEmail, postID strin
 	}
 
 	err = r.db.WithContext(ctx).
-		Where("user_email = ? AND post_id = ?", userEmail, postID).
+		Where("user_id = ? AND post_id = ?", userID, postID).
 		Delete(&likeModel{}).Error
 
 	if err != nil {
@@ -164,12 +425,12 @@ func (r *PostRepository) RemoveLike(ctx context.Context, userEmail, postID strin
 	return nil
 }
 
-// Exists checks if a post exists by ID
+// Exists checks if a post exists by ID and is not a tombstone
 func (r *PostRepository) Exists(ctx context.Context, postID string) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
 		Model(&postModel{}).
-		Where("id = ?", postID).
+		Where("id = ? AND deleted_at = 0", postID).
 		Count(&count).Error
 
 	if err != nil {
@@ -178,3 +439,19 @@ func (r *PostRepository) Exists(ctx context.Context, postID string) (bool, error
 
 	return count > 0, nil
 }
+
+// nullableString converts an empty string to a NULL column value
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// stringValue converts a nullable column value to a string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
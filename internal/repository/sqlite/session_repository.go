@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/session"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository implements session.Repository interface
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create creates a new session along with its first refresh token
+func (r *SessionRepository) Create(ctx context.Context, s *session.Session, token *session.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		model := &sessionModel{
+			ID:            s.ID,
+			UserID:        s.UserID,
+			UserAgent:     s.UserAgent,
+			IPAddress:     s.IPAddress,
+			AccessTokenID: s.AccessTokenID,
+			CreatedAt:     s.CreatedAt.Unix(),
+			LastSeenAt:    s.LastSeenAt.Unix(),
+			ClientID:      s.ClientID,
+			Scopes:        strings.Join(s.Scopes, " "),
+		}
+		if err := tx.Create(model).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to create session")
+		}
+
+		if err := tx.Create(toRefreshTokenModel(token)).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to create refresh token")
+		}
+
+		return nil
+	})
+}
+
+// GetByID retrieves a session by ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*session.Session, error) {
+	var model sessionModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrSessionNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get session")
+	}
+
+	return model.toDomain(), nil
+}
+
+// ListActiveForUser retrieves the non-revoked sessions of a user, most recently seen first
+func (r *SessionRepository) ListActiveForUser(ctx context.Context, userID string) ([]*session.Session, error) {
+	var models []sessionModel
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at = 0", userID).
+		Order("last_seen_at DESC, created_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list sessions")
+	}
+
+	sessions := make([]*session.Session, 0, len(models))
+	for i := range models {
+		sessions = append(sessions, models[i].toDomain())
+	}
+
+	return sessions, nil
+}
+
+// RecordAccess updates the session's last-seen time, client details and latest access token ID
+func (r *SessionRepository) RecordAccess(ctx context.Context, s *session.Session) error {
+	err := r.db.WithContext(ctx).
+		Model(&sessionModel{}).
+		Where("id = ?", s.ID).
+		Updates(map[string]interface{}{
+			"user_agent":      s.UserAgent,
+			"ip_address":      s.IPAddress,
+			"access_token_id": s.AccessTokenID,
+			"last_seen_at":    s.LastSeenAt.Unix(),
+		}).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update session")
+	}
+
+	return nil
+}
+
+// Touch updates the session's last-seen time
+func (r *SessionRepository) Touch(ctx context.Context, id string, seenAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&sessionModel{}).
+		Where("id = ?", id).
+		Update("last_seen_at", seenAt.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update session")
+	}
+
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by the hash of its value
+func (r *SessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*session.RefreshToken, error) {
+	var model refreshTokenModel
+	err := r.db.WithContext(ctx).First(&model, "token_hash = ?", tokenHash).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidRefreshToken
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get refresh token")
+	}
+
+	return &session.RefreshToken{
+		ID:        model.ID,
+		SessionID: model.SessionID,
+		TokenHash: model.TokenHash,
+		CreatedAt: time.Unix(model.CreatedAt, 0),
+		ExpiresAt: time.Unix(model.ExpiresAt, 0),
+		UsedAt:    unixOrZero(model.UsedAt),
+	}, nil
+}
+
+// Rotate marks the old refresh token as used and stores its replacement atomically.
+// It returns ErrRefreshTokenReused if the old token was already used.
+func (r *SessionRepository) Rotate(ctx context.Context, oldTokenID string, newToken *session.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The used_at condition makes concurrent exchanges of the same token fail
+		result := tx.Model(&refreshTokenModel{}).
+			Where("id = ? AND used_at = 0", oldTokenID).
+			Update("used_at", newToken.CreatedAt.Unix())
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, 500, "failed to use refresh token")
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrRefreshTokenReused
+		}
+
+		if err := tx.Create(toRefreshTokenModel(newToken)).Error; err != nil {
+			return apperrors.Wrap(err, 500, "failed to create refresh token")
+		}
+
+		return nil
+	})
+}
+
+// Revoke revokes a session
+func (r *SessionRepository) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&sessionModel{}).
+		Where("id = ? AND revoked_at = 0", id).
+		Update("revoked_at", revokedAt.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to revoke session")
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every active session of a user
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&sessionModel{}).
+		Where("user_id = ? AND revoked_at = 0", userID).
+		Update("revoked_at", revokedAt.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to revoke sessions")
+	}
+
+	return nil
+}
+
+// RevokeAllForClient revokes every active session granted to an OAuth client
+func (r *SessionRepository) RevokeAllForClient(ctx context.Context, clientID string, revokedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&sessionModel{}).
+		Where("client_id = ? AND revoked_at = 0", clientID).
+		Update("revoked_at", revokedAt.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to revoke sessions")
+	}
+
+	return nil
+}
+
+// toDomain maps a session model to the domain model
+func (m *sessionModel) toDomain() *session.Session {
+	return &session.Session{
+		ID:            m.ID,
+		UserID:        m.UserID,
+		UserAgent:     m.UserAgent,
+		IPAddress:     m.IPAddress,
+		AccessTokenID: m.AccessTokenID,
+		CreatedAt:     time.Unix(m.CreatedAt, 0),
+		LastSeenAt:    unixOrZero(m.LastSeenAt),
+		RevokedAt:     unixOrZero(m.RevokedAt),
+		ClientID:      m.ClientID,
+		Scopes:        strings.Fields(m.Scopes),
+	}
+}
+
+// toRefreshTokenModel maps a refresh token domain model to the database model
+func toRefreshTokenModel(t *session.RefreshToken) *refreshTokenModel {
+	return &refreshTokenModel{
+		ID:        t.ID,
+		SessionID: t.SessionID,
+		TokenHash: t.TokenHash,
+		CreatedAt: t.CreatedAt.Unix(),
+		ExpiresAt: t.ExpiresAt.Unix(),
+	}
+}
+
+// unixOrZero converts a unix timestamp to a time, 0 meaning the zero time
+func unixOrZero(ts int64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0)
+}
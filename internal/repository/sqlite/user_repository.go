@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"ynov-social-api/internal/domain/user"
 	"ynov-social-api/internal/pkg/apperrors"
@@ -23,12 +24,24 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
+	handle := strings.ToLower(u.Handle)
 	model := &userModel{
+		ID:           u.ID,
 		Email:        strings.ToLower(u.Email),
 		PasswordHash: u.PasswordHash,
+		Handle:       &handle,
+		DisplayName:  u.DisplayName,
+		Bio:          u.Bio,
+		AvatarURL:    u.AvatarURL,
+		Role:         string(u.Role),
+		InvitedBy:    u.InvitedBy,
+		InviteID:     u.InviteID,
 	}
 
 	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: users.handle") {
+			return apperrors.ErrHandleTaken
+		}
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
 			return apperrors.ErrUserAlreadyExists
 		}
@@ -52,10 +65,472 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 		return nil, apperrors.Wrap(err, 500, "failed to get user")
 	}
 
-	return &user.User{
-		Email:        model.Email,
-		PasswordHash: model.PasswordHash,
-	}, nil
+	return model.toDomain(), nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	var model userModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get user")
+	}
+
+	return model.toDomain(), nil
+}
+
+// GetByHandle retrieves a user by handle
+func (r *UserRepository) GetByHandle(ctx context.Context, handle string) (*user.User, error) {
+	var model userModel
+	err := r.db.WithContext(ctx).
+		Where("handle = ? AND deleted_at = 0", strings.ToLower(handle)).
+		First(&model).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get user")
+	}
+
+	return model.toDomain(), nil
+}
+
+// UpdateProfile saves the user's public profile fields (handle, display name, bio, avatar)
+func (r *UserRepository) UpdateProfile(ctx context.Context, u *user.User) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", u.ID).
+		Updates(map[string]interface{}{
+			"handle":       strings.ToLower(u.Handle),
+			"display_name": u.DisplayName,
+			"bio":          u.Bio,
+			"avatar_url":   u.AvatarURL,
+		}).Error
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return apperrors.ErrHandleTaken
+		}
+		return apperrors.Wrap(err, 500, "failed to update user profile")
+	}
+
+	return nil
+}
+
+// UpdatePassword saves a new password hash for the user
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", id).
+		Update("password_hash", passwordHash).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update password")
+	}
+
+	return nil
+}
+
+// RehashPassword replaces the password hash of the user, unless it was changed meanwhile
+func (r *UserRepository) RehashPassword(ctx context.Context, id, oldHash, newHash string) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ? AND password_hash = ?", id, oldHash).
+		Update("password_hash", newHash).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update password")
+	}
+
+	return nil
+}
+
+// ChangeEmail sets a new email address for the user, unverified until proven
+func (r *UserRepository) ChangeEmail(ctx context.Context, id, newEmail string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&userModel{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"email":          strings.ToLower(newEmail),
+				"email_verified": false,
+			}).Error; err != nil {
+			return err
+		}
+
+		// Links sent to the old address must not give access to the account anymore
+		return tx.Where("user_id = ? AND used_at = 0", id).Delete(&verificationTokenModel{}).Error
+	})
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: users.email") {
+			return apperrors.ErrEmailTaken
+		}
+		return apperrors.Wrap(err, 500, "failed to change email")
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user proved ownership of their email address
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", id).
+		Update("email_verified", true).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to mark email as verified")
+	}
+
+	return nil
+}
+
+// UpdateTwoFactor saves the user's TOTP secret and enabled flag
+func (r *UserRepository) UpdateTwoFactor(ctx context.Context, u *user.User) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", u.ID).
+		Updates(map[string]interface{}{
+			"totp_secret":  u.TOTPSecret,
+			"totp_enabled": u.TOTPEnabled,
+		}).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update two-factor settings")
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code, refusing replayed steps
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	// The guard makes concurrent submissions of the same code fail
+	result := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to record TOTP code")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes replaces the user's recovery codes with the given hashes
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, id string, codeHashes []string) error {
+	now := time.Now().Unix()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&recoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+
+		if len(codeHashes) == 0 {
+			return nil
+		}
+
+		models := make([]recoveryCodeModel, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			models = append(models, recoveryCodeModel{
+				UserID:    id,
+				CodeHash:  hash,
+				CreatedAt: now,
+			})
+		}
+		return tx.Create(&models).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to save recovery codes")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode consumes the unused recovery code with the given hash
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&recoveryCodeModel{}).
+		Where("user_id = ? AND code_hash = ? AND used_at = 0", id, codeHash).
+		Update("used_at", time.Now().Unix())
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to use recovery code")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes counts the user's unused recovery codes
+func (r *UserRepository) CountRecoveryCodes(ctx context.Context, id string) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&recoveryCodeModel{}).
+		Where("user_id = ? AND used_at = 0", id).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, apperrors.Wrap(err, 500, "failed to count recovery codes")
+	}
+
+	return int(count), nil
+}
+
+// UpdateRole saves the user's role
+func (r *UserRepository) UpdateRole(ctx context.Context, id string, role user.Role) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", id).
+		Update("role", string(role)).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update role")
+	}
+
+	return nil
+}
+
+// UpdateSuspension suspends the user's account, or lifts the suspension when suspendedAt is zero
+func (r *UserRepository) UpdateSuspension(ctx context.Context, id string, suspendedAt time.Time) error {
+	var ts int64
+	if !suspendedAt.IsZero() {
+		ts = suspendedAt.Unix()
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", id).
+		Update("suspended_at", ts).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to update suspension")
+	}
+
+	return nil
+}
+
+// CountByRole counts the users having the given role
+func (r *UserRepository) CountByRole(ctx context.Context, role user.Role) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("role = ? AND deleted_at = 0", string(role)).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, apperrors.Wrap(err, 500, "failed to count users by role")
+	}
+
+	return int(count), nil
+}
+
+// ScheduleDeletion records that the user's account is to be purged at the given time
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, id string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ?", id).
+		Update("deletion_scheduled_at", at.Unix()).Error
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to schedule account deletion")
+	}
+
+	return nil
+}
+
+// CancelDeletion cancels the pending deletion of the user's account
+func (r *UserRepository) CancelDeletion(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("id = ? AND deletion_scheduled_at != 0", id).
+		Update("deletion_scheduled_at", 0)
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to cancel account deletion")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ListDueForDeletion retrieves up to limit users whose scheduled deletion time has passed
+func (r *UserRepository) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*user.User, error) {
+	var models []userModel
+	err := r.db.WithContext(ctx).
+		Where("deletion_scheduled_at != 0 AND deletion_scheduled_at <= ?", now.Unix()).
+		Order("deletion_scheduled_at ASC").
+		Limit(limit).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to list accounts due for deletion")
+	}
+
+	users := make([]*user.User, len(models))
+	for i := range models {
+		users[i] = models[i].toDomain()
+	}
+
+	return users, nil
+}
+
+// Purge erases the user's account. Foreign keys are not enforced by default in SQLite,
+// so every table referencing the user is cleaned up explicitly, in a single transaction.
+func (r *UserRepository) Purge(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().Unix()
+
+		// OAuth clients registered by the user, and the sessions other users granted to them
+		clientIDs := tx.Model(&oauthClientModel{}).Select("id").Where("owner_id = ?", id)
+		if err := tx.Model(&sessionModel{}).Where("client_id IN (?) AND revoked_at = 0", clientIDs).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&oauthCodeModel{}, &oauthConsentModel{}} {
+			if err := tx.Where("client_id IN (?)", clientIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("owner_id = ?", id).Delete(&oauthClientModel{}).Error; err != nil {
+			return err
+		}
+
+		// Credentials, sessions and relationships
+		if err := tx.Where("session_id IN (?)", tx.Model(&sessionModel{}).Select("id").Where("user_id = ?", id)).
+			Delete(&refreshTokenModel{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&sessionModel{}, &recoveryCodeModel{}, &verificationTokenModel{}, &accessTokenModel{}, &oauthCodeModel{}, &oauthConsentModel{}, &likeModel{}} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Invites the user created; the accounts registered with them keep the ID of their inviter
+		if err := tx.Where("created_by = ?", id).Delete(&inviteModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("follower_id = ? OR followee_id = ?", id, id).Delete(&followModel{}).Error; err != nil {
+			return err
+		}
+
+		// One-to-one conversations of the user, along with the messages of the other participant.
+		// In group conversations, only the user's messages go away.
+		directIDs := tx.Model(&conversationMemberModel{}).Select("conversation_id").
+			Where("user_id = ? AND conversation_id IN (?)", id, tx.Model(&conversationModel{}).Select("id").Where("direct_key IS NOT NULL"))
+		for _, model := range []interface{}{&messageModel{}, &conversationMemberModel{}} {
+			if err := tx.Where("conversation_id IN (?)", directIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("sender_id = ?", id).Delete(&messageModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&conversationMemberModel{}).Error; err != nil {
+			return err
+		}
+		if err := cleanUpConversations(tx); err != nil {
+			return err
+		}
+
+		// Notifications of the user, and the user's part in other users' notifications
+		if err := tx.Where("notification_id IN (?) OR actor_id = ?", tx.Model(&notificationModel{}).Select("id").Where("user_id = ?", id), id).
+			Delete(&notificationActorModel{}).Error; err != nil {
+			return err
+		}
+		if err := cleanUpNotifications(tx); err != nil {
+			return err
+		}
+
+		// Login tracking and audit entries naming the user's email address
+		email := tx.Model(&userModel{}).Select("email").Where("id = ?", id)
+		if err := tx.Where("key IN (?)", tx.Model(&userModel{}).Select("'account:' || email").Where("id = ?", id)).
+			Delete(&loginAttemptModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject IN (?)", email).Delete(&auditEntryModel{}).Error; err != nil {
+			return err
+		}
+
+		// Delete the user's posts nobody replied to, leaves first so that whole threads of
+		// the user go away. Tombstones left without replies are removed along the way.
+		for {
+			var leafIDs []string
+			if err := tx.Model(&postModel{}).
+				Where("(user_id = ? OR deleted_at != 0) AND NOT EXISTS (SELECT 1 FROM posts AS replies WHERE replies.parent_id = posts.id)", id).
+				Pluck("id", &leafIDs).Error; err != nil {
+				return err
+			}
+			if len(leafIDs) == 0 {
+				break
+			}
+
+			if err := tx.Where("post_id IN ?", leafIDs).Delete(&likeModel{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("post_id IN ?", leafIDs).Delete(&revisionModel{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", leafIDs).Delete(&postModel{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// The remaining posts have replies. Their history goes away; those only kept for the
+		// structure of the conversation become tombstones, those other users replied to keep
+		// their content.
+		if err := tx.Where("post_id IN (?)", tx.Model(&postModel{}).Select("id").Where("user_id = ?", id)).
+			Delete(&revisionModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&postModel{}).
+			Where("user_id = ? AND deleted_at = 0 AND NOT EXISTS (SELECT 1 FROM posts AS replies WHERE replies.parent_id = posts.id AND replies.user_id != ?)", id, id).
+			Updates(map[string]interface{}{"content": "", "deleted_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id IN (?)", tx.Model(&postModel{}).Select("id").Where("user_id = ? AND deleted_at != 0", id)).
+			Delete(&likeModel{}).Error; err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&postModel{}).Where("user_id = ?", id).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return tx.Where("id = ?", id).Delete(&userModel{}).Error
+		}
+
+		// Keep an anonymous account for the remaining posts. The placeholder email and handle are
+		// unique and can never be registered (the handle is longer than allowed).
+		return tx.Model(&userModel{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"email":                 id + "@deleted.invalid",
+				"handle":                "deleted_" + id,
+				"password_hash":         "",
+				"email_verified":        false,
+				"display_name":          "",
+				"bio":                   "",
+				"avatar_url":            "",
+				"totp_secret":           "",
+				"totp_enabled":          false,
+				"role":                  string(user.RoleUser),
+				"suspended_at":          0,
+				"deletion_scheduled_at": 0,
+				"deleted_at":            now,
+			}).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to purge account")
+	}
+
+	return nil
 }
 
 // Exists checks if a user exists by email
@@ -72,3 +547,39 @@ func (r *UserRepository) Exists(ctx context.Context, email string) (bool, error)
 
 	return count > 0, nil
 }
+
+// HandleExists checks if a user exists by handle
+func (r *UserRepository) HandleExists(ctx context.Context, handle string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&userModel{}).
+		Where("handle = ?", strings.ToLower(handle)).
+		Count(&count).Error
+
+	if err != nil {
+		return false, apperrors.Wrap(err, 500, "failed to check handle existence")
+	}
+
+	return count > 0, nil
+}
+
+// toDomain maps a user model to the domain model
+func (m *userModel) toDomain() *user.User {
+	return &user.User{
+		ID:                  m.ID,
+		Email:               m.Email,
+		PasswordHash:        m.PasswordHash,
+		EmailVerified:       m.EmailVerified,
+		Handle:              stringValue(m.Handle),
+		DisplayName:         m.DisplayName,
+		Bio:                 m.Bio,
+		AvatarURL:           m.AvatarURL,
+		TOTPSecret:          m.TOTPSecret,
+		TOTPEnabled:         m.TOTPEnabled,
+		Role:                user.Role(m.Role),
+		SuspendedAt:         unixOrZero(m.SuspendedAt),
+		DeletionScheduledAt: unixOrZero(m.DeletionScheduledAt),
+		InvitedBy:           m.InvitedBy,
+		InviteID:            m.InviteID,
+	}
+}
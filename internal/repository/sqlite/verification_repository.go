@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ynov-social-api/internal/domain/verification"
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// VerificationRepository implements verification.Repository interface
+type VerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewVerificationRepository creates a new VerificationRepository
+func NewVerificationRepository(db *gorm.DB) *VerificationRepository {
+	return &VerificationRepository{db: db}
+}
+
+// Create stores a new token, replacing the user's unused tokens with the same purpose
+func (r *VerificationRepository) Create(ctx context.Context, t *verification.Token) error {
+	model := &verificationTokenModel{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Purpose:   t.Purpose,
+		TokenHash: t.TokenHash,
+		CreatedAt: t.CreatedAt.Unix(),
+		ExpiresAt: t.ExpiresAt.Unix(),
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the latest link sent to the user remains valid
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at = 0", model.UserID, model.Purpose).
+			Delete(&verificationTokenModel{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(model).Error
+	})
+
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to create verification token")
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a token by the hash of its value
+func (r *VerificationRepository) GetByHash(ctx context.Context, tokenHash string) (*verification.Token, error) {
+	var model verificationTokenModel
+	err := r.db.WithContext(ctx).First(&model, "token_hash = ?", tokenHash).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidVerificationToken
+		}
+		return nil, apperrors.Wrap(err, 500, "failed to get verification token")
+	}
+
+	return &verification.Token{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		Purpose:   model.Purpose,
+		TokenHash: model.TokenHash,
+		CreatedAt: time.Unix(model.CreatedAt, 0),
+		ExpiresAt: time.Unix(model.ExpiresAt, 0),
+		UsedAt:    unixOrZero(model.UsedAt),
+	}, nil
+}
+
+// MarkUsed marks a token as used, failing if it already was
+func (r *VerificationRepository) MarkUsed(ctx context.Context, id string) (bool, error) {
+	// The guard makes concurrent uses of the same token fail
+	result := r.db.WithContext(ctx).
+		Model(&verificationTokenModel{}).
+		Where("id = ? AND used_at = 0", id).
+		Update("used_at", time.Now().Unix())
+
+	if result.Error != nil {
+		return false, apperrors.Wrap(result.Error, 500, "failed to use verification token")
+	}
+
+	return result.RowsAffected > 0, nil
+}
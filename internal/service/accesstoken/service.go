@@ -0,0 +1,152 @@
+package accesstoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// maxExpiresInDays is the longest lifetime a token can be given (0 means no expiry)
+const maxExpiresInDays = 365
+
+// lastUsedResolution limits how often a token's last-used time is written on authenticated requests
+const lastUsedResolution = time.Minute
+
+// Service handles personal access tokens
+type Service struct {
+	repo  accesstoken.Repository
+	users user.Repository
+}
+
+// NewService creates a new access token service
+func NewService(repo accesstoken.Repository, users user.Repository) *Service {
+	return &Service{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// Create creates a token granting the given scopes to the user and returns it along with
+// its value, which is only available at this point. An expiresInDays of 0 creates a token
+// that never expires.
+func (s *Service) Create(ctx context.Context, userID, name string, scopes []string, expiresInDays int) (string, *accesstoken.Token, error) {
+	// Validate input
+	name = strings.TrimSpace(name)
+	v := validator.New()
+	v.Required(name, "name")
+	v.MaxLength(name, 100, "name")
+	v.Check(len(scopes) > 0, "scopes", "at least one scope is required")
+	for _, scope := range scopes {
+		v.Check(accesstoken.ValidScope(scope), "scopes", fmt.Sprintf("unknown scope %q, must be one of %s", scope, strings.Join(accesstoken.Scopes, ", ")))
+	}
+	v.Check(expiresInDays >= 0 && expiresInDays <= maxExpiresInDays, "expiresInDays", fmt.Sprintf("must be between 0 (no expiry) and %d", maxExpiresInDays))
+
+	if !v.Valid() {
+		return "", nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate access token ID")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate access token")
+	}
+	rawToken := accesstoken.Prefix + base64.RawURLEncoding.EncodeToString(b)
+
+	ttl := time.Duration(expiresInDays) * 24 * time.Hour
+	t := accesstoken.NewToken(id, userID, name, hashToken(rawToken), normalizeScopes(scopes), ttl)
+	if err := s.repo.Create(ctx, t); err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, t, nil
+}
+
+// List returns the user's tokens, newest first
+func (s *Service) List(ctx context.Context, userID string) ([]*accesstoken.Token, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+// Revoke deletes one of the user's tokens
+func (s *Service) Revoke(ctx context.Context, userID, id string) error {
+	deleted, err := s.repo.Delete(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Tokens of other users are reported as missing
+	if !deleted {
+		return apperrors.ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// Authenticate checks a token presented by a client and returns it along with its owner
+func (s *Service) Authenticate(ctx context.Context, rawToken string) (*accesstoken.Token, *user.User, error) {
+	if !strings.HasPrefix(rawToken, accesstoken.Prefix) {
+		return nil, nil, apperrors.ErrInvalidToken
+	}
+
+	t, err := s.repo.GetByHash(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.IsExpired() {
+		return nil, nil, apperrors.ErrInvalidToken
+	}
+
+	u, err := s.users.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, nil, apperrors.ErrInvalidToken
+	}
+	if u.IsSuspended() {
+		return nil, nil, apperrors.ErrAccountSuspended
+	}
+	// Tokens stop working as soon as the owner asks for their account to be deleted
+	if u.IsDeletionPending() {
+		return nil, nil, apperrors.ErrInvalidToken
+	}
+
+	// Avoid a write on every request
+	if now := time.Now(); now.Sub(t.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.Touch(ctx, t.ID, now); err != nil {
+			return nil, nil, err
+		}
+		t.LastUsedAt = now
+	}
+
+	return t, u, nil
+}
+
+// normalizeScopes removes duplicates and sorts scopes in display order
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range accesstoken.Scopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// hashToken returns the hex-encoded SHA-256 of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
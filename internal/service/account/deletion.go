@@ -0,0 +1,91 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// purgeBatchSize is the number of accounts loaded at once by PurgeDeletedAccounts
+const purgeBatchSize = 100
+
+// RequestDeletion schedules the deletion of the account after checking the current password,
+// and returns when it will be purged. Every session of the user is revoked; logging in again
+// before that time cancels the deletion.
+func (s *Service) RequestDeletion(ctx context.Context, userID, currentPassword, ipAddress string) (time.Time, error) {
+	v := validator.New()
+	v.Required(currentPassword, "currentPassword")
+	if !v.Valid() {
+		return time.Time{}, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	u, err := s.reauthenticate(ctx, userID, currentPassword, ipAddress, true)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	deleteAt := time.Now().Add(s.deletionGracePeriod)
+	if err := s.users.ScheduleDeletion(ctx, u.ID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.sessionService.LogoutAll(ctx, u.ID); err != nil {
+		return time.Time{}, err
+	}
+
+	s.notify(ctx, u.Email, "Suppression de votre compte", fmt.Sprintf("Bonjour @%s,\n\n"+
+		"Votre compte et vos données seront définitivement supprimés le %s.\n"+
+		"Pour annuler la suppression, il suffit de vous reconnecter avant cette date.\n",
+		u.Handle, deleteAt.Format("02/01/2006 à 15:04")))
+
+	return deleteAt, nil
+}
+
+// CancelDeletion cancels the pending deletion of the user's account, if any.
+// It is called when the user logs in during the grace period.
+func (s *Service) CancelDeletion(ctx context.Context, u *user.User) error {
+	if !u.IsDeletionPending() {
+		return nil
+	}
+
+	cancelled, err := s.users.CancelDeletion(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+
+	if cancelled {
+		u.DeletionScheduledAt = time.Time{}
+		s.notify(ctx, u.Email, "Suppression de votre compte annulée", fmt.Sprintf("Bonjour @%s,\n\n"+
+			"Une connexion à votre compte a annulé sa suppression.\n",
+			u.Handle))
+	}
+
+	return nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose deletion grace period is over
+// and returns how many were purged
+func (s *Service) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		users, err := s.users.ListDueForDeletion(ctx, time.Now(), purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, u := range users {
+			if err := s.users.Purge(ctx, u.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
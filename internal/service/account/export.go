@@ -0,0 +1,83 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"ynov-social-api/internal/domain/follow"
+	"ynov-social-api/internal/domain/post"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+)
+
+// exportPageSize is the page size used to load follow lists for an export
+const exportPageSize = 50
+
+// Export holds the personal data of a user
+type Export struct {
+	User      *user.User
+	Posts     []*post.Post                // posts and replies written by the user
+	Revisions map[string][]*post.Revision // previous versions of the user's posts, by post ID
+	Likes     []*post.Post                // posts liked by the user
+	Following []*follow.Follow
+	Followers []*follow.Follow
+	CreatedAt time.Time
+}
+
+// Export gathers the personal data of the user: profile, posts with their history, likes and follows
+func (s *Service) Export(ctx context.Context, userID string) (*Export, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	export := &Export{
+		User:      u,
+		Revisions: make(map[string][]*post.Revision),
+		CreatedAt: time.Now(),
+	}
+
+	if export.Posts, err = s.posts.ListByAuthor(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	for _, p := range export.Posts {
+		revisions, err := s.posts.ListRevisions(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) > 0 {
+			export.Revisions[p.ID] = revisions
+		}
+	}
+
+	if export.Likes, err = s.posts.ListLikedBy(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	if export.Following, err = listAllFollows(ctx, s.follows.ListFollowing, u.ID); err != nil {
+		return nil, err
+	}
+
+	if export.Followers, err = listAllFollows(ctx, s.follows.ListFollowers, u.ID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// listAllFollows loads every page of a follow list
+func listAllFollows(ctx context.Context, list func(ctx context.Context, userID string, page, limit int) ([]*follow.Follow, error), userID string) ([]*follow.Follow, error) {
+	var all []*follow.Follow
+	for page := 1; ; page++ {
+		follows, err := list(ctx, userID, page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, follows...)
+		if len(follows) < exportPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/domain/follow"
+	"ynov-social-api/internal/domain/post"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/domain/verification"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/mailer"
+	"ynov-social-api/internal/pkg/validator"
+	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/lockout"
+	"ynov-social-api/internal/service/session"
+)
+
+// Lifetimes of the tokens sent by email
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+// Service handles account recovery (password reset), email verification, credentials changes,
+// account deletion and data export
+type Service struct {
+	users               user.Repository
+	tokens              verification.Repository
+	accessTokens        accesstoken.Repository
+	posts               post.Repository
+	follows             follow.Repository
+	passwordService     *auth.PasswordService
+	passwordPolicy      *auth.PasswordPolicy
+	sessionService      *session.Service
+	lockoutService      *lockout.Service
+	mailer              mailer.Mailer
+	baseURL             string        // client application URL used to build the links sent by email
+	deletionGracePeriod time.Duration // time during which a deleted account can be restored by logging in
+}
+
+// NewService creates a new account service
+func NewService(users user.Repository, tokens verification.Repository, accessTokens accesstoken.Repository, posts post.Repository, follows follow.Repository, passwordService *auth.PasswordService, passwordPolicy *auth.PasswordPolicy, sessionService *session.Service, lockoutService *lockout.Service, mailer mailer.Mailer, baseURL string, deletionGracePeriod time.Duration) *Service {
+	return &Service{
+		users:               users,
+		tokens:              tokens,
+		accessTokens:        accessTokens,
+		posts:               posts,
+		follows:             follows,
+		passwordService:     passwordService,
+		passwordPolicy:      passwordPolicy,
+		sessionService:      sessionService,
+		lockoutService:      lockoutService,
+		mailer:              mailer,
+		baseURL:             strings.TrimRight(baseURL, "/"),
+		deletionGracePeriod: deletionGracePeriod,
+	}
+}
+
+// RequestPasswordReset emails a password reset link to the user.
+// Unknown addresses are silently ignored so that the endpoint cannot be used to find accounts.
+// Requests are throttled per address and per IP address, known or not.
+func (s *Service) RequestPasswordReset(ctx context.Context, email, ipAddress string) error {
+	v := validator.New()
+	v.Required(email, "email")
+	v.Email(email, "email")
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if err := s.lockoutService.ThrottlePasswordReset(ctx, email, ipAddress); err != nil {
+		return err
+	}
+
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	rawToken, err := s.issue(ctx, u.ID, verification.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Réinitialisation de votre mot de passe",
+		Body: fmt.Sprintf("Bonjour @%s,\n\n"+
+			"Pour choisir un nouveau mot de passe, ouvrez le lien suivant (valable %s) :\n%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez ce message.\n",
+			u.Handle, formatTTL(passwordResetTTL), s.link("reset-password", rawToken)),
+	})
+}
+
+// ResetPassword sets a new password using a reset token, and revokes every session and
+// personal access token of the user
+func (s *Service) ResetPassword(ctx context.Context, rawToken, newPassword string) error {
+	v := validator.New()
+	v.Required(rawToken, "token")
+	v.Required(newPassword, "password")
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	// The token is only used once the password is accepted, so that a refused password does not burn it
+	token, err := s.lookup(ctx, rawToken, verification.PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	u, err := s.users.GetByID(ctx, token.UserID)
+	if err != nil {
+		return apperrors.ErrInvalidVerificationToken
+	}
+
+	if err := s.passwordPolicy.Check(v, newPassword, "password", u.Email, u.Handle, u.DisplayName); err != nil {
+		return err
+	}
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	passwordHash, err := s.passwordService.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.use(ctx, token); err != nil {
+		return err
+	}
+
+	if err := s.users.UpdatePassword(ctx, token.UserID, passwordHash); err != nil {
+		return err
+	}
+
+	// Receiving the reset link proves ownership of the address
+	if err := s.users.MarkEmailVerified(ctx, token.UserID); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in
+	return s.revokeCredentials(ctx, token.UserID)
+}
+
+// ChangePassword sets a new password after checking the current one. Every session and
+// personal access token of the user is revoked and a new session is started for the device
+// making the change.
+func (s *Service) ChangePassword(ctx context.Context, userID, currentPassword, newPassword, userAgent, ipAddress string) (*session.TokenPair, error) {
+	v := validator.New()
+	v.Required(currentPassword, "currentPassword")
+	v.Required(newPassword, "newPassword")
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	// The password hash is replaced below, upgrading it first would be wasted work
+	u, err := s.reauthenticate(ctx, userID, currentPassword, ipAddress, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.passwordPolicy.Check(v, newPassword, "newPassword", u.Email, u.Handle, u.DisplayName); err != nil {
+		return nil, err
+	}
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	passwordHash, err := s.passwordService.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.UpdatePassword(ctx, u.ID, passwordHash); err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeCredentials(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, u.Email, "Votre mot de passe a été modifié", fmt.Sprintf("Bonjour @%s,\n\n"+
+		"Le mot de passe de votre compte vient d'être modifié : vos autres appareils ont été déconnectés et vos tokens d'accès personnels révoqués.\n"+
+		"Si vous n'êtes pas à l'origine de ce changement, réinitialisez votre mot de passe :\n%s\n",
+		u.Handle, s.baseURL+"/forgot-password"))
+
+	return s.sessionService.Start(ctx, u.ID, userAgent, ipAddress)
+}
+
+// ChangeEmail moves the account to a new email address after checking the current password.
+// Posts, likes and follows follow the account; the new address must be verified again.
+// Every session and personal access token of the user is revoked and a new session is started
+// for the device making the change.
+func (s *Service) ChangeEmail(ctx context.Context, userID, currentPassword, newEmail, userAgent, ipAddress string) (*session.TokenPair, error) {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	v := validator.New()
+	v.Required(currentPassword, "currentPassword")
+	v.Required(newEmail, "newEmail")
+	v.Email(newEmail, "newEmail")
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	u, err := s.reauthenticate(ctx, userID, currentPassword, ipAddress, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if newEmail == u.Email {
+		return nil, apperrors.NewValidationError(map[string]string{"newEmail": "must be different from the current email"})
+	}
+
+	exists, err := s.users.Exists(ctx, newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, apperrors.ErrEmailTaken
+	}
+
+	if err := s.users.ChangeEmail(ctx, u.ID, newEmail); err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeCredentials(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	// Warn the previous owner of the address, in case the account was taken over
+	s.notify(ctx, u.Email, "Votre adresse email a été modifiée", fmt.Sprintf("Bonjour @%s,\n\n"+
+		"L'adresse email de votre compte a été remplacée par %s.\n"+
+		"Si vous n'êtes pas à l'origine de ce changement, contactez le support.\n",
+		u.Handle, newEmail))
+
+	if err := s.SendEmailVerification(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	return s.sessionService.Start(ctx, u.ID, userAgent, ipAddress)
+}
+
+// SendEmailVerification emails a verification link to the user
+func (s *Service) SendEmailVerification(ctx context.Context, userID string) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+
+	if u.EmailVerified {
+		return apperrors.ErrEmailAlreadyVerified
+	}
+
+	rawToken, err := s.issue(ctx, u.ID, verification.PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Confirmez votre adresse email",
+		Body: fmt.Sprintf("Bonjour @%s,\n\n"+
+			"Pour confirmer votre adresse email, ouvrez le lien suivant (valable %s) :\n%s\n",
+			u.Handle, formatTTL(emailVerificationTTL), s.link("verify-email", rawToken)),
+	})
+}
+
+// VerifyEmail marks the user's email address as verified using a verification token
+func (s *Service) VerifyEmail(ctx context.Context, rawToken string) error {
+	v := validator.New()
+	v.Required(rawToken, "token")
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	token, err := s.consume(ctx, rawToken, verification.PurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	return s.users.MarkEmailVerified(ctx, token.UserID)
+}
+
+// reauthenticate checks the current password of a logged-in user before a sensitive change.
+// Wrong passwords count as failed logins on the account, so that a stolen access token does
+// not allow guessing the password. With rehash, an outdated password hash is upgraded.
+func (s *Service) reauthenticate(ctx context.Context, userID, password, ipAddress string, rehash bool) (*user.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	if err := s.lockoutService.Check(ctx, u.Email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	if !s.passwordService.VerifyPassword(password, u.PasswordHash) {
+		if err := s.lockoutService.Fail(ctx, u.Email, ipAddress); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.ErrInvalidPassword
+	}
+
+	// Upgrade hashes made with an outdated algorithm or cost while the password is known,
+	// unless the password is about to be replaced
+	if rehash {
+		passwordHash, err := s.passwordService.Rehash(password, u.PasswordHash)
+		if err != nil {
+			return nil, err
+		}
+		if passwordHash != "" {
+			if err := s.users.RehashPassword(ctx, u.ID, u.PasswordHash, passwordHash); err != nil {
+				return nil, err
+			}
+			u.PasswordHash = passwordHash
+		}
+	}
+
+	if err := s.lockoutService.Succeed(ctx, u.Email); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// revokeCredentials revokes every session and personal access token of the user, once their
+// credentials changed
+func (s *Service) revokeCredentials(ctx context.Context, userID string) error {
+	if err := s.accessTokens.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionService.LogoutAll(ctx, userID)
+}
+
+// notify sends a security notice; failures do not undo the change it reports
+func (s *Service) notify(ctx context.Context, email, subject, body string) {
+	_ = s.mailer.Send(ctx, mailer.Message{To: email, Subject: subject, Body: body})
+}
+
+// issue creates a token for the user and returns its raw value, to be sent by email
+func (s *Service) issue(ctx context.Context, userID, purpose string, ttl time.Duration) (string, error) {
+	id, err := ids.New()
+	if err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to generate token ID")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to generate token")
+	}
+	rawToken := base64.RawURLEncoding.EncodeToString(b)
+
+	token := verification.NewToken(id, userID, purpose, hashToken(rawToken), ttl)
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// consume checks a raw token for the given purpose and marks it as used
+func (s *Service) consume(ctx context.Context, rawToken, purpose string) (*verification.Token, error) {
+	token, err := s.lookup(ctx, rawToken, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.use(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// lookup retrieves a valid token for the given purpose without using it
+func (s *Service) lookup(ctx context.Context, rawToken, purpose string) (*verification.Token, error) {
+	token, err := s.tokens.GetByHash(ctx, hashToken(strings.TrimSpace(rawToken)))
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Purpose != purpose || token.IsUsed() || token.IsExpired() {
+		return nil, apperrors.ErrInvalidVerificationToken
+	}
+
+	return token, nil
+}
+
+// use marks a token as used, failing if it was used concurrently
+func (s *Service) use(ctx context.Context, token *verification.Token) error {
+	used, err := s.tokens.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return apperrors.ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+// link builds a link to a page of the client application carrying a token
+func (s *Service) link(page, rawToken string) string {
+	return s.baseURL + "/" + page + "?token=" + url.QueryEscape(rawToken)
+}
+
+// formatTTL formats a token lifetime for emails
+func formatTTL(ttl time.Duration) string {
+	if ttl%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d jours", int(ttl/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%d heure(s)", int(ttl/time.Hour))
+}
+
+// hashToken hashes a token for storage and lookup
+func hashToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
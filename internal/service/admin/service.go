@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/service/post"
+	"ynov-social-api/internal/service/session"
+	userService "ynov-social-api/internal/service/user"
+)
+
+// Service handles moderation (user suspension, post takedown) and role assignment.
+// Permissions are checked by the routes; the service enforces the role hierarchy.
+type Service struct {
+	users          user.Repository
+	userService    *userService.Service
+	postService    *post.Service
+	sessionService *session.Service
+}
+
+// NewService creates a new admin service
+func NewService(users user.Repository, userService *userService.Service, postService *post.Service, sessionService *session.Service) *Service {
+	return &Service{
+		users:          users,
+		userService:    userService,
+		postService:    postService,
+		sessionService: sessionService,
+	}
+}
+
+// SuspendUser suspends the account with the given handle and revokes its sessions.
+// Moderators can only suspend regular users.
+func (s *Service) SuspendUser(ctx context.Context, actorID, handle string) (*user.User, error) {
+	target, err := s.resolveTarget(ctx, actorID, handle, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.IsSuspended() {
+		return target, nil
+	}
+
+	target.SuspendedAt = time.Now()
+	if err := s.users.UpdateSuspension(ctx, target.ID, target.SuspendedAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionService.LogoutAll(ctx, target.ID); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// UnsuspendUser lifts the suspension of the account with the given handle
+func (s *Service) UnsuspendUser(ctx context.Context, actorID, handle string) (*user.User, error) {
+	target, err := s.resolveTarget(ctx, actorID, handle, true)
+	if err != nil {
+		return nil, err
+	}
+
+	target.SuspendedAt = time.Time{}
+	if err := s.users.UpdateSuspension(ctx, target.ID, target.SuspendedAt); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// AssignRole gives a role to the user with the given handle. Their sessions are revoked so
+// that tokens carrying the previous permissions stop working.
+func (s *Service) AssignRole(ctx context.Context, actorID, handle, role string) (*user.User, error) {
+	newRole := user.Role(strings.ToLower(strings.TrimSpace(role)))
+	if !newRole.Valid() {
+		return nil, apperrors.NewValidationError(map[string]string{"role": "must be one of user, moderator, admin"})
+	}
+
+	target, err := s.resolveTarget(ctx, actorID, handle, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Role == newRole {
+		return target, nil
+	}
+
+	target.Role = newRole
+	if err := s.users.UpdateRole(ctx, target.ID, newRole); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionService.LogoutAll(ctx, target.ID); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// TakedownPost removes a post regardless of its author
+func (s *Service) TakedownPost(ctx context.Context, postID string) error {
+	return s.postService.TakedownPost(ctx, postID)
+}
+
+// BootstrapAdmin makes sure an admin exists. When there is none, the account with the given
+// email is promoted, or created with the given password if it does not exist.
+// Returns whether an admin was bootstrapped.
+func (s *Service) BootstrapAdmin(ctx context.Context, email, password string) (bool, error) {
+	count, err := s.users.CountByRole(ctx, user.RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	u, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return false, err
+		}
+		if password == "" {
+			return false, apperrors.New(500, "a password is required to create the admin account")
+		}
+
+		if u, err = s.userService.CreateAccount(ctx, email, password, ""); err != nil {
+			return false, err
+		}
+		// The address comes from the server configuration
+		if err := s.users.MarkEmailVerified(ctx, u.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.users.UpdateRole(ctx, u.ID, user.RoleAdmin); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// resolveTarget loads the user targeted by an action of the actor, refusing actions on oneself
+// and, when outranked is set, on users the actor does not outrank
+func (s *Service) resolveTarget(ctx context.Context, actorID, handle string, outranked bool) (*user.User, error) {
+	target, err := s.userService.GetPublicProfile(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.ID == actorID {
+		return nil, apperrors.ErrCannotModerateSelf
+	}
+
+	if outranked {
+		actor, err := s.users.GetByID(ctx, actorID)
+		if err != nil {
+			return nil, apperrors.ErrUserNotFound
+		}
+		if !actor.Role.Outranks(target.Role) {
+			return nil, apperrors.ErrForbidden
+		}
+	}
+
+	return target, nil
+}
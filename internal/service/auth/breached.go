@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswords checks passwords against an offline copy of a breached password dataset
+// such as Have I Been Pwned's Pwned Passwords. Passwords are looked up by the uppercase hex
+// SHA-1 of their value, in one of the formats produced by the Pwned Passwords downloader:
+//   - a directory of range files named <first 5 hex digits>.txt, each line holding the other
+//     35 digits of a hash and its count (SUFFIX:COUNT), the same layout as the k-anonymity
+//     range API: only the file of the password's prefix is read;
+//   - a single file of full hashes sorted in ascending order (HASH:COUNT), searched by bisection.
+type BreachedPasswords struct {
+	path  string
+	isDir bool
+}
+
+// hashPrefixLength is the length of the hash prefixes naming range files
+const hashPrefixLength = 5
+
+// NewBreachedPasswords opens a breached password dataset
+func NewBreachedPasswords(path string) (*BreachedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password dataset: %w", err)
+	}
+
+	return &BreachedPasswords{path: path, isDir: info.IsDir()}, nil
+}
+
+// Contains reports whether the password appears in the dataset
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if b.isDir {
+		return b.containsInRange(hash[:hashPrefixLength], hash[hashPrefixLength:])
+	}
+	return b.containsInSortedFile(hash)
+}
+
+// containsInRange looks a hash suffix up in the range file of its prefix
+func (b *BreachedPasswords) containsInRange(prefix, suffix string) (bool, error) {
+	f, err := os.Open(filepath.Join(b.path, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if hashOf(scanner.Text()) == suffix {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// containsInSortedFile looks a hash up in a file of sorted hashes, by bisection over byte
+// offsets. lo always is the start of a line, hi the start of a line or the end of the file.
+func (b *BreachedPasswords) containsInSortedFile(hash string) (bool, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, err := lineStart(f, mid, hi)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			// No line starts in [mid, hi): the hash can only be before mid
+			hi = mid
+			continue
+		}
+
+		line, next, err := readLine(f, start)
+		if err != nil {
+			return false, err
+		}
+
+		switch candidate := hashOf(line); {
+		case candidate == hash:
+			return true, nil
+		case candidate < hash:
+			lo = next
+		default:
+			hi = start
+		}
+	}
+
+	return false, nil
+}
+
+// lineStart returns the offset of the first line starting at or after off, or limit if there is none before it
+func lineStart(r io.ReaderAt, off, limit int64) (int64, error) {
+	if off == 0 {
+		return 0, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(r, off-1, limit-off+1))
+	skipped, err := reader.ReadString('\n')
+	if err == io.EOF {
+		return limit, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return off - 1 + int64(len(skipped)), nil
+}
+
+// readLine reads the line starting at off and returns it with the offset of the next line
+func readLine(r io.ReaderAt, off int64) (string, int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, off, 1<<16))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+
+	return line, off + int64(len(line)), nil
+}
+
+// hashOf extracts the uppercase hash from a HASH:COUNT line
+func hashOf(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
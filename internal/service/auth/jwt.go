@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"ynov-social-api/internal/pkg/apperrors"
@@ -9,38 +10,101 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Claims represents JWT claims
+// PurposeTwoFactor marks a challenge token proving the password step of a two-factor login
+const PurposeTwoFactor = "2fa"
+
+// Claims represents JWT claims. The user ID is carried as the standard sub claim.
 type Claims struct {
-	Email string `json:"email"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // permissions granted by the role when the token was issued
+	SessionID   string   `json:"sid,omitempty"`         // session the token was issued for, checked against revocation
+	ClientID    string   `json:"client_id,omitempty"`   // OAuth client the token was issued to, empty for first-party logins
+	Scope       string   `json:"scope,omitempty"`       // space-separated scopes granted to the OAuth client
+	// Purpose is empty for access tokens; other tokens (e.g. two-factor challenges) are only
+	// accepted by the endpoint they were issued for
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // JWTService handles JWT token operations
 type JWTService struct {
-	secret []byte
-	ttl    time.Duration
+	keyring *Keyring
+	ttl     time.Duration
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(secret []byte, ttl time.Duration) *JWTService {
+// NewJWTService creates a new JWT service signing with the keyring's active key
+func NewJWTService(keyring *Keyring, ttl time.Duration) *JWTService {
 	return &JWTService{
-		secret: secret,
-		ttl:    ttl,
+		keyring: keyring,
+		ttl:     ttl,
 	}
 }
 
-// GenerateToken generates a new JWT token for the given email
-func (s *JWTService) GenerateToken(email string) (string, error) {
-	claims := Claims{
-		Email: email,
+// Keyring returns the keys used to sign and verify tokens
+func (s *JWTService) Keyring() *Keyring {
+	return s.keyring
+}
+
+// TTL returns the lifetime of generated tokens
+func (s *JWTService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Subject describes the user an access token is issued to
+type Subject struct {
+	UserID      string
+	Email       string
+	Role        string
+	Permissions []string
+	// ClientID and Scopes are set when the user delegated access to an OAuth client
+	ClientID string
+	Scopes   []string
+}
+
+// GenerateToken generates a new JWT access token for the given user and session.
+// tokenID is embedded as the jti claim.
+func (s *JWTService) GenerateToken(subject Subject, sessionID, tokenID string) (string, error) {
+	return s.sign(Claims{
+		Email:       subject.Email,
+		Role:        subject.Role,
+		Permissions: subject.Permissions,
+		SessionID:   sessionID,
+		ClientID:    subject.ClientID,
+		Scope:       strings.Join(subject.Scopes, " "),
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.UserID,
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
+	})
+}
+
+// GenerateChallengeToken generates a short-lived token proving that the given user passed
+// the password step of a login and must now provide a second factor
+func (s *JWTService) GenerateChallengeToken(userID, email string, ttl time.Duration) (string, error) {
+	return s.sign(Claims{
+		Email:   email,
+		Purpose: PurposeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+}
+
+// sign signs the claims with the active key, recording its ID in the kid header
+func (s *JWTService) sign(claims Claims) (string, error) {
+	key, ok := s.keyring.Active()
+	if !ok {
+		return "", apperrors.New(500, "no active signing key")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.secret)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", apperrors.Wrap(err, 500, "failed to sign token")
 	}
@@ -48,22 +112,60 @@ func (s *JWTService) GenerateToken(email string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the email
-func (s *JWTService) ValidateToken(tokenString string) (string, error) {
+// ValidateToken validates a JWT access token, selecting the verification key by its kid header,
+// and returns its claims
+func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before user IDs were introduced carry no subject
+	if claims.Purpose != "" || claims.Subject == "" {
+		return nil, apperrors.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// ValidateChallengeToken validates a two-factor challenge token and returns its claims
+func (s *JWTService) ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil || claims.Purpose != PurposeTwoFactor || claims.Subject == "" {
+		return nil, apperrors.ErrInvalidChallenge
+	}
+
+	return claims, nil
+}
+
+// parse verifies a token's signature and expiry, whatever its purpose
+func (s *JWTService) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Tokens issued before key IDs were introduced
+			kid = SecretKeyID
+		}
+
+		key, ok := s.keyring.Get(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+
+		// Never let the token pick the algorithm (e.g. HS256 with a public key as secret)
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return s.secret, nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
-		return "", apperrors.ErrInvalidToken
+		return nil, apperrors.ErrInvalidToken
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims.Email, nil
+		return claims, nil
 	}
 
-	return "", apperrors.ErrInvalidToken
+	return nil, apperrors.ErrInvalidToken
 }
@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SecretKeyID is the key ID of the HMAC key derived from the shared secret.
+// Tokens issued before key IDs existed carry no kid and are verified with this key.
+const SecretKeyID = "secret"
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+// Key is a token signing key identified by its kid. Keys loaded from a public key
+// only can verify tokens but cannot sign them.
+type Key struct {
+	ID        string
+	Algorithm string
+	method    jwt.SigningMethod
+	signKey   interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey; nil for verification-only keys
+	verifyKey interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// PublicKey returns the public half of an asymmetric key, or nil for HMAC keys
+func (k *Key) PublicKey() crypto.PublicKey {
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key
+	default:
+		return nil
+	}
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: AlgorithmHS256, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey creates an RS256 signing key
+func NewRSAKey(id string, private *rsa.PrivateKey) *Key {
+	return &Key{ID: id, Algorithm: AlgorithmRS256, method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+}
+
+// NewEd25519Key creates an EdDSA signing key
+func NewEd25519Key(id string, private ed25519.PrivateKey) *Key {
+	return &Key{ID: id, Algorithm: AlgorithmEdDSA, method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}
+}
+
+// NewVerificationKey creates a verification-only key from an RSA or Ed25519 public key
+func NewVerificationKey(id string, public crypto.PublicKey) (*Key, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Algorithm: AlgorithmRS256, method: jwt.SigningMethodRS256, verifyKey: key}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, method: jwt.SigningMethodEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// GenerateKey generates a new signing key for the given asymmetric algorithm
+func GenerateKey(id, algorithm string) (*Key, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(id, private), nil
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewEd25519Key(id, private), nil
+	default:
+		return nil, fmt.Errorf("cannot generate keys for algorithm %q", algorithm)
+	}
+}
+
+// ParseKeyPEM parses a PEM-encoded private key (PKCS#8 or PKCS#1) or public key (PKIX)
+func ParseKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch private := parsed.(type) {
+		case *rsa.PrivateKey:
+			return NewRSAKey(id, private), nil
+		case ed25519.PrivateKey:
+			return NewEd25519Key(id, private), nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(id, private), nil
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewVerificationKey(id, public)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// MarshalPEM encodes the private part of an asymmetric signing key as PKCS#8 PEM
+func (k *Key) MarshalPEM() ([]byte, error) {
+	if !k.CanSign() || k.Algorithm == AlgorithmHS256 {
+		return nil, errors.New("only asymmetric signing keys can be exported")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.signKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Keyring holds the keys used to sign and verify tokens. A single active key signs new
+// tokens while every key of the ring keeps verifying the tokens it signed, so that keys
+// can be rotated without invalidating tokens in flight.
+type Keyring struct {
+	mu       sync.RWMutex
+	keys     map[string]*Key
+	activeID string
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*Key)}
+}
+
+// Add adds (or replaces) a key in the ring without activating it
+func (k *Keyring) Add(key *Key) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[key.ID] = key
+}
+
+// Activate makes the given key the one used to sign new tokens
+func (k *Keyring) Activate(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[id]
+	if !ok {
+		return fmt.Errorf("unknown key %q", id)
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("key %q cannot sign tokens", id)
+	}
+
+	k.activeID = id
+	return nil
+}
+
+// Active returns the key used to sign new tokens
+func (k *Keyring) Active() (*Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[k.activeID]
+	return key, ok
+}
+
+// Get returns the key with the given ID
+func (k *Keyring) Get(id string) (*Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// PublicKeys returns the asymmetric keys of the ring (HMAC keys are never published), sorted by ID
+func (k *Keyring) PublicKeys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.PublicKey() != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// LoadDir adds every *.pem key of a directory to the ring, the file name (without
+// extension) being the key ID. It returns the IDs of the keys able to sign, sorted.
+func (k *Keyring) LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var signing []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(entry.Name(), ".pem")
+		key, err := ParseKeyPEM(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %w", entry.Name(), err)
+		}
+
+		k.Add(key)
+		if key.CanSign() {
+			signing = append(signing, id)
+		}
+	}
+
+	sort.Strings(signing)
+	return signing, nil
+}
+
+// KeyringOptions configures LoadKeyring
+type KeyringOptions struct {
+	Algorithm string // HS256, RS256 or EdDSA
+	Secret    []byte // shared secret, required for HS256 and kept for verification otherwise
+	KeysDir   string // directory of PEM keys for asymmetric algorithms
+	ActiveID  string // kid of the signing key; defaults to the last key ID in lexical order
+}
+
+// LoadKeyring builds the keyring described by the options. For asymmetric algorithms,
+// a key is generated and written to KeysDir when the directory holds no signing key.
+func LoadKeyring(opts KeyringOptions) (*Keyring, error) {
+	k := NewKeyring()
+
+	// The shared secret keeps verifying HS256 tokens issued before a switch to asymmetric keys
+	if len(opts.Secret) > 0 {
+		k.Add(NewHMACKey(SecretKeyID, opts.Secret))
+	}
+
+	if opts.Algorithm == AlgorithmHS256 {
+		if len(opts.Secret) == 0 {
+			return nil, errors.New("HS256 signing requires a secret")
+		}
+		return k, k.Activate(SecretKeyID)
+	}
+
+	signing, err := k.LoadDir(opts.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	activeID := opts.ActiveID
+	if activeID == "" {
+		for _, id := range signing {
+			if key, _ := k.Get(id); key.Algorithm == opts.Algorithm {
+				activeID = id
+			}
+		}
+	}
+
+	// First start: generate and persist a signing key
+	if activeID == "" {
+		key, err := GenerateKey(NewKeyID(), opts.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeKey(opts.KeysDir, key); err != nil {
+			return nil, err
+		}
+		k.Add(key)
+		activeID = key.ID
+	}
+
+	if err := k.Activate(activeID); err != nil {
+		return nil, err
+	}
+	if key, _ := k.Active(); key.Algorithm != opts.Algorithm {
+		return nil, fmt.Errorf("active key %q uses %s, expected %s", activeID, key.Algorithm, opts.Algorithm)
+	}
+
+	return k, nil
+}
+
+// Reload replaces the ring's keys with the ones described by the options, e.g. after a
+// new key was dropped in the keys directory (rotation) or an old one was removed (retirement)
+func (k *Keyring) Reload(opts KeyringOptions) error {
+	fresh, err := LoadKeyring(opts)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = fresh.keys
+	k.activeID = fresh.activeID
+	return nil
+}
+
+// NewKeyID generates a key ID that sorts chronologically (date prefix followed by random bytes)
+func NewKeyID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// writeKey persists a signing key as <dir>/<kid>.pem, readable by the owner only
+func writeKey(dir string, key *Key) error {
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, key.ID+".pem"), data, 0o600)
+}
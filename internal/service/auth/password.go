@@ -1,32 +1,184 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ynov-social-api/internal/pkg/apperrors"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// PasswordService handles password hashing and verification
+// Password hashing algorithms
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	// bcryptMaxLength is the number of bytes of a password bcrypt takes into account
+	bcryptMaxLength = 72
+)
+
+// PasswordOptions configures the algorithm and cost of new password hashes
+type PasswordOptions struct {
+	Algorithm         string // argon2id or bcrypt
+	Argon2Memory      uint32 // memory in KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// PasswordService handles password hashing and verification. Hashes are self-describing
+// (PHC string format for argon2id, modular crypt format for bcrypt), so hashes made with
+// older algorithms or costs keep verifying and can be upgraded with Rehash.
 type PasswordService struct {
-	cost int // bcrypt cost factor
+	options PasswordOptions
 }
 
 // NewPasswordService creates a new password service
-func NewPasswordService() *PasswordService {
+func NewPasswordService(options PasswordOptions) *PasswordService {
 	return &PasswordService{
-		cost: bcrypt.DefaultCost,
+		options: options,
 	}
 }
 
-// HashPassword hashes a password using bcrypt
+// argon2Hash holds the parts of an argon2id PHC string:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type argon2Hash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// HashPassword hashes a password with the configured algorithm
 func (s *PasswordService) HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
-	if err != nil {
-		return "", err
+	if s.options.Algorithm == AlgorithmBcrypt {
+		// bcrypt would silently ignore the end of the password
+		if len(password) > bcryptMaxLength {
+			return "", apperrors.ErrPasswordTooLong
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), s.options.BcryptCost)
+		if err != nil {
+			return "", apperrors.Wrap(err, 500, "failed to hash password")
+		}
+		return string(hash), nil
 	}
-	return string(hash), nil
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to hash password")
+	}
+
+	h := argon2Hash{
+		memory:      s.options.Argon2Memory,
+		iterations:  s.options.Argon2Iterations,
+		parallelism: s.options.Argon2Parallelism,
+		salt:        salt,
+	}
+	h.key = argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, argon2KeyLength)
+
+	return h.String(), nil
 }
 
-// VerifyPassword verifies a password against a bcrypt hash
+// VerifyPassword verifies a password against an argon2id or bcrypt hash
 func (s *PasswordService) VerifyPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	if !strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	h, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// Rehash returns a new hash of a password, verified against hash, when hash was made with an
+// outdated algorithm or cost, and an empty string otherwise. Passwords too long for bcrypt
+// keep their hash.
+func (s *PasswordService) Rehash(password, hash string) (string, error) {
+	if !s.needsRehash(hash) {
+		return "", nil
+	}
+
+	newHash, err := s.HashPassword(password)
+	if errors.Is(err, apperrors.ErrPasswordTooLong) {
+		return "", nil
+	}
+	return newHash, err
+}
+
+// needsRehash reports whether a hash was made with another algorithm or other parameters than
+// the configured ones, and should be replaced the next time the password is known
+func (s *PasswordService) needsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$") {
+		h, err := parseArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		return s.options.Algorithm != AlgorithmArgon2id ||
+			h.memory != s.options.Argon2Memory ||
+			h.iterations != s.options.Argon2Iterations ||
+			h.parallelism != s.options.Argon2Parallelism ||
+			len(h.salt) != argon2SaltLength ||
+			len(h.key) != argon2KeyLength
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		// Not a hash we know how to verify: nothing to upgrade
+		return false
+	}
+	return s.options.Algorithm != AlgorithmBcrypt || cost != s.options.BcryptCost
+}
+
+// String formats the hash as a PHC string
+func (h argon2Hash) String() string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version, h.memory, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(h.salt), base64.RawStdEncoding.EncodeToString(h.key))
+}
+
+// parseArgon2Hash parses an argon2id PHC string
+func parseArgon2Hash(hash string) (*argon2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version: %q", parts[2])
+	}
+
+	var h argon2Hash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if h.memory == 0 || h.iterations == 0 || h.parallelism == 0 {
+		return nil, fmt.Errorf("invalid argon2id parameters: %q", parts[3])
+	}
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, fmt.Errorf("invalid argon2id key")
+	}
+
+	return &h, nil
 }
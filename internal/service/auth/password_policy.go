@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// minContextWordLength is the length from which personal words are refused in passwords:
+// shorter ones are too likely to appear by chance
+const minContextWordLength = 4
+
+// PasswordPolicyOptions configures the passwords users may choose
+type PasswordPolicyOptions struct {
+	MinLength   int
+	MaxLength   int
+	MinEntropy  int                // minimum estimated strength, in bits
+	BannedWords []string           // words refused in every password, e.g. the name of the service
+	Breached    *BreachedPasswords // optional breached password dataset
+}
+
+// PasswordPolicy checks the passwords users choose
+type PasswordPolicy struct {
+	options PasswordPolicyOptions
+}
+
+// NewPasswordPolicy creates a new password policy
+func NewPasswordPolicy(options PasswordPolicyOptions) *PasswordPolicy {
+	banned := make([]string, 0, len(options.BannedWords))
+	for _, word := range options.BannedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			banned = append(banned, word)
+		}
+	}
+	options.BannedWords = banned
+
+	return &PasswordPolicy{
+		options: options,
+	}
+}
+
+// Check adds an error for field to v when the password breaks the policy. contextWords are
+// personal information the password must not contain, typically the user's email address
+// and handle. The returned error is only set when the breached password dataset cannot be read.
+func (p *PasswordPolicy) Check(v *validator.Validator, password, field string, contextWords ...string) error {
+	length := utf8.RuneCountInString(password)
+	switch {
+	case length < p.options.MinLength:
+		v.AddError(field, fmt.Sprintf("must be at least %d characters", p.options.MinLength))
+		return nil
+	case length > p.options.MaxLength:
+		v.AddError(field, fmt.Sprintf("must be at most %d characters", p.options.MaxLength))
+		return nil
+	}
+
+	lowered := strings.ToLower(password)
+	for _, word := range p.options.BannedWords {
+		if strings.Contains(lowered, word) {
+			v.AddError(field, fmt.Sprintf("must not contain %q", word))
+			return nil
+		}
+	}
+	for _, word := range personalWords(contextWords) {
+		if strings.Contains(lowered, word) {
+			v.AddError(field, "must not contain your email address, handle or name")
+			return nil
+		}
+	}
+
+	if entropy(password) < float64(p.options.MinEntropy) {
+		v.AddError(field, "is too easy to guess: use a longer password mixing letters, digits and symbols")
+		return nil
+	}
+
+	if p.options.Breached != nil {
+		breached, err := p.options.Breached.Contains(password)
+		if err != nil {
+			return apperrors.Wrap(err, 500, "failed to check password against breached passwords")
+		}
+		if breached {
+			v.AddError(field, "appears in a known data breach, choose another one")
+		}
+	}
+
+	return nil
+}
+
+// personalWords splits personal information (email addresses, handles, names) into the
+// lowercased words a password must not contain
+func personalWords(values []string) []string {
+	var words []string
+	for _, value := range values {
+		value, _, _ = strings.Cut(strings.ToLower(value), "@")
+
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(parts) > 1 {
+			parts = append(parts, strings.Join(parts, ""))
+		}
+
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minContextWordLength {
+				words = append(words, part)
+			}
+		}
+	}
+	return words
+}
+
+// entropy estimates the strength of a password in bits, from the size of the character
+// classes it uses and its length. Characters repeating the previous one or continuing a
+// sequence (abc, 321) do not count.
+func entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	effectiveLength := 0
+	var previous, delta rune
+
+	for i, r := range []rune(password) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < utf8.RuneSelf:
+			symbol = true
+		default:
+			other = true
+		}
+
+		step := r - previous
+		predictable := i > 0 && (step == 0 || ((step == 1 || step == -1) && step == delta))
+		if !predictable {
+			effectiveLength++
+		}
+		delta, previous = step, r
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effectiveLength) * math.Log2(float64(pool))
+}
@@ -0,0 +1,109 @@
+package follow
+
+import (
+	"context"
+	"errors"
+
+	"ynov-social-api/internal/domain/follow"
+	"ynov-social-api/internal/domain/notification"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	notificationService "ynov-social-api/internal/service/notification"
+)
+
+// Service handles follow graph business logic
+type Service struct {
+	repo          follow.Repository
+	userRepo      user.Repository
+	notifications *notificationService.Service
+}
+
+// NewService creates a new follow service
+func NewService(repo follow.Repository, userRepo user.Repository, notifications *notificationService.Service) *Service {
+	return &Service{
+		repo:          repo,
+		userRepo:      userRepo,
+		notifications: notifications,
+	}
+}
+
+// Follow makes the follower follow the user identified by followee (handle)
+func (s *Service) Follow(ctx context.Context, followerID, followee string) error {
+	followeeID, err := s.resolveUser(ctx, followee)
+	if err != nil {
+		return err
+	}
+
+	if followeeID == followerID {
+		return apperrors.ErrCannotFollowSelf
+	}
+
+	if err := s.repo.Create(ctx, follow.NewFollow(followerID, followeeID)); err != nil {
+		return err
+	}
+
+	// Notifications are best-effort: the follow is recorded even if they fail
+	_ = s.notifications.Notify(ctx, followEvent(followerID, followeeID))
+
+	return nil
+}
+
+// Unfollow makes the follower stop following the user identified by followee (handle)
+func (s *Service) Unfollow(ctx context.Context, followerID, followee string) error {
+	followeeID, err := s.resolveUser(ctx, followee)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+
+	// The followee is not told about a follow that was undone before they saw it
+	_ = s.notifications.Withdraw(ctx, followEvent(followerID, followeeID))
+
+	return nil
+}
+
+// ListFollowers retrieves the users following the given user (handle) with pagination
+func (s *Service) ListFollowers(ctx context.Context, identifier string, page, limit int) ([]*follow.Follow, error) {
+	userID, err := s.resolveUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListFollowers(ctx, userID, page, limit)
+}
+
+// ListFollowing retrieves the users followed by the given user (handle) with pagination
+func (s *Service) ListFollowing(ctx context.Context, identifier string, page, limit int) ([]*follow.Follow, error) {
+	userID, err := s.resolveUser(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListFollowing(ctx, userID, page, limit)
+}
+
+// followEvent describes a follow for the notification of the followee
+func followEvent(followerID, followeeID string) notification.Event {
+	return notification.Event{
+		Type:        notification.TypeFollow,
+		RecipientID: followeeID,
+		ActorID:     followerID,
+	}
+}
+
+// resolveUser finds the ID of the user identified by a handle (with or without @). Users are
+// never looked up by email, which would tell whether an address is registered.
+func (s *Service) resolveUser(ctx context.Context, handle string) (string, error) {
+	u, err := s.userRepo.GetByHandle(ctx, user.NormalizeHandle(handle))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return "", apperrors.ErrUserNotFound
+		}
+		return "", err
+	}
+
+	return u.ID, nil
+}
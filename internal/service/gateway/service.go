@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/message"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/validator"
+	messageService "ynov-social-api/internal/service/message"
+)
+
+// Typing is pushed to the members of a conversation when another member starts or stops typing
+type Typing struct {
+	ConversationID string
+	UserID         string
+	Handle         string
+	Typing         bool
+}
+
+// Presence is pushed to the contacts of a user when their presence changes
+type Presence struct {
+	UserID string
+	Handle string
+	Status string
+}
+
+// Delivery is pushed to the sender of a message when a recipient acknowledges it
+type Delivery struct {
+	MessageID      string
+	ConversationID string
+	UserID         string
+	Handle         string
+	DeliveredAt    time.Time
+}
+
+// Service handles the live connections of users: their presence, typing indicators and
+// message delivery acknowledgements. Presence is only shared with contacts, the users who
+// share at least one conversation.
+type Service struct {
+	hub      *events.Hub
+	messages *messageService.Service
+	userRepo user.Repository
+}
+
+// NewService creates a new gateway service
+func NewService(hub *events.Hub, messages *messageService.Service, userRepo user.Repository) *Service {
+	return &Service{
+		hub:      hub,
+		messages: messages,
+		userRepo: userRepo,
+	}
+}
+
+// Connection is a live connection of a user
+type Connection struct {
+	client *events.Client
+	UserID string
+	Handle string
+}
+
+// Events returns the channel delivering the events meant for the user. It is closed when the
+// server shuts down or the connection was too slow to keep up.
+func (c *Connection) Events() <-chan events.Event {
+	return c.client.Events()
+}
+
+// Connect registers a live connection of the user, online, and returns the presence of their
+// contacts who are connected. It fails with events.ErrHubClosed on shutdown.
+func (s *Service) Connect(ctx context.Context, userID string) (*Connection, []Presence, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, changed, err := s.hub.Register(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := &Connection{client: client, UserID: u.ID, Handle: u.Handle}
+
+	contacts, err := s.messages.ListContacts(ctx, userID)
+	if err != nil {
+		s.hub.Unregister(client)
+		return nil, nil, err
+	}
+
+	presences := []Presence{}
+	for _, contact := range contacts {
+		if status := s.hub.Status(contact.UserID); status != events.StatusOffline {
+			presences = append(presences, Presence{UserID: contact.UserID, Handle: contact.Profile.Handle, Status: status})
+		}
+	}
+
+	if changed {
+		s.broadcastPresence(ctx, conn, events.StatusOnline, contacts)
+	}
+
+	return conn, presences, nil
+}
+
+// Disconnect unregisters a connection once it is closed
+func (s *Service) Disconnect(ctx context.Context, conn *Connection) {
+	if status, changed := s.hub.Unregister(conn.client); changed {
+		s.broadcastPresence(ctx, conn, status, nil)
+	}
+}
+
+// SetPresence changes the presence status of a connection (online or away). The user is
+// online as long as one of their connections is.
+func (s *Service) SetPresence(ctx context.Context, conn *Connection, status string) error {
+	// Validate input
+	status = strings.ToLower(strings.TrimSpace(status))
+	v := validator.New()
+	v.Check(status == events.StatusOnline || status == events.StatusAway, "status", "must be online or away")
+
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	if status, changed := s.hub.SetStatus(conn.client, status); changed {
+		s.broadcastPresence(ctx, conn, status, nil)
+	}
+
+	return nil
+}
+
+// Typing tells the other members of a conversation the user takes part in that they started
+// or stopped typing
+func (s *Service) Typing(ctx context.Context, conn *Connection, conversationID string, typing bool) error {
+	// Validate input
+	v := validator.New()
+	v.Required(conversationID, "conversationId")
+
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	conversation, err := s.messages.GetConversation(ctx, conn.UserID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	event := Typing{
+		ConversationID: conversation.ID,
+		UserID:         conn.UserID,
+		Handle:         conn.Handle,
+		Typing:         typing,
+	}
+	for _, member := range conversation.Members {
+		if member.UserID != conn.UserID {
+			s.hub.Send(member.UserID, events.TypeTyping, event)
+		}
+	}
+
+	return nil
+}
+
+// Ack acknowledges the delivery of a message the user received, which is pushed to its
+// sender. Acknowledging a message the user sent is a no-op, as their other connections
+// receive it too.
+func (s *Service) Ack(ctx context.Context, conn *Connection, messageID string) error {
+	// Validate input
+	v := validator.New()
+	v.Required(messageID, "messageId")
+
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	m, err := s.messages.Get(ctx, conn.UserID, messageID)
+	if err != nil {
+		return err
+	}
+	if m.SenderID == conn.UserID {
+		return nil
+	}
+
+	s.hub.Send(m.SenderID, events.TypeDelivered, Delivery{
+		MessageID:      m.ID,
+		ConversationID: m.ConversationID,
+		UserID:         conn.UserID,
+		Handle:         conn.Handle,
+		DeliveredAt:    time.Now(),
+	})
+
+	return nil
+}
+
+// ShuttingDown reports whether the server is shutting down, which closes every connection
+func (s *Service) ShuttingDown() bool {
+	return s.hub.Closed()
+}
+
+// broadcastPresence pushes the presence of the user of a connection to their contacts,
+// loading them if contacts is nil. Presence is best-effort: it is not pushed if the
+// contacts cannot be loaded.
+func (s *Service) broadcastPresence(ctx context.Context, conn *Connection, status string, contacts []message.Contact) {
+	if contacts == nil {
+		var err error
+		if contacts, err = s.messages.ListContacts(ctx, conn.UserID); err != nil {
+			return
+		}
+	}
+
+	event := Presence{UserID: conn.UserID, Handle: conn.Handle, Status: status}
+	for _, contact := range contacts {
+		s.hub.Send(contact.UserID, events.TypePresence, event)
+	}
+}
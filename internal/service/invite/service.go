@@ -0,0 +1,183 @@
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/invite"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// Invite lifetimes and usage limits
+const (
+	defaultExpiresInDays = 7
+	maxExpiresInDays     = 90
+	maxUsesPerUserInvite = 5    // for users without the invites:manage permission
+	maxUsesPerInvite     = 1000 // for the others
+)
+
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Service handles invite codes. Any user can invite a few people; admins (invites:manage
+// permission) have no quota and can trace the invites of any user.
+type Service struct {
+	repo  invite.Repository
+	users user.Repository
+	quota int // active invites per user without the invites:manage permission
+}
+
+// NewService creates a new invite service
+func NewService(repo invite.Repository, users user.Repository, quota int) *Service {
+	return &Service{
+		repo:  repo,
+		users: users,
+		quota: quota,
+	}
+}
+
+// Create creates an invite usable maxUses times (1 if 0) during expiresInDays days (7 if 0)
+// and returns it along with its code, which is only available at this point
+func (s *Service) Create(ctx context.Context, userID string, maxUses, expiresInDays int) (string, *invite.Invite, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return "", nil, apperrors.ErrUserNotFound
+	}
+	unlimited := slices.Contains(u.Role.Permissions(), user.PermissionManageInvites)
+
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if expiresInDays == 0 {
+		expiresInDays = defaultExpiresInDays
+	}
+
+	// Validate input
+	usesLimit := maxUsesPerUserInvite
+	if unlimited {
+		usesLimit = maxUsesPerInvite
+	}
+	v := validator.New()
+	v.Check(maxUses >= 1 && maxUses <= usesLimit, "maxUses", fmt.Sprintf("must be between 1 and %d", usesLimit))
+	v.Check(expiresInDays >= 1 && expiresInDays <= maxExpiresInDays, "expiresInDays", fmt.Sprintf("must be between 1 and %d", maxExpiresInDays))
+
+	if !v.Valid() {
+		return "", nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	if !unlimited {
+		active, err := s.repo.CountActive(ctx, u.ID, time.Now())
+		if err != nil {
+			return "", nil, err
+		}
+		if active >= s.quota {
+			return "", nil, apperrors.ErrInviteQuotaExceeded
+		}
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate invite ID")
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate invite code")
+	}
+
+	ttl := time.Duration(expiresInDays) * 24 * time.Hour
+	inv := invite.NewInvite(id, u.ID, hashCode(code), maxUses, ttl)
+	if err := s.repo.Create(ctx, inv); err != nil {
+		return "", nil, err
+	}
+
+	return code, inv, nil
+}
+
+// List returns the invites the user created, newest first, with the handles of their invitees
+func (s *Service) List(ctx context.Context, userID string) ([]*invite.Invite, error) {
+	return s.repo.ListByCreator(ctx, userID)
+}
+
+// ListForUser returns the invites created by the user with the given handle, to trace back
+// who brought abusive accounts in (admin)
+func (s *Service) ListForUser(ctx context.Context, handle string) ([]*invite.Invite, error) {
+	u, err := s.users.GetByHandle(ctx, user.NormalizeHandle(handle))
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	return s.repo.ListByCreator(ctx, u.ID)
+}
+
+// Revoke revokes an invite so that it cannot be used anymore. Users can revoke their own
+// invites, holders of the invites:manage permission any invite.
+func (s *Service) Revoke(ctx context.Context, actorID, id string) error {
+	inv, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if inv.CreatedBy != actorID {
+		actor, err := s.users.GetByID(ctx, actorID)
+		if err != nil {
+			return apperrors.ErrUserNotFound
+		}
+		// Invites of other users are reported as missing
+		if !slices.Contains(actor.Role.Permissions(), user.PermissionManageInvites) {
+			return apperrors.ErrInviteNotFound
+		}
+	}
+
+	return s.repo.Revoke(ctx, inv.ID, time.Now())
+}
+
+// Redeem counts a use of the invite with the given code and returns it. Release must be
+// called if the registration then fails. Invites of suspended users cannot be used.
+func (s *Service) Redeem(ctx context.Context, code string) (*invite.Invite, error) {
+	inv, err := s.repo.Redeem(ctx, hashCode(code), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := s.users.GetByID(ctx, inv.CreatedBy)
+	if err != nil || creator.IsSuspended() {
+		if err := s.repo.Release(ctx, inv.ID); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.ErrInvalidInvite
+	}
+
+	return inv, nil
+}
+
+// Release gives back the use of an invite counted by Redeem
+func (s *Service) Release(ctx context.Context, id string) error {
+	return s.repo.Release(ctx, id)
+}
+
+// generateCode generates an invite code (formatted xxxx-xxxx-xxxx-xxxx)
+func generateCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(codeEncoding.EncodeToString(b))
+	return raw[:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:], nil
+}
+
+// hashCode hashes an invite code, ignoring case, spaces and dashes
+func hashCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
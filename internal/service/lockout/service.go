@@ -0,0 +1,184 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/audit"
+	"ynov-social-api/internal/domain/loginattempt"
+	"ynov-social-api/internal/pkg/apperrors"
+)
+
+const (
+	// freeAttempts is the number of failed logins on an account allowed without delay
+	freeAttempts = 3
+	// baseDelay is the delay imposed after the first failure past freeAttempts, doubled for each further one
+	baseDelay = time.Second
+	// maxDelay caps the exponential backoff
+	maxDelay = time.Minute
+
+	// resetAccountRequests is the number of password reset emails an address may request per window
+	resetAccountRequests = 3
+	// resetIPRequests is the number of password reset emails an IP address may request per window
+	resetIPRequests = 10
+)
+
+// Service protects logins against brute force: failures are counted per account and per IP
+// address, with an exponential backoff and then a temporary lockout of the account, and a
+// temporary block of IP addresses trying many accounts. It also throttles password reset
+// requests, which send emails.
+type Service struct {
+	attempts        loginattempt.Repository
+	audit           audit.Repository
+	maxAttempts     int           // failures on an account before it is locked
+	ipMaxAttempts   int           // failures from an IP address before it is blocked
+	lockoutDuration time.Duration // duration of lockouts, also the window over which failures are counted
+}
+
+// NewService creates a new lockout service
+func NewService(attempts loginattempt.Repository, auditRepo audit.Repository, maxAttempts, ipMaxAttempts int, lockoutDuration time.Duration) *Service {
+	return &Service{
+		attempts:        attempts,
+		audit:           auditRepo,
+		maxAttempts:     maxAttempts,
+		ipMaxAttempts:   ipMaxAttempts,
+		lockoutDuration: lockoutDuration,
+	}
+}
+
+// Check refuses a login attempt on the account (normalized email) from the IP address while
+// either is locked out, or while the account is within its backoff delay
+func (s *Service) Check(ctx context.Context, account, ipAddress string) error {
+	now := time.Now()
+
+	if ipAddress != "" {
+		record, err := s.attempts.Get(ctx, ipKey(ipAddress))
+		if err != nil {
+			return err
+		}
+		if record.IsLocked(now) {
+			return apperrors.ErrTooManyAttempts.WithRetryAfter(record.LockedUntil.Sub(now))
+		}
+	}
+
+	record, err := s.attempts.Get(ctx, accountKey(account))
+	if err != nil {
+		return err
+	}
+	if record.IsLocked(now) {
+		return apperrors.ErrAccountLocked.WithRetryAfter(record.LockedUntil.Sub(now))
+	}
+
+	// Failures outside the window are forgotten
+	if record.LastFailure.Before(now.Add(-s.lockoutDuration)) {
+		return nil
+	}
+	if retryAt := record.LastFailure.Add(backoff(record.Failures)); now.Before(retryAt) {
+		return apperrors.ErrTooManyAttempts.WithRetryAfter(retryAt.Sub(now))
+	}
+
+	return nil
+}
+
+// Fail records a failed login attempt. It returns the lockout error when this failure locks
+// the account or blocks the IP address.
+func (s *Service) Fail(ctx context.Context, account, ipAddress string) error {
+	now := time.Now()
+	var lockErr error
+
+	record, err := s.attempts.RecordFailure(ctx, accountKey(account), now, s.lockoutDuration)
+	if err != nil {
+		return err
+	}
+	if record.Failures >= s.maxAttempts {
+		if err := s.lock(ctx, record, now, audit.ActionAccountLocked, account, ipAddress); err != nil {
+			return err
+		}
+		lockErr = apperrors.ErrAccountLocked.WithRetryAfter(s.lockoutDuration)
+	}
+
+	if ipAddress != "" {
+		record, err := s.attempts.RecordFailure(ctx, ipKey(ipAddress), now, s.lockoutDuration)
+		if err != nil {
+			return err
+		}
+		if record.Failures >= s.ipMaxAttempts {
+			if err := s.lock(ctx, record, now, audit.ActionIPBlocked, ipAddress, ipAddress); err != nil {
+				return err
+			}
+			lockErr = apperrors.ErrTooManyAttempts.WithRetryAfter(s.lockoutDuration)
+		}
+	}
+
+	return lockErr
+}
+
+// Succeed forgets the failures on an account after a successful login. Failures from the
+// IP address are kept: a valid login on one account does not vouch for the others tried.
+func (s *Service) Succeed(ctx context.Context, account string) error {
+	return s.attempts.Reset(ctx, accountKey(account))
+}
+
+// ThrottlePasswordReset counts a password reset request for the address (normalized email)
+// from the IP address, and refuses it once either made too many within the window. The
+// requests are tracked apart from login failures, so that they cannot lock the account.
+func (s *Service) ThrottlePasswordReset(ctx context.Context, email, ipAddress string) error {
+	now := time.Now()
+
+	if ipAddress != "" {
+		if err := s.throttle(ctx, "reset:"+ipKey(ipAddress), resetIPRequests, now); err != nil {
+			return err
+		}
+	}
+	return s.throttle(ctx, "reset:"+accountKey(email), resetAccountRequests, now)
+}
+
+// throttle counts a request for a key, refusing it once limit requests were made within the
+// window. Refused requests are not counted, so that the key is released when the window elapses.
+func (s *Service) throttle(ctx context.Context, key string, limit int, now time.Time) error {
+	record, err := s.attempts.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if retryAt := record.LastFailure.Add(s.lockoutDuration); record.Failures >= limit && now.Before(retryAt) {
+		return apperrors.ErrTooManyRequests.WithRetryAfter(retryAt.Sub(now))
+	}
+
+	_, err = s.attempts.RecordFailure(ctx, key, now, s.lockoutDuration)
+	return err
+}
+
+// lock locks a key out and records it in the audit log
+func (s *Service) lock(ctx context.Context, record *loginattempt.Record, now time.Time, action, subject, ipAddress string) error {
+	if err := s.attempts.Lock(ctx, record.Key, now.Add(s.lockoutDuration)); err != nil {
+		return err
+	}
+
+	details := fmt.Sprintf("%d failed login attempts, locked for %s", record.Failures, s.lockoutDuration)
+	return s.audit.Create(ctx, audit.NewEntry(action, subject, ipAddress, details))
+}
+
+// backoff returns the delay imposed after the given number of consecutive failures
+func backoff(failures int) time.Duration {
+	if failures <= freeAttempts {
+		return 0
+	}
+
+	delay := baseDelay
+	for i := freeAttempts + 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// accountKey returns the tracking key of an account
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// ipKey returns the tracking key of an IP address
+func ipKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
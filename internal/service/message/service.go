@@ -0,0 +1,547 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/message"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// Message and conversation limits
+const (
+	maxSubjectLength = 150
+	maxContentLength = 2000
+	maxNameLength    = 100
+	maxGroupMembers  = 50 // creator included
+)
+
+// Service handles messaging business logic. Every read and write checks that the caller
+// takes part in the conversation: users who left a group lose access to its messages.
+type Service struct {
+	repo     message.Repository
+	userRepo user.Repository
+	hub      *events.Hub
+}
+
+// NewService creates a new message service
+func NewService(repo message.Repository, userRepo user.Repository, hub *events.Hub) *Service {
+	return &Service{
+		repo:     repo,
+		userRepo: userRepo,
+		hub:      hub,
+	}
+}
+
+// Send sends a message to the user with the given handle, in the one-to-one conversation
+// the two users share (created with their first message)
+func (s *Service) Send(ctx context.Context, senderID, recipient, subject, content string) (*message.Message, error) {
+	// Validate input
+	recipient = user.NormalizeHandle(recipient)
+	v := validator.New()
+	v.Required(recipient, "recipient")
+	subject, content = validateMessage(v, subject, content)
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	to, err := s.resolveUser(ctx, recipient)
+	if err != nil {
+		return nil, err
+	}
+	if to.ID == senderID {
+		return nil, apperrors.ErrCannotMessageSelf
+	}
+
+	conversationID, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate conversation ID")
+	}
+	conversation, err := s.repo.EnsureDirectConversation(ctx, message.NewDirectConversation(conversationID, senderID, to.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.send(ctx, conversation, senderID, subject, content)
+}
+
+// SendToConversation sends a message in a conversation the sender takes part in
+func (s *Service) SendToConversation(ctx context.Context, senderID, conversationID, subject, content string) (*message.Message, error) {
+	// Validate input
+	v := validator.New()
+	subject, content = validateMessage(v, subject, content)
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	conversation, err := s.GetConversation(ctx, senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.send(ctx, conversation, senderID, subject, content)
+}
+
+// send stores a message in a conversation. Messages of one-to-one conversations are
+// addressed to the other member, who gets a read receipt for each of them.
+func (s *Service) send(ctx context.Context, conversation *message.Conversation, senderID, subject, content string) (*message.Message, error) {
+	var recipientID string
+	if !conversation.IsGroup {
+		for _, member := range conversation.Members {
+			if member.UserID != senderID {
+				recipientID = member.UserID
+			}
+		}
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate message ID")
+	}
+
+	m := message.NewMessage(id, conversation.ID, senderID, recipientID, subject, content)
+	if err := s.repo.Create(ctx, m); err != nil {
+		return nil, err
+	}
+
+	// Reload the message to get the profiles of its participants
+	m, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.push(conversation, m)
+	return m, nil
+}
+
+// push delivers a new message to the connected members of its conversation, the sender
+// included for their other devices
+func (s *Service) push(conversation *message.Conversation, m *message.Message) {
+	for _, member := range conversation.Members {
+		received := *m
+		markUnreadByViewer(conversation, member.UserID, &received)
+		s.hub.Send(member.UserID, events.TypeMessage, &received)
+	}
+}
+
+// CreateGroup creates a group conversation between the creator, who administers it, and
+// the users with the given handles
+func (s *Service) CreateGroup(ctx context.Context, creatorID, name string, participants []string) (*message.Conversation, error) {
+	// Validate input
+	name = strings.TrimSpace(name)
+	v := validator.New()
+	v.MaxLength(name, maxNameLength, "name")
+	v.Check(len(participants) > 0, "participants", "must contain at least one handle")
+	v.Check(len(participants) < maxGroupMembers, "participants", fmt.Sprintf("must contain at most %d handles", maxGroupMembers-1))
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	memberIDs, err := s.resolveMembers(ctx, participants, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) == 0 {
+		return nil, apperrors.NewValidationError(map[string]string{"participants": "must contain at least one other user"})
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate conversation ID")
+	}
+
+	if err := s.repo.CreateGroupConversation(ctx, message.NewGroupConversation(id, creatorID, name, memberIDs)); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetConversation(ctx, id, creatorID)
+}
+
+// Rename changes the name of a group conversation administered by the user
+func (s *Service) Rename(ctx context.Context, userID, conversationID, name string) (*message.Conversation, error) {
+	// Validate input
+	name = strings.TrimSpace(name)
+	v := validator.New()
+	v.MaxLength(name, maxNameLength, "name")
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	if _, err := s.getAdministeredGroup(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Rename(ctx, conversationID, name); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetConversation(ctx, conversationID, userID)
+}
+
+// AddMembers adds the users with the given handles to a group conversation administered by
+// the user. New members can read the whole history, which they start with as read.
+func (s *Service) AddMembers(ctx context.Context, userID, conversationID string, participants []string) (*message.Conversation, error) {
+	// Validate input
+	v := validator.New()
+	v.Check(len(participants) > 0, "participants", "must contain at least one handle")
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	conversation, err := s.getAdministeredGroup(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := s.resolveMembers(ctx, participants, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newMemberIDs []string
+	for _, memberID := range memberIDs {
+		if !conversation.HasMember(memberID) {
+			newMemberIDs = append(newMemberIDs, memberID)
+		}
+	}
+	if len(conversation.Members)+len(newMemberIDs) > maxGroupMembers {
+		return nil, apperrors.NewValidationError(map[string]string{
+			"participants": fmt.Sprintf("a conversation has at most %d members", maxGroupMembers),
+		})
+	}
+
+	if len(newMemberIDs) > 0 {
+		if err := s.repo.AddMembers(ctx, conversationID, newMemberIDs, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetConversation(ctx, conversationID, userID)
+}
+
+// RemoveMember removes the member with the given handle from a group conversation
+// administered by the user. Removing oneself is leaving.
+func (s *Service) RemoveMember(ctx context.Context, userID, conversationID, handle string) error {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.userRepo.GetByHandle(ctx, user.NormalizeHandle(handle))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.ErrNotConversationMember
+		}
+		return err
+	}
+
+	if target.ID == userID {
+		return s.Leave(ctx, userID, conversationID)
+	}
+
+	if !conversation.IsGroup {
+		return apperrors.ErrNotGroupConversation
+	}
+	if !conversation.IsAdmin(userID) {
+		return apperrors.ErrNotConversationAdmin
+	}
+	if !conversation.HasMember(target.ID) {
+		return apperrors.ErrNotConversationMember
+	}
+
+	return s.repo.RemoveMember(ctx, conversationID, target.ID)
+}
+
+// Leave removes the user from a group conversation. When its last admin leaves, the oldest
+// remaining member becomes admin; the conversation is deleted once everybody left.
+func (s *Service) Leave(ctx context.Context, userID, conversationID string) error {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if !conversation.IsGroup {
+		return apperrors.ErrNotGroupConversation
+	}
+
+	return s.repo.RemoveMember(ctx, conversationID, userID)
+}
+
+// ListReceived retrieves the messages received by the user in one-to-one conversations,
+// newest first, optionally filtered on whether they were read
+func (s *Service) ListReceived(ctx context.Context, userID string, status message.ReadStatus, page, limit int) ([]*message.Message, error) {
+	return s.repo.ListReceived(ctx, userID, status, page, limit)
+}
+
+// Get retrieves a message of a conversation the user takes part in
+func (s *Service) Get(ctx context.Context, userID, id string) (*message.Message, error) {
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.IsDirect() {
+		// Messages of other users are reported as missing
+		if m.SenderID != userID && m.RecipientID != userID {
+			return nil, apperrors.ErrMessageNotFound
+		}
+		return m, nil
+	}
+
+	conversation, err := s.GetConversation(ctx, userID, m.ConversationID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrConversationNotFound) {
+			return nil, apperrors.ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	markUnreadByViewer(conversation, userID, m)
+	return m, nil
+}
+
+// MarkRead records that the user read a message they received (no-op if they already had).
+// In group conversations, the user's last-read pointer moves forward to the message.
+func (s *Service) MarkRead(ctx context.Context, userID, id string) (*message.Message, error) {
+	return s.setRead(ctx, userID, id, true)
+}
+
+// MarkUnread marks a message as not read yet, e.g. to come back to it later. In group
+// conversations, the user's last-read pointer moves back to just before the message.
+func (s *Service) MarkUnread(ctx context.Context, userID, id string) (*message.Message, error) {
+	return s.setRead(ctx, userID, id, false)
+}
+
+// setRead changes the read status of a message for the user, who must have received it
+func (s *Service) setRead(ctx context.Context, userID, id string, read bool) (*message.Message, error) {
+	m, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if (m.IsDirect() && m.RecipientID != userID) || (!m.IsDirect() && m.SenderID == userID) {
+		return nil, apperrors.ErrNotMessageRecipient
+	}
+
+	if !m.IsDirect() {
+		if read == !m.UnreadByViewer {
+			return m, nil
+		}
+
+		if read {
+			err = s.repo.MarkConversationRead(ctx, m.ConversationID, userID, m.Seq, time.Now())
+		} else {
+			err = s.repo.SetLastRead(ctx, m.ConversationID, userID, m.Seq-1)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		m.UnreadByViewer = !read
+		return m, nil
+	}
+
+	if read == m.IsRead() {
+		return m, nil
+	}
+
+	var readAt time.Time
+	if read {
+		readAt = time.Now()
+	}
+	if err := s.repo.SetReadAt(ctx, m.ID, readAt); err != nil {
+		return nil, err
+	}
+
+	m.ReadAt = readAt
+	return m, nil
+}
+
+// Delete deletes a message for every participant, which only its sender can do
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	m, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if m.SenderID != userID {
+		return apperrors.ErrNotMessageSender
+	}
+
+	return s.repo.Delete(ctx, m.ID)
+}
+
+// CountUnread counts the messages the user has not read, in all their conversations
+func (s *Service) CountUnread(ctx context.Context, userID string) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+// ListConversations retrieves the conversations of the user, most recently active first
+func (s *Service) ListConversations(ctx context.Context, userID string, page, limit int) ([]*message.Conversation, error) {
+	return s.repo.ListConversations(ctx, userID, page, limit)
+}
+
+// ListContacts retrieves the users who share at least one conversation with the user
+func (s *Service) ListContacts(ctx context.Context, userID string) ([]message.Contact, error) {
+	return s.repo.ListContacts(ctx, userID)
+}
+
+// GetConversation retrieves a conversation the user takes part in
+func (s *Service) GetConversation(ctx context.Context, userID, id string) (*message.Conversation, error) {
+	c, err := s.repo.GetConversation(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conversations of other users are reported as missing
+	if !c.HasMember(userID) {
+		return nil, apperrors.ErrConversationNotFound
+	}
+
+	return c, nil
+}
+
+// ListConversationMessages retrieves the messages of a conversation the user takes part in,
+// newest first. before is the ID of a message from a previous page: only older messages are
+// returned, so that new messages do not shift the pages.
+func (s *Service) ListConversationMessages(ctx context.Context, userID, conversationID, before string, page, limit int) ([]*message.Message, error) {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var beforeSeq int64
+	if before != "" {
+		cursor, err := s.repo.GetByID(ctx, before)
+		if err != nil || cursor.ConversationID != conversationID {
+			return nil, apperrors.ErrMessageNotFound
+		}
+		beforeSeq = cursor.Seq
+	}
+
+	messages, err := s.repo.ListByConversation(ctx, conversationID, beforeSeq, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range messages {
+		markUnreadByViewer(conversation, userID, m)
+	}
+
+	return messages, nil
+}
+
+// MarkConversationRead marks the messages of a conversation the user takes part in as read,
+// up to the message with the given ID (the latest one if empty)
+func (s *Service) MarkConversationRead(ctx context.Context, userID, conversationID, messageID string) (*message.Conversation, error) {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var upToSeq int64
+	switch {
+	case messageID != "":
+		m, err := s.repo.GetByID(ctx, messageID)
+		if err != nil || m.ConversationID != conversationID {
+			return nil, apperrors.ErrMessageNotFound
+		}
+		upToSeq = m.Seq
+	case conversation.LastMessage != nil:
+		upToSeq = conversation.LastMessage.Seq
+	default:
+		return conversation, nil
+	}
+
+	if err := s.repo.MarkConversationRead(ctx, conversationID, userID, upToSeq, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetConversation(ctx, conversationID, userID)
+}
+
+// getAdministeredGroup retrieves a group conversation administered by the user
+func (s *Service) getAdministeredGroup(ctx context.Context, userID, conversationID string) (*message.Conversation, error) {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conversation.IsGroup {
+		return nil, apperrors.ErrNotGroupConversation
+	}
+	if !conversation.IsAdmin(userID) {
+		return nil, apperrors.ErrNotConversationAdmin
+	}
+
+	return conversation, nil
+}
+
+// resolveMembers finds the IDs of the users with the given handles, ignoring duplicates
+// and the acting user
+func (s *Service) resolveMembers(ctx context.Context, handles []string, actorID string) ([]string, error) {
+	seen := map[string]bool{actorID: true}
+	var ids []string
+	for _, handle := range handles {
+		u, err := s.resolveUser(ctx, user.NormalizeHandle(handle))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[u.ID] {
+			seen[u.ID] = true
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
+// resolveUser finds a user who can receive messages by handle
+func (s *Service) resolveUser(ctx context.Context, handle string) (*user.User, error) {
+	u, err := s.userRepo.GetByHandle(ctx, handle)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// Suspended accounts cannot read their messages: they are reported as missing
+	if u.IsSuspended() {
+		return nil, apperrors.ErrUserNotFound
+	}
+
+	return u, nil
+}
+
+// validateMessage trims and validates the subject and content of a message
+func validateMessage(v *validator.Validator, subject, content string) (string, string) {
+	subject = strings.TrimSpace(subject)
+	content = strings.TrimSpace(content)
+	v.MaxLength(subject, maxSubjectLength, "subject")
+	v.Required(content, "content")
+	v.MaxLength(content, maxContentLength, "content")
+	return subject, content
+}
+
+// markUnreadByViewer sets whether a message of a group conversation comes after the
+// viewer's last-read pointer
+func markUnreadByViewer(conversation *message.Conversation, viewerID string, m *message.Message) {
+	if m.IsDirect() {
+		return
+	}
+	if member := conversation.Member(viewerID); member != nil {
+		m.UnreadByViewer = m.SenderID != viewerID && m.Seq > member.LastReadSeq
+	}
+}
@@ -0,0 +1,154 @@
+package notification
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/notification"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// maxMentions is the number of users a single post can notify by mentioning them
+const maxMentions = 10
+
+// mentionRegex matches the mentions of a text (@handle)
+var mentionRegex = regexp.MustCompile(`@([a-zA-Z0-9_]+)`)
+
+// Service handles notification business logic
+type Service struct {
+	repo     notification.Repository
+	userRepo user.Repository
+	broker   *events.Broker
+}
+
+// NewService creates a new notification service
+func NewService(repo notification.Repository, userRepo user.Repository, broker *events.Broker) *Service {
+	return &Service{
+		repo:     repo,
+		userRepo: userRepo,
+		broker:   broker,
+	}
+}
+
+// Notify records an event for its recipient and pushes the resulting notification to the
+// recipient's live streams. Users are not notified of their own actions.
+func (s *Service) Notify(ctx context.Context, event notification.Event) error {
+	if event.RecipientID == "" || event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return apperrors.Wrap(err, 500, "failed to generate notification ID")
+	}
+
+	notificationID, err := s.repo.Record(ctx, id, event, time.Now())
+	if err != nil || notificationID == "" {
+		return err
+	}
+
+	n, err := s.repo.GetByID(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	s.broker.Publish(events.TypeNotification, n.UserID, n)
+
+	return nil
+}
+
+// Withdraw cancels an event (a like or a follow undone) that its recipient has not seen yet
+func (s *Service) Withdraw(ctx context.Context, event notification.Event) error {
+	if event.RecipientID == "" || event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	return s.repo.Withdraw(ctx, event)
+}
+
+// NotifyMentions notifies the users mentioned in the content of a post, except the ones
+// listed in skipIDs (e.g. the author of the post replied to, who is notified of the reply)
+func (s *Service) NotifyMentions(ctx context.Context, authorID, postID, content string, skipIDs ...string) error {
+	for _, handle := range mentionedHandles(content) {
+		u, err := s.userRepo.GetByHandle(ctx, handle)
+		if err != nil {
+			// Mentions of unknown handles are plain text
+			continue
+		}
+		if u.IsSuspended() || slices.Contains(skipIDs, u.ID) {
+			continue
+		}
+
+		err = s.Notify(ctx, notification.Event{
+			Type:        notification.TypeMention,
+			RecipientID: u.ID,
+			ActorID:     authorID,
+			PostID:      postID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List retrieves the notifications of a user, most recent first. Notifications with a
+// Seq lower than before are returned if it is set.
+func (s *Service) List(ctx context.Context, userID string, before int64, page, limit int) ([]*notification.Notification, error) {
+	return s.repo.List(ctx, userID, before, page, limit)
+}
+
+// CountUnread counts the notifications a user has not read
+func (s *Service) CountUnread(ctx context.Context, userID string) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+// MarkRead marks the notifications of a user up to the cursor (the Seq of a notification)
+// as read, or all of them if cursor is 0, and returns the number of notifications left unread
+func (s *Service) MarkRead(ctx context.Context, userID string, cursor int64) (int, error) {
+	v := validator.New()
+	v.Check(cursor >= 0, "cursor", "must be a notification cursor")
+	if !v.Valid() {
+		return 0, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	if err := s.repo.MarkRead(ctx, userID, cursor, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return s.repo.CountUnread(ctx, userID)
+}
+
+// mentionedHandles extracts the handles mentioned in a text, lowercased and without duplicates
+func mentionedHandles(content string) []string {
+	var handles []string
+	for _, match := range mentionRegex.FindAllStringSubmatchIndex(content, -1) {
+		// Email addresses are not mentions
+		if match[0] > 0 && isHandleChar(content[match[0]-1]) {
+			continue
+		}
+
+		handle := strings.ToLower(content[match[2]:match[3]])
+		if len(handle) < 3 || len(handle) > 30 || slices.Contains(handles, handle) {
+			continue
+		}
+
+		handles = append(handles, handle)
+		if len(handles) == maxMentions {
+			break
+		}
+	}
+	return handles
+}
+
+// isHandleChar reports whether a character can be part of a handle
+func isHandleChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,164 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/oauth"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+)
+
+// AuthorizationRequest holds the parameters of an authorization request (RFC 6749 section 4.1.1,
+// RFC 7636 section 4.3)
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string // space-separated, defaults to every scope the client registered
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// AuthorizationPrompt describes what the user is asked to consent to
+type AuthorizationPrompt struct {
+	Client    *oauth.Client
+	Scopes    []string
+	Consented bool // the user already granted these scopes to the client
+}
+
+// PrepareAuthorization checks an authorization request made to the user and returns what the
+// consent screen should display
+func (s *Service) PrepareAuthorization(ctx context.Context, userID string, req AuthorizationRequest) (*AuthorizationPrompt, error) {
+	c, scopes, err := s.checkAuthorization(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	consent, err := s.repo.GetConsent(ctx, userID, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizationPrompt{
+		Client:    c,
+		Scopes:    scopes,
+		Consented: consent != nil && consent.Covers(scopes),
+	}, nil
+}
+
+// Authorize records the user's decision on an authorization request and returns the URI the
+// user agent must be redirected to: with an authorization code if the user approved,
+// with an access_denied error otherwise
+func (s *Service) Authorize(ctx context.Context, userID string, req AuthorizationRequest, approved bool) (string, error) {
+	c, scopes, err := s.checkAuthorization(ctx, &req)
+	if err != nil {
+		return "", err
+	}
+
+	if !approved {
+		return redirectError(req.RedirectURI, req.State, ErrorAccessDenied, "the user denied the request").RedirectURI, nil
+	}
+
+	// Remember the consent, so that the next requests for these scopes can be approved at once
+	consent, err := s.repo.GetConsent(ctx, userID, c.ID)
+	if err != nil {
+		return "", err
+	}
+	if consent == nil {
+		consent = &oauth.Consent{UserID: userID, ClientID: c.ID}
+	}
+	consent.Scopes = normalizeScopes(append(consent.Scopes, scopes...))
+	consent.UpdatedAt = time.Now()
+	if err := s.repo.SaveConsent(ctx, consent); err != nil {
+		return "", err
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to generate authorization code ID")
+	}
+	rawCode, err := generateSecret()
+	if err != nil {
+		return "", apperrors.Wrap(err, 500, "failed to generate authorization code")
+	}
+
+	code := oauth.NewAuthorizationCode(id, hashSecret(rawCode), c.ID, userID, req.RedirectURI, scopes, req.CodeChallenge, req.CodeChallengeMethod, s.codeTTL)
+	if err := s.repo.CreateCode(ctx, code); err != nil {
+		return "", err
+	}
+
+	return withQuery(req.RedirectURI, url.Values{
+		"code":  {rawCode},
+		"state": {req.State},
+	}), nil
+}
+
+// checkAuthorization validates an authorization request and returns the client and the
+// requested scopes. Errors are only reported through the redirect URI once it is known to
+// belong to the client. req.RedirectURI is filled in when the client has a single one.
+func (s *Service) checkAuthorization(ctx context.Context, req *AuthorizationRequest) (*oauth.Client, []string, error) {
+	if req.ClientID == "" {
+		return nil, nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "client_id is required")
+	}
+
+	c, err := s.repo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrOAuthClientNotFound) {
+			return nil, nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "unknown client_id")
+		}
+		return nil, nil, err
+	}
+
+	if req.RedirectURI == "" && len(c.RedirectURIs) == 1 {
+		req.RedirectURI = c.RedirectURIs[0]
+	}
+	if !c.HasRedirectURI(req.RedirectURI) {
+		return nil, nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "redirect_uri does not match a registered redirect URI")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, redirectError(req.RedirectURI, req.State, ErrorUnsupportedResponseType, "response_type must be code")
+	}
+
+	// PKCE is required from every client, confidential or not
+	if req.CodeChallengeMethod != oauth.CodeChallengeMethodS256 {
+		return nil, nil, redirectError(req.RedirectURI, req.State, ErrorInvalidRequest, "code_challenge_method must be S256")
+	}
+	if len(req.CodeChallenge) != 43 || !isUnreserved(req.CodeChallenge) {
+		return nil, nil, redirectError(req.RedirectURI, req.State, ErrorInvalidRequest, "code_challenge must be the base64url-encoded SHA-256 of the code verifier")
+	}
+
+	scopes := c.Scopes
+	if req.Scope != "" {
+		requested := strings.Fields(req.Scope)
+		for _, scope := range requested {
+			if !slices.Contains(c.Scopes, scope) {
+				return nil, nil, redirectError(req.RedirectURI, req.State, ErrorInvalidScope, "scope "+scope+" is not available to this client")
+			}
+		}
+		scopes = normalizeScopes(requested)
+	}
+
+	return c, scopes, nil
+}
+
+// isUnreserved reports whether s only contains the unreserved characters PKCE values are made of
+// (RFC 7636 section 4.1)
+func isUnreserved(s string) bool {
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"ynov-social-api/internal/pkg/apperrors"
+)
+
+// Error codes defined by RFC 6749
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+)
+
+// Error is an OAuth 2.0 error, returned in the format of RFC 6749 rather than as an apperrors.AppError
+type Error struct {
+	Status      int // HTTP status of the response
+	Code        string
+	Description string
+	// RedirectURI is set on authorization errors the client must be told about through its
+	// redirect URI, once the redirect URI itself has been checked
+	RedirectURI string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// newError creates a new Error
+func newError(status int, code, description string) *Error {
+	return &Error{Status: status, Code: code, Description: description}
+}
+
+// redirectError creates an authorization error reported to the client through redirectURI
+func redirectError(redirectURI, state, code, description string) *Error {
+	return &Error{
+		Status:      http.StatusBadRequest,
+		Code:        code,
+		Description: description,
+		RedirectURI: withQuery(redirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {state},
+		}),
+	}
+}
+
+// grantError converts a client error of the session service (e.g. a revoked session or a
+// suspended account) to an invalid_grant error
+func grantError(err error) error {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && isClientError(err) {
+		return newError(http.StatusBadRequest, ErrorInvalidGrant, appErr.Message)
+	}
+	return err
+}
+
+// isClientError reports whether err is an expected error about the presented token or
+// account rather than an internal failure
+func isClientError(err error) bool {
+	var appErr *apperrors.AppError
+	return errors.As(err, &appErr) && appErr.Code < http.StatusInternalServerError
+}
+
+// withQuery adds params to the query string of uri, dropping empty values
+func withQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,159 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/accesstoken"
+	"ynov-social-api/internal/domain/oauth"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/pkg/validator"
+	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/session"
+)
+
+// maxRedirectURIs is the number of redirect URIs a client can register
+const maxRedirectURIs = 10
+
+// Service implements the OAuth 2.0 authorization server: client registration, the authorization
+// code flow with PKCE, token introspection and revocation.
+// Tokens are issued as sessions granted to the client, restricted to the scopes of personal
+// access tokens, so that users can see and revoke them like any other session.
+type Service struct {
+	repo           oauth.Repository
+	sessionService *session.Service
+	jwtService     *auth.JWTService
+	codeTTL        time.Duration
+}
+
+// NewService creates a new OAuth service
+func NewService(repo oauth.Repository, sessionService *session.Service, jwtService *auth.JWTService, codeTTL time.Duration) *Service {
+	return &Service{
+		repo:           repo,
+		sessionService: sessionService,
+		jwtService:     jwtService,
+		codeTTL:        codeTTL,
+	}
+}
+
+// RegisterClient registers a client owned by the user and returns it along with its secret,
+// which is only available at this point. Public clients (confidential false) get no secret.
+func (s *Service) RegisterClient(ctx context.Context, ownerID, name string, redirectURIs, scopes []string, confidential bool) (string, *oauth.Client, error) {
+	// Validate input
+	name = strings.TrimSpace(name)
+	v := validator.New()
+	v.Required(name, "name")
+	v.MaxLength(name, 100, "name")
+	v.Check(len(redirectURIs) > 0, "redirectUris", "at least one redirect URI is required")
+	v.Check(len(redirectURIs) <= maxRedirectURIs, "redirectUris", fmt.Sprintf("at most %d redirect URIs can be registered", maxRedirectURIs))
+	for _, uri := range redirectURIs {
+		v.Check(validRedirectURI(uri), "redirectUris", fmt.Sprintf("%q must be an https URL (or http on localhost) without fragment", uri))
+	}
+	v.Check(len(scopes) > 0, "scopes", "at least one scope is required")
+	for _, scope := range scopes {
+		v.Check(accesstoken.ValidScope(scope), "scopes", fmt.Sprintf("unknown scope %q, must be one of %s", scope, strings.Join(accesstoken.Scopes, ", ")))
+	}
+
+	if !v.Valid() {
+		return "", nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate client ID")
+	}
+
+	var secret, secretHash string
+	if confidential {
+		secret, err = generateSecret()
+		if err != nil {
+			return "", nil, apperrors.Wrap(err, 500, "failed to generate client secret")
+		}
+		secretHash = hashSecret(secret)
+	}
+
+	c := oauth.NewClient(id, ownerID, name, secretHash, redirectURIs, normalizeScopes(scopes))
+	if err := s.repo.CreateClient(ctx, c); err != nil {
+		return "", nil, err
+	}
+
+	return secret, c, nil
+}
+
+// ListClients returns the clients registered by the user, newest first
+func (s *Service) ListClients(ctx context.Context, ownerID string) ([]*oauth.Client, error) {
+	return s.repo.ListClientsByOwner(ctx, ownerID)
+}
+
+// DeleteClient deletes one of the user's clients and revokes the sessions granted to it
+func (s *Service) DeleteClient(ctx context.Context, ownerID, clientID string) error {
+	c, err := s.repo.GetClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	// Clients of other users are reported as missing
+	if c.OwnerID != ownerID {
+		return apperrors.ErrOAuthClientNotFound
+	}
+
+	if err := s.repo.DeleteClient(ctx, c.ID); err != nil {
+		return err
+	}
+
+	return s.sessionService.RevokeClientSessions(ctx, c.ID)
+}
+
+// validRedirectURI reports whether uri can be registered as a redirect URI: an absolute https
+// URL without fragment, http being allowed for loopback addresses during development
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.Fragment != "" || strings.ContainsAny(uri, " \t\n") {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return false
+	}
+}
+
+// normalizeScopes removes duplicates and sorts scopes in display order
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range accesstoken.Scopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// generateSecret generates a random client secret, authorization code or similar credential
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret returns the hex-encoded SHA-256 of a client secret or authorization code
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/oauth"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/service/session"
+)
+
+// Grant types supported by the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// Token types reported by introspection
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// ClientCredentials identifies the client calling the token, introspection or revocation endpoint.
+// Public clients only send their ID.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// TokenRequest holds the parameters of a token request (RFC 6749 sections 4.1.3 and 6)
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	UserAgent    string
+	IPAddress    string
+}
+
+// Introspection describes a token presented to the introspection endpoint (RFC 7662)
+type Introspection struct {
+	Active    bool
+	TokenType string
+	Scopes    []string
+	ClientID  string // empty for tokens of first-party logins
+	UserID    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Token handles a token request: it exchanges an authorization code or a refresh token for a
+// new token pair
+func (s *Service) Token(ctx context.Context, creds ClientCredentials, req TokenRequest) (*session.TokenPair, error) {
+	c, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, c, req)
+	case GrantTypeRefreshToken:
+		if req.RefreshToken == "" {
+			return nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "refresh_token is required")
+		}
+		tokens, err := s.sessionService.RefreshForClient(ctx, req.RefreshToken, c.ID, req.UserAgent, req.IPAddress)
+		if err != nil {
+			return nil, grantError(err)
+		}
+		return tokens, nil
+	case "":
+		return nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "grant_type is required")
+	default:
+		return nil, newError(http.StatusBadRequest, ErrorUnsupportedGrantType, "grant_type must be authorization_code or refresh_token")
+	}
+}
+
+// Introspect describes an access or refresh token to a confidential client, e.g. an API gateway
+func (s *Service) Introspect(ctx context.Context, creds ClientCredentials, rawToken string) (*Introspection, error) {
+	c, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsConfidential() {
+		return nil, newError(http.StatusUnauthorized, ErrorInvalidClient, "public clients cannot introspect tokens")
+	}
+
+	if claims, err := s.jwtService.ValidateToken(rawToken); err == nil {
+		if err := s.sessionService.ValidateSession(ctx, claims.SessionID); err != nil {
+			return &Introspection{}, nil
+		}
+		return &Introspection{
+			Active:    true,
+			TokenType: TokenTypeAccessToken,
+			Scopes:    strings.Fields(claims.Scope),
+			ClientID:  claims.ClientID,
+			UserID:    claims.Subject,
+			IssuedAt:  claims.IssuedAt.Time,
+			ExpiresAt: claims.ExpiresAt.Time,
+		}, nil
+	}
+
+	sess, token, err := s.sessionService.LookupRefreshToken(ctx, rawToken)
+	if err != nil {
+		if isClientError(err) {
+			return &Introspection{}, nil
+		}
+		return nil, err
+	}
+
+	return &Introspection{
+		Active:    true,
+		TokenType: TokenTypeRefreshToken,
+		Scopes:    sess.Scopes,
+		ClientID:  sess.ClientID,
+		UserID:    sess.UserID,
+		IssuedAt:  token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// Revoke revokes the session an access or refresh token issued to the client belongs to
+// (RFC 7009). Unknown tokens and tokens of other clients are ignored.
+func (s *Service) Revoke(ctx context.Context, creds ClientCredentials, rawToken string) error {
+	c, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	if claims, err := s.jwtService.ValidateToken(rawToken); err == nil {
+		if claims.ClientID != c.ID {
+			return nil
+		}
+		return s.sessionService.Logout(ctx, claims.SessionID)
+	}
+
+	sess, _, err := s.sessionService.LookupRefreshToken(ctx, rawToken)
+	if err != nil {
+		if isClientError(err) {
+			return nil
+		}
+		return err
+	}
+	if sess.ClientID != c.ID {
+		return nil
+	}
+
+	return s.sessionService.Logout(ctx, sess.ID)
+}
+
+// exchangeCode exchanges an authorization code for the first token pair of a session granted to the client
+func (s *Service) exchangeCode(ctx context.Context, c *oauth.Client, req TokenRequest) (*session.TokenPair, error) {
+	if req.Code == "" {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidRequest, "code is required")
+	}
+
+	code, err := s.repo.GetCodeByHash(ctx, hashSecret(req.Code))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "invalid authorization code")
+		}
+		return nil, err
+	}
+	if code.ClientID != c.ID {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "invalid authorization code")
+	}
+
+	// A code showing up again means it leaked: revoke the tokens it was exchanged for
+	if code.IsUsed() {
+		if code.SessionID != "" {
+			if err := s.sessionService.Logout(ctx, code.SessionID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "authorization code already used")
+	}
+
+	if code.IsExpired() {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "authorization code expired")
+	}
+	if req.RedirectURI != code.RedirectURI {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "code_verifier does not match the code challenge")
+	}
+
+	used, err := s.repo.UseCode(ctx, code.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "authorization code already used")
+	}
+
+	tokens, err := s.sessionService.StartForClient(ctx, code.UserID, c.ID, code.Scopes, req.UserAgent, req.IPAddress)
+	if err != nil {
+		return nil, grantError(err)
+	}
+
+	if err := s.repo.SetCodeSession(ctx, code.ID, tokens.SessionID); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// authenticateClient checks the credentials of the client calling an endpoint.
+// Confidential clients must present their secret.
+func (s *Service) authenticateClient(ctx context.Context, creds ClientCredentials) (*oauth.Client, error) {
+	if creds.ClientID == "" {
+		return nil, newError(http.StatusUnauthorized, ErrorInvalidClient, "client authentication required")
+	}
+
+	c, err := s.repo.GetClient(ctx, creds.ClientID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrOAuthClientNotFound) {
+			return nil, newError(http.StatusUnauthorized, ErrorInvalidClient, "invalid client credentials")
+		}
+		return nil, err
+	}
+
+	if c.IsConfidential() {
+		if subtle.ConstantTimeCompare([]byte(hashSecret(creds.ClientSecret)), []byte(c.SecretHash)) != 1 {
+			return nil, newError(http.StatusUnauthorized, ErrorInvalidClient, "invalid client credentials")
+		}
+	} else if creds.ClientSecret != "" {
+		return nil, newError(http.StatusUnauthorized, ErrorInvalidClient, "public clients have no secret")
+	}
+
+	return c, nil
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against the S256 challenge of the authorization request
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 || !isUnreserved(verifier) {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -2,30 +2,41 @@ package post
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"strings"
 	"time"
 
+	"ynov-social-api/internal/domain/notification"
 	"ynov-social-api/internal/domain/post"
+	"ynov-social-api/internal/domain/user"
 	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/events"
+	"ynov-social-api/internal/pkg/ids"
 	"ynov-social-api/internal/pkg/validator"
+	notificationService "ynov-social-api/internal/service/notification"
 )
 
 // Service handles post business logic
 type Service struct {
-	repo post.Repository
+	repo                 post.Repository
+	userRepo             user.Repository
+	notifications        *notificationService.Service
+	broker               *events.Broker
+	requireVerifiedEmail bool // only users with a verified email address may publish
 }
 
 // NewService creates a new post service
-func NewService(repo post.Repository) *Service {
+func NewService(repo post.Repository, userRepo user.Repository, notifications *notificationService.Service, broker *events.Broker, requireVerifiedEmail bool) *Service {
 	return &Service{
-		repo: repo,
+		repo:                 repo,
+		userRepo:             userRepo,
+		notifications:        notifications,
+		broker:               broker,
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
 // CreatePost creates a new post
-func (s *Service) CreatePost(ctx context.Context, author, content string) (*post.Post, error) {
+func (s *Service) CreatePost(ctx context.Context, authorID, content string) (*post.Post, error) {
 	// Validate input
 	content = strings.TrimSpace(content)
 	v := validator.New()
@@ -36,68 +47,390 @@ func (s *Service) CreatePost(ctx context.Context, author, content string) (*post
 		return nil, apperrors.NewValidationError(v.GetErrors())
 	}
 
+	if err := s.checkCanPublish(ctx, authorID); err != nil {
+		return nil, err
+	}
+
 	// Generate unique ID
-	id, err := generateID()
+	id, err := ids.New()
 	if err != nil {
 		return nil, apperrors.Wrap(err, 500, "failed to generate post ID")
 	}
 
 	// Create post
-	p := post.NewPost(id, author, content)
+	p := post.NewPost(id, authorID, content)
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+
+	// Notifications are best-effort: the post is published even if they fail
+	_ = s.notifications.NotifyMentions(ctx, authorID, id, content)
+
+	return s.loadPublished(ctx, id, authorID)
+}
+
+// Thread pagination defaults and bounds
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	// threadFanOut is the number of replies loaded per post below the root's direct replies
+	threadFanOut = 3
+	// maxThreadNodes bounds the number of replies loaded by a thread request
+	maxThreadNodes = 200
+	// maxThreadAncestors bounds the number of ancestors loaded above the root
+	maxThreadAncestors = 100
+)
+
+// CreateReply creates a new post replying to the given parent post
+func (s *Service) CreateReply(ctx context.Context, authorID, parentID, content string) (*post.Post, error) {
+	// Validate input
+	content = strings.TrimSpace(content)
+	v := validator.New()
+	v.Required(content, "content")
+	v.MaxLength(content, 400, "content")
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	if err := s.checkCanPublish(ctx, authorID); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.GetPost(ctx, authorID, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate unique ID
+	id, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate post ID")
+	}
+
+	// Create reply
+	p := post.NewReply(id, authorID, content, parent)
 	if err := s.repo.Create(ctx, p); err != nil {
 		return nil, err
 	}
 
-	// Set likes count to 0 for new post
-	p.LikesCount = 0
+	// Notifications are best-effort: the reply is published even if they fail.
+	// The author of the parent post is notified of the reply rather than of a mention.
+	_ = s.notifications.Notify(ctx, notification.Event{
+		Type:        notification.TypeReply,
+		RecipientID: parent.AuthorID,
+		ActorID:     authorID,
+		PostID:      parent.ID,
+	})
+	_ = s.notifications.NotifyMentions(ctx, authorID, id, content, parent.AuthorID)
+
+	return s.loadPublished(ctx, id, authorID)
+}
+
+// loadPublished reloads a new post to get its computed fields (likes count, author profile),
+// and pushes it to the live streams
+func (s *Service) loadPublished(ctx context.Context, id, authorID string) (*post.Post, error) {
+	p, err := s.repo.GetByID(ctx, id, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.broker.Publish(events.TypePost, "", p)
+
+	return p, nil
+}
+
+// GetPost retrieves a single post as seen by the given viewer
+func (s *Service) GetPost(ctx context.Context, viewerID, postID string) (*post.Post, error) {
+	p, err := s.repo.GetByID(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tombstones are only visible inside threads
+	if p.Deleted {
+		return nil, apperrors.ErrPostNotFound
+	}
+
 	return p, nil
 }
 
-// ListPosts retrieves posts with pagination
-func (s *Service) ListPosts(ctx context.Context, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
+// GetThread retrieves the conversation tree rooted at a post, as seen by the given viewer.
+// Direct replies of the root are paginated with page/limit; deeper levels load the first few
+// replies of each post down to depth levels, within a total number of replies, flagging
+// posts with unloaded replies.
+func (s *Service) GetThread(ctx context.Context, viewerID, postID string, depth, page, limit int) (*post.Thread, error) {
+	if depth <= 0 {
+		depth = defaultThreadDepth
+	}
+	if depth > maxThreadDepth {
+		depth = maxThreadDepth
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	root, err := s.repo.GetByID(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk up to the conversation's top-level post
+	ancestors := []*post.Post{}
+	if root.ParentID != "" {
+		ancestors, err = s.repo.ListAncestors(ctx, root.ID, viewerID, maxThreadAncestors)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rootNode := &post.ThreadNode{Post: root}
+	level := []*post.ThreadNode{rootNode}
+	offset := (page - 1) * limit
+	budget := maxThreadNodes
+
+	// Load the tree one level at a time
+	for d := 0; d < depth && len(level) > 0; d++ {
+		// Only the root's replies are paginated, deeper posts load their first few replies
+		perParent := threadFanOut
+		if d == 0 {
+			perParent = limit
+		}
+
+		// Posts left out once the budget is spent keep their replies unloaded
+		byID := make(map[string]*post.ThreadNode, len(level))
+		parentIDs := make([]string, 0, len(level))
+		for _, node := range level {
+			if node.Post.RepliesCount == 0 {
+				continue
+			}
+			if (len(parentIDs)+1)*perParent > budget {
+				break
+			}
+			byID[node.Post.ID] = node
+			parentIDs = append(parentIDs, node.Post.ID)
+		}
+
+		replies, err := s.repo.ListReplies(ctx, viewerID, parentIDs, offset, perParent)
+		if err != nil {
+			return nil, err
+		}
+		budget -= len(replies)
+
+		next := make([]*post.ThreadNode, 0, len(replies))
+		for _, reply := range replies {
+			child := &post.ThreadNode{Post: reply}
+			parent := byID[reply.ParentID]
+			parent.Replies = append(parent.Replies, child)
+			next = append(next, child)
+		}
+
+		for _, node := range level {
+			node.HasMoreReplies = offset+len(node.Replies) < node.Post.RepliesCount
+		}
+
+		offset = 0
+		level = next
+	}
+
+	// Posts below the depth limit have unloaded replies
+	for _, node := range level {
+		node.HasMoreReplies = node.Post.RepliesCount > 0
+	}
+
+	return &post.Thread{Ancestors: ancestors, Root: rootNode}, nil
+}
+
+// ListPosts retrieves posts with pagination as seen by the given viewer
+func (s *Service) ListPosts(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
+	// If no beforeTimestamp provided, use current time + 1
+	if beforeTimestamp <= 0 {
+		beforeTimestamp = time.Now().Unix() + 1
+	}
+
+	return s.repo.ListBefore(ctx, viewerID, beforeTimestamp, page, limit)
+}
+
+// GetTimeline retrieves posts from the viewer and the users they follow with pagination
+func (s *Service) GetTimeline(ctx context.Context, viewerID string, beforeTimestamp int64, page, limit int) ([]*post.Post, error) {
 	// If no beforeTimestamp provided, use current time + 1
 	if beforeTimestamp <= 0 {
 		beforeTimestamp = time.Now().Unix() + 1
 	}
 
-	return s.repo.ListBefore(ctx, beforeTimestamp, page, limit)
+	return s.repo.ListTimeline(ctx, viewerID, beforeTimestamp, page, limit)
+}
+
+// UpdatePost edits the content of a post owned by the given user
+func (s *Service) UpdatePost(ctx context.Context, userID, postID, content string) (*post.Post, error) {
+	// Validate input
+	content = strings.TrimSpace(content)
+	v := validator.New()
+	v.Required(content, "content")
+	v.MaxLength(content, 400, "content")
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	p, err := s.GetPost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the author can edit a post
+	if p.AuthorID != userID {
+		return nil, apperrors.ErrNotPostAuthor
+	}
+
+	// Nothing to record if the content did not change
+	if p.Content == content {
+		return p, nil
+	}
+
+	p.Content = content
+	p.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	// Users mentioned by the edit are notified (the ones already mentioned are not notified again)
+	_ = s.notifications.NotifyMentions(ctx, userID, p.ID, content)
+
+	return p, nil
+}
+
+// DeletePost deletes a post owned by the given user.
+// A post with replies is replaced by a tombstone so that the conversation stays intact.
+func (s *Service) DeletePost(ctx context.Context, userID, postID string) error {
+	p, err := s.GetPost(ctx, userID, postID)
+	if err != nil {
+		return err
+	}
+
+	// Only the author can delete a post
+	if p.AuthorID != userID {
+		return apperrors.ErrNotPostAuthor
+	}
+
+	return s.removePost(ctx, p)
+}
+
+// TakedownPost removes a post regardless of its author, for moderation
+func (s *Service) TakedownPost(ctx context.Context, postID string) error {
+	p, err := s.GetPost(ctx, "", postID)
+	if err != nil {
+		return err
+	}
+
+	return s.removePost(ctx, p)
+}
+
+// removePost tombstones a post that has replies, or deletes it and then
+// cleans up ancestor tombstones left without any reply
+func (s *Service) removePost(ctx context.Context, p *post.Post) error {
+	if p.RepliesCount > 0 {
+		return s.repo.Tombstone(ctx, p.ID, time.Now())
+	}
+
+	if err := s.repo.Delete(ctx, p.ID); err != nil {
+		return err
+	}
+
+	for parentID := p.ParentID; parentID != ""; {
+		parent, err := s.repo.GetByID(ctx, parentID, "")
+		if err != nil {
+			return err
+		}
+		if !parent.Deleted || parent.RepliesCount > 0 {
+			break
+		}
+		if err := s.repo.Delete(ctx, parent.ID); err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+// GetPostHistory retrieves the previous versions of a post, newest first
+func (s *Service) GetPostHistory(ctx context.Context, postID string) ([]*post.Revision, error) {
+	exists, err := s.repo.Exists(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apperrors.ErrPostNotFound
+	}
+
+	return s.repo.ListRevisions(ctx, postID)
 }
 
 // LikePost adds a like to a post and returns the updated likes count
-func (s *Service) LikePost(ctx context.Context, userEmail, postID string) (int, error) {
-	if err := s.repo.AddLike(ctx, userEmail, postID); err != nil {
+func (s *Service) LikePost(ctx context.Context, userID, postID string) (int, error) {
+	if err := s.repo.AddLike(ctx, userID, postID); err != nil {
 		return 0, err
 	}
 
 	// Retrieve the post to get the updated likes count
-	p, err := s.repo.GetByID(ctx, postID)
+	p, err := s.repo.GetByID(ctx, postID, userID)
 	if err != nil {
 		return 0, err
 	}
 
+	// Notifications are best-effort: the like is recorded even if they fail
+	_ = s.notifications.Notify(ctx, likeEvent(userID, p))
+	s.broker.Publish(events.TypeLikes, "", post.LikesChange{PostID: p.ID, LikesCount: p.LikesCount})
+
 	return p.LikesCount, nil
 }
 
 // UnlikePost removes a like from a post and returns the updated likes count
-func (s *Service) UnlikePost(ctx context.Context, userEmail, postID string) (int, error) {
-	if err := s.repo.RemoveLike(ctx, userEmail, postID); err != nil {
+func (s *Service) UnlikePost(ctx context.Context, userID, postID string) (int, error) {
+	if err := s.repo.RemoveLike(ctx, userID, postID); err != nil {
 		return 0, err
 	}
 
 	// Retrieve the post to get the updated likes count
-	p, err := s.repo.GetByID(ctx, postID)
+	p, err := s.repo.GetByID(ctx, postID, userID)
 	if err != nil {
 		return 0, err
 	}
 
+	// The author is not told about a like that was undone before they saw it
+	_ = s.notifications.Withdraw(ctx, likeEvent(userID, p))
+	s.broker.Publish(events.TypeLikes, "", post.LikesChange{PostID: p.ID, LikesCount: p.LikesCount})
+
 	return p.LikesCount, nil
 }
 
-// generateID generates a unique ID for a post
-func generateID() (string, error) {
-	b := make([]byte, 12)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// likeEvent describes a like of a post for the notification of its author
+func likeEvent(userID string, p *post.Post) notification.Event {
+	return notification.Event{
+		Type:        notification.TypeLike,
+		RecipientID: p.AuthorID,
+		ActorID:     userID,
+		PostID:      p.ID,
 	}
-	return hex.EncodeToString(b), nil
+}
+
+// checkCanPublish checks that the author may publish posts and replies
+func (s *Service) checkCanPublish(ctx context.Context, authorID string) error {
+	if !s.requireVerifiedEmail {
+		return nil
+	}
+
+	u, err := s.userRepo.GetByID(ctx, authorID)
+	if err != nil {
+		return apperrors.ErrUserNotFound
+	}
+	if !u.EmailVerified {
+		return apperrors.ErrEmailNotVerified
+	}
+
+	return nil
 }
@@ -0,0 +1,322 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/session"
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
+	"ynov-social-api/internal/service/auth"
+)
+
+// TokenPair holds the credentials returned to a client for a session
+type TokenPair struct {
+	SessionID    string
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // lifetime of the access token
+	Scopes       []string      // scopes granted to the OAuth client, nil for logins
+}
+
+// lastSeenResolution limits how often a session's last-seen time is written on authenticated requests
+const lastSeenResolution = time.Minute
+
+// Service handles login sessions, refresh token rotation and logout
+type Service struct {
+	repo       session.Repository
+	userRepo   user.Repository
+	jwtService *auth.JWTService
+	refreshTTL time.Duration
+}
+
+// NewService creates a new session service
+func NewService(repo session.Repository, userRepo user.Repository, jwtService *auth.JWTService, refreshTTL time.Duration) *Service {
+	return &Service{
+		repo:       repo,
+		userRepo:   userRepo,
+		jwtService: jwtService,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Start opens a new session for an authenticated user on the device described by
+// userAgent and ipAddress, and issues its first token pair
+func (s *Service) Start(ctx context.Context, userID, userAgent, ipAddress string) (*TokenPair, error) {
+	return s.start(ctx, userID, "", nil, userAgent, ipAddress)
+}
+
+// StartForClient opens a new session the user granted to an OAuth client, whose tokens are
+// restricted to the given scopes
+func (s *Service) StartForClient(ctx context.Context, userID, clientID string, scopes []string, userAgent, ipAddress string) (*TokenPair, error) {
+	return s.start(ctx, userID, clientID, scopes, userAgent, ipAddress)
+}
+
+// start opens a new session and issues its first token pair
+func (s *Service) start(ctx context.Context, userID, clientID string, scopes []string, userAgent, ipAddress string) (*TokenPair, error) {
+	sessionID, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate session ID")
+	}
+
+	rawToken, token, err := s.newRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := session.NewSession(sessionID, userID, userAgent, ipAddress)
+	sess.ClientID = clientID
+	sess.Scopes = scopes
+	tokens, err := s.issue(ctx, sess, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, sess, token); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the refresh token.
+// Presenting an already used refresh token revokes the whole session.
+func (s *Service) Refresh(ctx context.Context, rawToken, userAgent, ipAddress string) (*TokenPair, error) {
+	return s.refresh(ctx, rawToken, "", userAgent, ipAddress)
+}
+
+// RefreshForClient is Refresh for the sessions granted to an OAuth client
+func (s *Service) RefreshForClient(ctx context.Context, rawToken, clientID, userAgent, ipAddress string) (*TokenPair, error) {
+	return s.refresh(ctx, rawToken, clientID, userAgent, ipAddress)
+}
+
+// refresh rotates a refresh token of a session belonging to clientID (empty for logins)
+func (s *Service) refresh(ctx context.Context, rawToken, clientID, userAgent, ipAddress string) (*TokenPair, error) {
+	rawToken = strings.TrimSpace(rawToken)
+	if rawToken == "" {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	current, err := s.repo.GetRefreshToken(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+
+	// A used token showing up again means it leaked: kill the whole family
+	if current.IsUsed() {
+		if err := s.repo.Revoke(ctx, current.SessionID, time.Now()); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.ErrRefreshTokenReused
+	}
+
+	if current.IsExpired() {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	sess, err := s.repo.GetByID(ctx, current.SessionID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+	if sess.IsRevoked() {
+		return nil, apperrors.ErrSessionRevoked
+	}
+	// Refresh tokens only work for the client they were issued to
+	if sess.ClientID != clientID {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	newRawToken, newToken, err := s.newRefreshToken(sess.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Rotate(ctx, current.ID, newToken); err != nil {
+		// Lost a race against another exchange of the same token
+		if errors.Is(err, apperrors.ErrRefreshTokenReused) {
+			if revokeErr := s.repo.Revoke(ctx, sess.ID, time.Now()); revokeErr != nil {
+				return nil, revokeErr
+			}
+		}
+		return nil, err
+	}
+
+	tokens, err := s.issue(ctx, sess, newRawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep track of the device currently holding the session
+	sess.UserAgent = userAgent
+	sess.IPAddress = ipAddress
+	sess.LastSeenAt = time.Now()
+	if err := s.repo.RecordAccess(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// ValidateSession checks that a session exists and has not been revoked,
+// and records the session as recently seen
+func (s *Service) ValidateSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return apperrors.ErrInvalidToken
+	}
+
+	sess, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return apperrors.ErrInvalidToken
+		}
+		return err
+	}
+	if sess.IsRevoked() {
+		return apperrors.ErrSessionRevoked
+	}
+
+	// Avoid a write on every request
+	if now := time.Now(); now.Sub(sess.LastSeenAt) >= lastSeenResolution {
+		if err := s.repo.Touch(ctx, sess.ID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListSessions retrieves the active sessions of a user
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]*session.Session, error) {
+	return s.repo.ListActiveForUser(ctx, userID)
+}
+
+// RevokeSession revokes one of the user's sessions, e.g. a stolen device
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	sess, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Do not reveal sessions of other users
+	if sess.UserID != userID || sess.IsRevoked() {
+		return apperrors.ErrSessionNotFound
+	}
+
+	return s.repo.Revoke(ctx, sess.ID, time.Now())
+}
+
+// LookupRefreshToken returns the active session a refresh token belongs to, along with the
+// token, without exchanging it
+func (s *Service) LookupRefreshToken(ctx context.Context, rawToken string) (*session.Session, *session.RefreshToken, error) {
+	token, err := s.repo.GetRefreshToken(ctx, hashToken(strings.TrimSpace(rawToken)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.IsUsed() || token.IsExpired() {
+		return nil, nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	sess, err := s.repo.GetByID(ctx, token.SessionID)
+	if err != nil {
+		return nil, nil, apperrors.ErrInvalidRefreshToken
+	}
+	if sess.IsRevoked() {
+		return nil, nil, apperrors.ErrSessionRevoked
+	}
+
+	return sess, token, nil
+}
+
+// Logout revokes a single session
+func (s *Service) Logout(ctx context.Context, sessionID string) error {
+	return s.repo.Revoke(ctx, sessionID, time.Now())
+}
+
+// LogoutAll revokes every session of a user
+func (s *Service) LogoutAll(ctx context.Context, userID string) error {
+	return s.repo.RevokeAllForUser(ctx, userID, time.Now())
+}
+
+// RevokeClientSessions revokes every session granted to an OAuth client
+func (s *Service) RevokeClientSessions(ctx context.Context, clientID string) error {
+	return s.repo.RevokeAllForClient(ctx, clientID, time.Now())
+}
+
+// issue generates the access token of a session, records its jti on the session
+// and pairs it with a refresh token
+func (s *Service) issue(ctx context.Context, sess *session.Session, rawRefreshToken string) (*TokenPair, error) {
+	// The access token carries the current email address and role along with the user ID
+	u, err := s.userRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	// Suspended users keep their sessions revoked
+	if u.IsSuspended() {
+		return nil, apperrors.ErrAccountSuspended
+	}
+
+	tokenID, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate token ID")
+	}
+
+	subject := auth.Subject{
+		UserID: u.ID,
+		Email:  u.Email,
+		Role:   string(u.Role),
+	}
+	// Role permissions are not delegated to OAuth clients
+	if sess.IsDelegated() {
+		subject.ClientID = sess.ClientID
+		subject.Scopes = sess.Scopes
+	} else {
+		subject.Permissions = u.Role.Permissions()
+	}
+
+	accessToken, err := s.jwtService.GenerateToken(subject, sess.ID, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	sess.AccessTokenID = tokenID
+
+	return &TokenPair{
+		SessionID:    sess.ID,
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    s.jwtService.TTL(),
+		Scopes:       sess.Scopes,
+	}, nil
+}
+
+// newRefreshToken generates an opaque refresh token and its stored (hashed) representation
+func (s *Service) newRefreshToken(sessionID string) (string, *session.RefreshToken, error) {
+	id, err := ids.New()
+	if err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate refresh token ID")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, apperrors.Wrap(err, 500, "failed to generate refresh token")
+	}
+	rawToken := base64.RawURLEncoding.EncodeToString(b)
+
+	return rawToken, session.NewRefreshToken(id, sessionID, hashToken(rawToken), s.refreshTTL), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 of an opaque token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
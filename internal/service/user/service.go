@@ -2,88 +2,326 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"strings"
+	"time"
 
 	"ynov-social-api/internal/domain/user"
 	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/ids"
 	"ynov-social-api/internal/pkg/validator"
 	"ynov-social-api/internal/service/auth"
+	"ynov-social-api/internal/service/invite"
+	"ynov-social-api/internal/service/lockout"
+)
+
+// Registration modes
+const (
+	RegistrationOpen   = "open"   // anyone can sign up, an invite code is optional
+	RegistrationInvite = "invite" // signing up requires an invite code
+	RegistrationClosed = "closed" // nobody can sign up
 )
 
 // Service handles user business logic
 type Service struct {
 	repo            user.Repository
 	passwordService *auth.PasswordService
+	passwordPolicy  *auth.PasswordPolicy
+	totpService     *auth.TOTPService
+	jwtService      *auth.JWTService
+	lockoutService  *lockout.Service
+	inviteService   *invite.Service
+	challengeTTL    time.Duration // lifetime of two-factor login challenges
+	registration    string        // registration mode: open, invite or closed
 }
 
 // NewService creates a new user service
-func NewService(repo user.Repository, passwordService *auth.PasswordService) *Service {
+func NewService(repo user.Repository, passwordService *auth.PasswordService, passwordPolicy *auth.PasswordPolicy, totpService *auth.TOTPService, jwtService *auth.JWTService, lockoutService *lockout.Service, inviteService *invite.Service, challengeTTL time.Duration, registration string) *Service {
 	return &Service{
 		repo:            repo,
 		passwordService: passwordService,
+		passwordPolicy:  passwordPolicy,
+		totpService:     totpService,
+		jwtService:      jwtService,
+		lockoutService:  lockoutService,
+		inviteService:   inviteService,
+		challengeTTL:    challengeTTL,
+		registration:    registration,
 	}
 }
 
-// Register registers a new user
-func (s *Service) Register(ctx context.Context, email, password string) error {
+// Register signs a new user up according to the registration mode and returns it. The handle
+// is optional: a random one is generated when empty. The invite code is required in invite
+// mode; when given in open mode, the invite is recorded too.
+func (s *Service) Register(ctx context.Context, email, password, handle, inviteCode string) (*user.User, error) {
+	if s.registration == RegistrationClosed {
+		return nil, apperrors.ErrRegistrationClosed
+	}
+
+	return s.register(ctx, email, password, handle, strings.TrimSpace(inviteCode), s.registration == RegistrationInvite)
+}
+
+// CreateAccount creates an account regardless of the registration mode, e.g. for the
+// bootstrap admin
+func (s *Service) CreateAccount(ctx context.Context, email, password, handle string) (*user.User, error) {
+	return s.register(ctx, email, password, handle, "", false)
+}
+
+// register validates and creates a new account, redeeming the invite code if any
+func (s *Service) register(ctx context.Context, email, password, handle, inviteCode string, requireInvite bool) (*user.User, error) {
 	// Validate input
+	handle = user.NormalizeHandle(handle)
 	v := validator.New()
 	v.Required(email, "email")
 	v.Email(email, "email")
 	v.Required(password, "password")
-	v.MinLength(password, 6, "password")
+	if handle != "" {
+		v.Handle(handle, "handle")
+	}
+	if requireInvite {
+		v.Required(inviteCode, "inviteCode")
+	}
+	if err := s.passwordPolicy.Check(v, password, "password", email, handle); err != nil {
+		return nil, err
+	}
 
 	if !v.Valid() {
-		return apperrors.NewValidationError(v.GetErrors())
+		return nil, apperrors.NewValidationError(v.GetErrors())
 	}
 
 	// Normalize email
 	email = strings.ToLower(strings.TrimSpace(email))
 
+	// Pick or check the handle
+	if handle == "" {
+		generated, err := s.generateHandle(ctx)
+		if err != nil {
+			return nil, err
+		}
+		handle = generated
+	} else {
+		taken, err := s.repo.HandleExists(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, apperrors.ErrHandleTaken
+		}
+	}
+
 	// Check if user already exists
 	exists, err := s.repo.Exists(ctx, email)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if exists {
-		return apperrors.ErrUserAlreadyExists
+		return nil, apperrors.ErrUserAlreadyExists
 	}
 
-	// Hash password (bcrypt automatically generates and embeds salt)
+	// Hash password (the hash embeds its salt and parameters)
 	passwordHash, err := s.passwordService.HashPassword(password)
 	if err != nil {
-		return apperrors.Wrap(err, 500, "failed to hash password")
+		return nil, err
+	}
+
+	id, err := ids.New()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate user ID")
+	}
+
+	u := user.NewUser(id, email, handle, passwordHash)
+
+	// Redeem the invite last, so that its use is only counted for a registration that goes through
+	if inviteCode != "" {
+		inv, err := s.inviteService.Redeem(ctx, inviteCode)
+		if err != nil {
+			return nil, err
+		}
+		u.InvitedBy = inv.CreatedBy
+		u.InviteID = inv.ID
 	}
 
 	// Create user
-	u := user.NewUser(email, passwordHash)
-	return s.repo.Create(ctx, u)
+	if err := s.repo.Create(ctx, u); err != nil {
+		if u.InviteID != "" {
+			if releaseErr := s.inviteService.Release(ctx, u.InviteID); releaseErr != nil {
+				return nil, releaseErr
+			}
+		}
+		return nil, err
+	}
+
+	return u, nil
 }
 
-// Authenticate authenticates a user and returns their email
-func (s *Service) Authenticate(ctx context.Context, email, password string) (string, error) {
+// Authenticate checks a user's password and returns the user.
+// When the user has two-factor authentication enabled, the login must be completed with
+// IssueTwoFactorChallenge and CompleteTwoFactorLogin.
+// Failed attempts are counted per account and per IP address (brute-force protection).
+func (s *Service) Authenticate(ctx context.Context, email, password, ipAddress string) (*user.User, error) {
 	// Validate input
 	v := validator.New()
 	v.Required(email, "email")
 	v.Required(password, "password")
 
 	if !v.Valid() {
-		return "", apperrors.NewValidationError(v.GetErrors())
+		return nil, apperrors.NewValidationError(v.GetErrors())
 	}
 
 	// Normalize email
 	email = strings.ToLower(strings.TrimSpace(email))
 
-	// Get user
+	if err := s.lockoutService.Check(ctx, email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	// Get user. Unknown accounts count as failures too, so lockouts do not reveal which exist.
 	u, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", apperrors.ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, email, ipAddress)
 	}
 
-	// Verify password (bcrypt hash contains the salt)
+	// Verify password (the hash contains the salt and parameters)
 	if !s.passwordService.VerifyPassword(password, u.PasswordHash) {
-		return "", apperrors.ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, email, ipAddress)
+	}
+
+	// Upgrade hashes made with an outdated algorithm or cost while the password is known
+	passwordHash, err := s.passwordService.Rehash(password, u.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if passwordHash != "" {
+		if err := s.repo.RehashPassword(ctx, u.ID, u.PasswordHash, passwordHash); err != nil {
+			return nil, err
+		}
+		u.PasswordHash = passwordHash
+	}
+
+	// With two-factor authentication the failures are only forgotten once the second factor
+	// is checked, otherwise knowing the password would allow guessing codes indefinitely
+	if !u.TOTPEnabled {
+		if err := s.lockoutService.Succeed(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.IsSuspended() {
+		return nil, apperrors.ErrAccountSuspended
+	}
+
+	return u, nil
+}
+
+// GetProfile retrieves the full profile of a user by ID
+func (s *Service) GetProfile(ctx context.Context, userID string) (*user.User, error) {
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	return u, nil
+}
+
+// GetPublicProfile retrieves a user by handle (with or without the leading @)
+func (s *Service) GetPublicProfile(ctx context.Context, handle string) (*user.User, error) {
+	u, err := s.repo.GetByHandle(ctx, user.NormalizeHandle(handle))
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	return u, nil
+}
+
+// UpdateProfile applies a partial profile update to the user identified by ID
+func (s *Service) UpdateProfile(ctx context.Context, userID string, update user.ProfileUpdate) (*user.User, error) {
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate input
+	v := validator.New()
+	if update.Handle != nil {
+		handle := user.NormalizeHandle(*update.Handle)
+		v.Handle(handle, "handle")
+		update.Handle = &handle
+	}
+	if update.DisplayName != nil {
+		displayName := strings.TrimSpace(*update.DisplayName)
+		v.MaxLength(displayName, 50, "displayName")
+		update.DisplayName = &displayName
+	}
+	if update.Bio != nil {
+		bio := strings.TrimSpace(*update.Bio)
+		v.MaxLength(bio, 160, "bio")
+		update.Bio = &bio
+	}
+	if update.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*update.AvatarURL)
+		if avatarURL != "" {
+			v.URL(avatarURL, "avatarUrl")
+			v.MaxLength(avatarURL, 500, "avatarUrl")
+		}
+		update.AvatarURL = &avatarURL
+	}
+
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	// Check that the new handle is free
+	if update.Handle != nil && *update.Handle != u.Handle {
+		taken, err := s.repo.HandleExists(ctx, *update.Handle)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, apperrors.ErrHandleTaken
+		}
+		u.Handle = *update.Handle
+	}
+	if update.DisplayName != nil {
+		u.DisplayName = *update.DisplayName
+	}
+	if update.Bio != nil {
+		u.Bio = *update.Bio
+	}
+	if update.AvatarURL != nil {
+		u.AvatarURL = *update.AvatarURL
+	}
+
+	if err := s.repo.UpdateProfile(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// loginFailed records a failed login attempt and returns the error to report
+func (s *Service) loginFailed(ctx context.Context, email, ipAddress string) error {
+	if err := s.lockoutService.Fail(ctx, email, ipAddress); err != nil {
+		return err
+	}
+	return apperrors.ErrInvalidCredentials
+}
+
+// generateHandle generates a random, unused handle (user_xxxxxxxx)
+func (s *Service) generateHandle(ctx context.Context) (string, error) {
+	for i := 0; i < 5; i++ {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return "", apperrors.Wrap(err, 500, "failed to generate handle")
+		}
+		handle := "user_" + hex.EncodeToString(b)
+
+		taken, err := s.repo.HandleExists(ctx, handle)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return handle, nil
+		}
 	}
 
-	return u.Email, nil
+	return "", apperrors.New(500, "failed to generate a unique handle")
 }
@@ -0,0 +1,311 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"ynov-social-api/internal/domain/user"
+	"ynov-social-api/internal/pkg/apperrors"
+	"ynov-social-api/internal/pkg/validator"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when two-factor authentication is enabled
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorEnrollment holds what a user needs to add their account to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string // otpauth:// URI, usually rendered as a QR code
+}
+
+// TwoFactorStatus describes a user's two-factor settings
+type TwoFactorStatus struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+// TwoFactorChallenge is returned by the password step of a login when a second factor is required
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+// GetTwoFactorStatus returns the two-factor settings of the user identified by ID
+func (s *Service) GetTwoFactorStatus(ctx context.Context, userID string) (*TwoFactorStatus, error) {
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{Enabled: u.TOTPEnabled}
+	if u.TOTPEnabled {
+		status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user. Two-factor authentication is only
+// enabled once a code generated from it is confirmed with ConfirmTwoFactor.
+func (s *Service) EnrollTwoFactor(ctx context.Context, userID string) (*TwoFactorEnrollment, error) {
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, apperrors.ErrTwoFactorEnabled
+	}
+
+	secret, err := s.totpService.GenerateSecret()
+	if err != nil {
+		return nil, apperrors.Wrap(err, 500, "failed to generate TOTP secret")
+	}
+
+	// Restarting an enrollment replaces the pending secret
+	u.TOTPSecret = secret
+	if err := s.repo.UpdateTwoFactor(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    s.totpService.URI(u.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves their authenticator
+// app produces valid codes, and returns single-use recovery codes (only shown this once)
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	v := validator.New()
+	v.Required(code, "code")
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, apperrors.ErrTwoFactorEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, apperrors.ErrTwoFactorNotPending
+	}
+
+	if err := s.verifyTOTP(ctx, u, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, u.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	u.TOTPEnabled = true
+	if err := s.repo.UpdateTwoFactor(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, given a current TOTP or recovery code.
+// Wrong codes count as failed logins on the account.
+func (s *Service) DisableTwoFactor(ctx context.Context, userID, code, ipAddress string) error {
+	v := validator.New()
+	v.Required(code, "code")
+	if !v.Valid() {
+		return apperrors.NewValidationError(v.GetErrors())
+	}
+
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !u.TOTPEnabled {
+		return apperrors.ErrTwoFactorDisabled
+	}
+
+	if err := s.lockoutService.Check(ctx, u.Email, ipAddress); err != nil {
+		return err
+	}
+
+	if err := s.verifySecondFactor(ctx, u, code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidOTP) {
+			if lockErr := s.lockoutService.Fail(ctx, u.Email, ipAddress); lockErr != nil {
+				return lockErr
+			}
+		}
+		return err
+	}
+
+	if err := s.lockoutService.Succeed(ctx, u.Email); err != nil {
+		return err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, u.ID, nil); err != nil {
+		return err
+	}
+
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	return s.repo.UpdateTwoFactor(ctx, u)
+}
+
+// IssueTwoFactorChallenge issues the short-lived token a user who passed Authenticate
+// exchanges, along with a second factor, in CompleteTwoFactorLogin
+func (s *Service) IssueTwoFactorChallenge(u *user.User) (*TwoFactorChallenge, error) {
+	token, err := s.jwtService.GenerateChallengeToken(u.ID, u.Email, s.challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorChallenge{
+		Token:     token,
+		ExpiresIn: s.challengeTTL,
+	}, nil
+}
+
+// CompleteTwoFactorLogin checks the second factor (TOTP or recovery code) for a login
+// challenge and returns the authenticated user. Wrong codes count as failed logins on the account.
+func (s *Service) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code, ipAddress string) (*user.User, error) {
+	v := validator.New()
+	v.Required(challengeToken, "challengeToken")
+	v.Required(code, "code")
+	if !v.Valid() {
+		return nil, apperrors.NewValidationError(v.GetErrors())
+	}
+
+	claims, err := s.jwtService.ValidateChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.repo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, apperrors.ErrInvalidChallenge
+	}
+
+	// Two-factor authentication was disabled since the challenge was issued
+	if !u.TOTPEnabled {
+		return nil, apperrors.ErrInvalidChallenge
+	}
+
+	if u.IsSuspended() {
+		return nil, apperrors.ErrAccountSuspended
+	}
+
+	if err := s.lockoutService.Check(ctx, u.Email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, u, code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidOTP) {
+			if lockErr := s.lockoutService.Fail(ctx, u.Email, ipAddress); lockErr != nil {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.lockoutService.Succeed(ctx, u.Email); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or one of the user's unused recovery codes
+func (s *Service) verifySecondFactor(ctx context.Context, u *user.User, code string) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return s.verifyTOTP(ctx, u, code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, u.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return apperrors.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+// verifyTOTP checks a TOTP code against the user's secret, refusing codes already used
+func (s *Service) verifyTOTP(ctx context.Context, u *user.User, code string) error {
+	step, ok := s.totpService.Validate(u.TOTPSecret, code, time.Now())
+	if !ok {
+		return apperrors.ErrInvalidOTP
+	}
+
+	fresh, err := s.repo.UseTOTPStep(ctx, u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return apperrors.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+// isTOTPCode reports whether the code looks like a TOTP code (digits only) rather than a recovery code
+func isTOTPCode(code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes generates recovery codes (formatted xxxxx-xxxxx) and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, apperrors.Wrap(err, 500, "failed to generate recovery codes")
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}